@@ -271,16 +271,54 @@ type FaceRenderer interface {
 	GlyphData(gid GID, xPpem, yPpem uint16) GlyphData
 }
 
+// GlyphDataKind identifies the concrete type of a GlyphData.
+type GlyphDataKind uint8
+
+const (
+	_                GlyphDataKind = iota // the zero value is not a valid kind
+	GlyphDataOutline                      // GlyphOutline
+	GlyphDataSVG                          // GlyphSVG
+	GlyphDataBitmap                       // GlyphBitmap
+)
+
 // GlyphData describe how to graw a glyph.
-// It is either an GlyphOutline, GlyphSVG or GlyphBitmap.
+// It is either an GlyphOutline, GlyphSVG or GlyphBitmap,
+// as indicated by its Kind method.
 type GlyphData interface {
 	isGlyphData()
+	// Kind returns the type of the glyph data.
+	Kind() GlyphDataKind
 }
 
 func (GlyphOutline) isGlyphData() {}
 func (GlyphSVG) isGlyphData()     {}
 func (GlyphBitmap) isGlyphData()  {}
 
+func (GlyphOutline) Kind() GlyphDataKind { return GlyphDataOutline }
+func (GlyphSVG) Kind() GlyphDataKind     { return GlyphDataSVG }
+func (GlyphBitmap) Kind() GlyphDataKind  { return GlyphDataBitmap }
+
+// AsOutline returns the outline stored in `data`, or false if `data`
+// is not a GlyphOutline (including if it is nil).
+func AsOutline(data GlyphData) (GlyphOutline, bool) {
+	out, ok := data.(GlyphOutline)
+	return out, ok
+}
+
+// AsSVG returns the SVG image stored in `data`, or false if `data`
+// is not a GlyphSVG (including if it is nil).
+func AsSVG(data GlyphData) (GlyphSVG, bool) {
+	out, ok := data.(GlyphSVG)
+	return out, ok
+}
+
+// AsBitmap returns the bitmap stored in `data`, or false if `data`
+// is not a GlyphBitmap (including if it is nil).
+func AsBitmap(data GlyphData) (GlyphBitmap, bool) {
+	out, ok := data.(GlyphBitmap)
+	return out, ok
+}
+
 // GlyphOutline exposes the path to draw for
 // vector glyph.
 // Coordinates are expressed in fonts units.
@@ -388,9 +426,16 @@ type FaceID struct {
 
 // FaceDescription is a summary of a font file.
 type FaceDescription struct {
-	Family string
+	Family  string
+	Style   Style
+	Weight  Weight
+	Stretch Stretch
+}
 
-	// TODO: more fields will be added
+// NewFaceDescription uses the given descriptor to build a description.
+func NewFaceDescription(fd FontDescriptor) FaceDescription {
+	style, weight, stretch := fd.Aspect()
+	return FaceDescription{Family: fd.Family(), Style: style, Weight: weight, Stretch: stretch}
 }
 
 // Style (also called slant) allows italic or oblique faces to be selected.
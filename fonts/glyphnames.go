@@ -0,0 +1,49 @@
+package fonts
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/benoitkugler/textlayout/fonts/glyphsnames"
+)
+
+// GlyphNameToRune maps a PostScript glyph name to a Unicode code point, following
+// the Adobe Glyph List Specification (https://github.com/adobe-type-tools/agl-specification).
+// The suffix starting with the first period is dropped (so that "A.sc" maps to 'A'), then
+// the name is looked up in the Adobe Glyph List, or decoded if it has the form "uniXXXX" or "uXXXX[XX]".
+// Names mapping to several code points (such as the ligature "f_f_i") are not supported
+// and, as ".notdef", return false.
+func GlyphNameToRune(name string) (rune, bool) {
+	if i := strings.IndexByte(name, '.'); i != -1 {
+		name = name[:i]
+	}
+	if name == "" || strings.IndexByte(name, '_') != -1 {
+		return 0, false
+	}
+	if r, ok := glyphsnames.GlyphListToRune(name); ok {
+		return r, true
+	}
+	if strings.HasPrefix(name, "uni") && len(name) == 7 {
+		return parseAGLHex(name[3:])
+	}
+	if strings.HasPrefix(name, "u") && 5 <= len(name) && len(name) <= 7 {
+		return parseAGLHex(name[1:])
+	}
+	return 0, false
+}
+
+// parseAGLHex decodes an upper case hexadecimal code point,
+// excluding surrogates and values out of the Unicode range
+func parseAGLHex(s string) (rune, bool) {
+	for _, c := range s {
+		if !('0' <= c && c <= '9' || 'A' <= c && c <= 'F') {
+			return 0, false
+		}
+	}
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil || !utf8.ValidRune(rune(v)) {
+		return 0, false
+	}
+	return rune(v), true
+}
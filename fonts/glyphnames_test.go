@@ -0,0 +1,33 @@
+package fonts
+
+import "testing"
+
+func TestGlyphNameToRune(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		r    rune
+		ok   bool
+	}{
+		{"A", 'A', true},
+		{"uni0041", 'A', true},
+		{"Adieresis", 'Ä', true},
+		{"A.sc", 'A', true},
+		{"Adieresis.alt.ss01", 'Ä', true},
+		{"u1F600", 0x1F600, true},
+		{"u0041", 'A', true},
+		{"uni20AC.fina", '€', true},
+		{".notdef", 0, false},
+		{"", 0, false},
+		{"uni004a", 0, false}, // lower case hex digits are not allowed
+		{"uniD800", 0, false}, // surrogate
+		{"u110000", 0, false}, // out of range
+		{"uni00410042", 0, false},
+		{"f_f_i", 0, false},
+		{"notAGlyphName", 0, false},
+	} {
+		r, ok := GlyphNameToRune(test.name)
+		if ok != test.ok || r != test.r {
+			t.Errorf("%s: expected %q (%v), got %q (%v)", test.name, test.r, test.ok, r, ok)
+		}
+	}
+}
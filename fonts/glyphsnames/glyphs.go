@@ -45,6 +45,13 @@ func GlyphToRune(glyph string) (rune, bool) {
 	return 0, false
 }
 
+// GlyphListToRune only looks up `glyph` in the Adobe Glyph List,
+// without any other naming convention.
+func GlyphListToRune(glyph string) (rune, bool) {
+	r, ok := glyphlistGlyphToRuneMap[glyph]
+	return r, ok
+}
+
 var (
 	reEncoding    = regexp.MustCompile(`^[A-Za-z](\d{1,5})$`) // C211
 	reUniEncoding = regexp.MustCompile(`^uni([\dA-F]{4})$`)   // uniFB03
@@ -0,0 +1,44 @@
+package fonts
+
+import "strings"
+
+// Matcher selects the font best matching a query among a small set of fonts,
+// using a simple scoring function. It is intended for applications
+// bundling a handful of fonts, for which the full fontconfig machinery is not needed.
+// Faces are typically built with NewFaceDescription.
+type Matcher []FaceDescription
+
+// Match returns the index of the face best matching the query,
+// or -1 if the matcher is empty.
+// The family is compared first (case insensitively), then the distance between the weights
+// (400 for regular, 700 for bold), and finally the style, where oblique faces are
+// accepted as italic ones.
+func (m Matcher) Match(family string, bold, italic bool) int {
+	target := WeightNormal
+	if bold {
+		target = WeightBold
+	}
+	best, bestScore := -1, float32(0)
+	for i, face := range m {
+		var score float32
+		if !strings.EqualFold(face.Family, family) {
+			score += 10000 // more than any weight distance
+		}
+		weight := face.Weight
+		if weight == 0 { // not found
+			weight = WeightNormal
+		}
+		if weight > target {
+			score += 2 * float32(weight-target)
+		} else {
+			score += 2 * float32(target-weight)
+		}
+		if isItalic := face.Style == StyleItalic || face.Style == StyleOblique; isItalic != italic {
+			score += 1 // less than any weight distance
+		}
+		if best == -1 || score < bestScore {
+			best, bestScore = i, score
+		}
+	}
+	return best
+}
@@ -0,0 +1,64 @@
+package fonts
+
+import "testing"
+
+type testDescriptor struct {
+	family string
+	style  Style
+	weight Weight
+}
+
+func (fd testDescriptor) Family() string { return fd.family }
+
+func (fd testDescriptor) Aspect() (Style, Weight, Stretch) {
+	return fd.style, fd.weight, StretchNormal
+}
+
+func (fd testDescriptor) AdditionalStyle() string { return "" }
+
+func (fd testDescriptor) LoadCmap() (Cmap, error) { return nil, nil }
+
+func TestMatcher(t *testing.T) {
+	var m Matcher
+	if m.Match("DejaVu Sans", true, true) != -1 {
+		t.Fatal("expected no match for empty matcher")
+	}
+
+	for _, desc := range []testDescriptor{
+		{"DejaVu Sans", StyleNormal, WeightNormal},
+		{"DejaVu Sans", StyleOblique, WeightNormal},
+		{"DejaVu Sans", StyleNormal, WeightBold},
+		{"DejaVu Sans", StyleOblique, WeightBold},
+		{"DejaVu Serif", StyleItalic, WeightBold},
+		{"Amiri", StyleNormal, WeightNormal},
+	} {
+		m = append(m, NewFaceDescription(desc))
+	}
+
+	for _, test := range []struct {
+		family       string
+		bold, italic bool
+		expected     int
+	}{
+		{"DejaVu Sans", true, true, 3},
+		{"dejavu sans", false, false, 0},
+		{"DejaVu Sans", false, true, 1},
+		{"DejaVu Serif", true, true, 4},
+		{"DejaVu Serif", false, false, 4}, // only one face in the family
+		{"Amiri", true, true, 5},
+		{"Unknown", true, true, 3}, // best style among all faces
+	} {
+		if got := m.Match(test.family, test.bold, test.italic); got != test.expected {
+			t.Errorf("%s (bold: %v, italic: %v): expected %d, got %d", test.family, test.bold, test.italic, test.expected, got)
+		}
+	}
+
+	// weight is compared before style
+	m = Matcher{
+		{Family: "DejaVu Sans", Style: StyleItalic, Weight: WeightNormal},
+		{Family: "DejaVu Sans", Style: StyleNormal, Weight: WeightBold},
+	}
+	if got := m.Match("DejaVu Sans", true, true); got != 1 {
+		t.Errorf("expected bold face, got %d", got)
+	}
+}
@@ -33,12 +33,23 @@ const (
 	// preceded by up to a maximum of 48 operands". 5177.Type2.pdf Appendix B
 	// "Type 2 Charstring Implementation Limits" says that "Argument stack 48".
 	// T1_SPEC.pdf 6.1 Encoding as a limitation of 24.
+	// See ArgStackSizer for CFF2.
 	psArgStackSize = 48
 
+	// MaxCFF2ArgStackSize is the argument stack limit of CFF2 DICTs,
+	// and the maximum value of the maxstack operator for CFF2 charstrings.
+	MaxCFF2ArgStackSize = 513
+
 	// Similarly, Appendix B says "Subr nesting, stack limit 10".
 	psCallStackSize = 10
 
 	maxRealNumberStrLen = 64 // Maximum length in bytes of the "-123.456E-7" representation.
+
+	// psMaxOperators is the maximum number of operators executed in one `Run` call.
+	// Since subroutines may call each other several times, the call stack limit
+	// is not enough to prevent exponential execution time on malicious fonts.
+	// This is the same limit as HarfBuzz (HB_CFF_MAX_OPS).
+	psMaxOperators = 10000
 )
 
 // PsContext is the flavour of the Postcript language.
@@ -52,7 +63,12 @@ const (
 )
 
 type ArgStack struct {
-	Vals [psArgStackSize]int32
+	// Vals has the length of the stack limit,
+	// which is set when starting to run instructions.
+	Vals []int32
+	// isReal has the same length as Vals, and records
+	// the values pushed as real numbers (see Number).
+	isReal []bool
 	// Effecive size currently in use. The first value to
 	// pop is at index Top-1
 	Top int32
@@ -68,6 +84,33 @@ func (a *ArgStack) Float() float32 {
 	return math.Float32frombits(uint32(a.Vals[a.Top-1]))
 }
 
+// Number returns the value at index `i` as a real number, without popping the stack.
+// Both integers and real numbers (stored as their binary representation) are supported,
+// using the operand encoding recorded when the interpreter pushed the value.
+// It should not be used for values written directly in `Vals`.
+func (a *ArgStack) Number(i int32) float32 {
+	if a.isReal[i] {
+		return math.Float32frombits(uint32(a.Vals[i]))
+	}
+	return float32(a.Vals[i])
+}
+
+// setLimit resizes the stack, re-using the previous storage if possible.
+// `limit` is clamped to ]0, MaxCFF2ArgStackSize].
+func (a *ArgStack) setLimit(limit int) {
+	if limit <= 0 {
+		limit = psArgStackSize
+	} else if limit > MaxCFF2ArgStackSize {
+		limit = MaxCFF2ArgStackSize
+	}
+	if cap(a.Vals) < limit {
+		a.Vals = make([]int32, limit)
+		a.isReal = make([]bool, limit)
+	}
+	a.Vals = a.Vals[:limit]
+	a.isReal = a.isReal[:limit]
+}
+
 // Pop returns the top level value and decrease `Top`
 // It will panic if the stack is empty.
 func (a *ArgStack) Pop() int32 {
@@ -143,10 +186,25 @@ func (p *Machine) Run(instructions []byte, localSubrs, globalSubrs [][]byte, han
 	p.instructions = instructions
 	p.localSubrs = localSubrs
 	p.globalSubrs = globalSubrs
+	p.ArgStack.setLimit(psArgStackSize)
+	if sizer, ok := handler.(ArgStackSizer); ok {
+		p.ArgStack.setLimit(sizer.ArgStackSize())
+	}
 	p.ArgStack.Top = 0
 	p.callStack.top = 0
 
-	for len(p.instructions) > 0 {
+	var nbOperators int
+	for {
+		if len(p.instructions) == 0 {
+			if p.callStack.top == 0 {
+				break
+			}
+			// CFF2 subroutines have no 'return' operator:
+			// reaching the end of a subroutine returns to its caller
+			p.Return()
+			continue
+		}
+
 		// Push a numeric operand on the stack, if applicable.
 		if hasResult, err := p.parseNumber(); hasResult {
 			if err != nil {
@@ -169,6 +227,11 @@ func (p *Machine) Run(instructions []byte, localSubrs, globalSubrs [][]byte, han
 			p.instructions = p.instructions[1:]
 		}
 
+		nbOperators++
+		if nbOperators > psMaxOperators {
+			return errors.New("maximum number of operators reached")
+		}
+
 		err := handler.Apply(PsOperator{Operator: b, IsEscaped: escaped}, p)
 		if err == ErrInterrupt { // stop cleanly
 			return nil
@@ -183,7 +246,7 @@ func (p *Machine) Run(instructions []byte, localSubrs, globalSubrs [][]byte, han
 
 // See 5176.CFF.pdf section 4 "DICT Data".
 func (p *Machine) parseNumber() (hasResult bool, err error) {
-	number := int32(0)
+	number, isReal := int32(0), false
 	switch b := p.instructions[0]; {
 	case b == 28:
 		if len(p.instructions) < 3 {
@@ -224,7 +287,7 @@ func (p *Machine) parseNumber() (hasResult bool, err error) {
 					if err != nil {
 						return true, errInvalidCFFTable
 					}
-					number, hasResult = int32(math.Float32bits(float32(f))), true
+					number, hasResult, isReal = int32(math.Float32bits(float32(f))), true, true
 					break loop
 				}
 				if nib == 0x0d {
@@ -256,7 +319,15 @@ func (p *Machine) parseNumber() (hasResult bool, err error) {
 		b1 := p.instructions[1]
 		p.instructions = p.instructions[2:]
 		number, hasResult = -int32(b-251)*256-int32(b1)-108, true
-	case b == 255 && (p.ctx == Type2Charstring || p.ctx == Type1Charstring):
+	case b == 255 && p.ctx == Type2Charstring:
+		if len(p.instructions) < 5 {
+			return true, errInvalidCFFTable
+		}
+		// 16.16 fixed number, rounded since the stack only stores integers
+		number, hasResult = (int32(be.Uint32(p.instructions[1:]))+1<<15)>>16, true
+		p.instructions = p.instructions[5:]
+
+	case b == 255 && p.ctx == Type1Charstring:
 		if len(p.instructions) < 5 {
 			return true, errInvalidCFFTable
 		}
@@ -265,10 +336,11 @@ func (p *Machine) parseNumber() (hasResult bool, err error) {
 	}
 
 	if hasResult {
-		if p.ArgStack.Top == psArgStackSize {
+		if int(p.ArgStack.Top) == len(p.ArgStack.Vals) {
 			return true, errInvalidCFFTable
 		}
 		p.ArgStack.Vals[p.ArgStack.Top] = number
+		p.ArgStack.isReal[p.ArgStack.Top] = isReal
 		p.ArgStack.Top++
 	}
 	return hasResult, nil
@@ -362,6 +434,16 @@ func (p PsOperator) String() string {
 	return fmt.Sprintf("1-byte operator (%d)", p.Operator)
 }
 
+// ArgStackSizer may be implemented by a PsOperatorHandler accepting
+// more (or less) operands than the default limit of 48, which is the case
+// of CFF2 DICTs and charstrings.
+type ArgStackSizer interface {
+	// ArgStackSize returns the maximum number of operands on the stack.
+	// It is clamped to MaxCFF2ArgStackSize, and a non positive value
+	// selects the default limit.
+	ArgStackSize() int
+}
+
 // PsOperatorHandler defines the behaviour of an operator.
 type PsOperatorHandler interface {
 	// Context defines the precise behaviour of the interpreter,
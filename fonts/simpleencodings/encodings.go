@@ -65,3 +65,27 @@ func (e Encoding) NameToByte() map[string]byte {
 	}
 	return out
 }
+
+// NameToCode returns the (smallest) code mapped to `name`,
+// or false if `name` is not in the encoding.
+func (e Encoding) NameToCode(name string) (byte, bool) {
+	if name == "" {
+		return 0, false
+	}
+	for b, n := range e {
+		if n == name {
+			return byte(b), true
+		}
+	}
+	return 0, false
+}
+
+// Merge returns a copy of the encoding, with the codes in `differences`
+// mapped to their new names, as done by the Differences array of PDF encodings.
+// The receiver is not modified, so that predefined encodings may be used as base.
+func (e Encoding) Merge(differences map[byte]string) Encoding {
+	for b, name := range differences {
+		e[b] = name
+	}
+	return e
+}
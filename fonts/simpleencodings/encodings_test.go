@@ -30,3 +30,42 @@ func TestRuneNames(t *testing.T) {
 		}
 	}
 }
+
+func TestNameToCode(t *testing.T) {
+	for _, e := range encs {
+		for name, b := range e.NameToByte() {
+			code, ok := e.NameToCode(name)
+			if !ok || e[code] != name {
+				t.Fatalf("invalid code %d for %s", code, name)
+			}
+			if code > b {
+				t.Fatalf("expected smallest code for %s, got %d", name, code)
+			}
+		}
+	}
+	if code, ok := WinAnsi.NameToCode("A"); !ok || code != 'A' {
+		t.Fatalf("expected code 65, got %d", code)
+	}
+	if _, ok := WinAnsi.NameToCode("notAGlyphName"); ok {
+		t.Fatal("unexpected code for invalid name")
+	}
+	if _, ok := WinAnsi.NameToCode(""); ok {
+		t.Fatal("unexpected code for empty name")
+	}
+}
+
+func TestMerge(t *testing.T) {
+	merged := WinAnsi.Merge(map[byte]string{65: "Adieresis", 200: "Lslash"})
+	if merged[65] != "Adieresis" || merged[200] != "Lslash" {
+		t.Fatalf("differences not applied: %s %s", merged[65], merged[200])
+	}
+	if merged[66] != "B" {
+		t.Fatalf("expected base encoding for unmodified code, got %s", merged[66])
+	}
+	if code, _ := merged.NameToCode("Adieresis"); code != 65 {
+		t.Fatalf("expected code 65, got %d", code)
+	}
+	if WinAnsi[65] != "A" {
+		t.Fatal("base encoding should not be modified")
+	}
+}
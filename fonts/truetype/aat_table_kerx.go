@@ -320,6 +320,9 @@ func parseKernxSubtable2(data []byte, headerLength int, extended bool, numGlyphs
 			return out, err
 		}
 	} else {
+		if len(data) < int(leftOffset) || len(data) < int(rightOffset) {
+			return out, errors.New("invalid kern subtable format 2 (EOF)")
+		}
 		out.left, err = parseClassFormat1(data[leftOffset:], 2)
 		if err != nil {
 			return out, fmt.Errorf("invalid kern subtable format 2: %s", err)
@@ -68,7 +68,7 @@ func parseMorxChain23(data []byte, numGlyphs int) (out MorxChain, size int, err
 	nFeatures := binary.BigEndian.Uint32(data[8:])
 	nSubtables := binary.BigEndian.Uint32(data[12:])
 
-	if len(data) < 12*int(nFeatures) {
+	if len(data) < 16+12*int(nFeatures) {
 		return out, 0, errors.New("invalid morx table (EOF)")
 	}
 	out.Features = make([]AATFeature, nFeatures)
@@ -185,7 +185,8 @@ func (b bitmapGlyphData) glyphExtents() (out fonts.GlyphExtents, ok bool) {
 }
 
 func parseBitmapGlyphData(data []byte, offsetStart, offsetNext uint32) (out bitmapGlyphData, err error) {
-	if len(data) < int(offsetStart)+8 || offsetStart+8 > offsetNext {
+	// use int to avoid uint32 overflows
+	if int(offsetStart)+8 > int(offsetNext) || int(offsetNext) > len(data) {
 		return out, errors.New("invalid 'sbix' bitmap glyph data (EOF)")
 	}
 	data = data[offsetStart:]
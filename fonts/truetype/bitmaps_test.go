@@ -51,6 +51,19 @@ func TestSbix(t *testing.T) {
 	}
 }
 
+func TestSbixInvalidGlyphData(t *testing.T) {
+	data := make([]byte, 16)
+	for _, offsets := range [][2]uint32{
+		{0xFFFFFFFC, 4}, // offsetStart + 8 overflows
+		{4, 20},         // EOF
+		{8, 12},         // too short
+	} {
+		if _, err := parseBitmapGlyphData(data, offsets[0], offsets[1]); err == nil {
+			t.Fatalf("expected error for offsets %v", offsets)
+		}
+	}
+}
+
 func TestCblc(t *testing.T) {
 	for _, filename := range []string{
 		"ToyCBLC1.ttf",
@@ -6,6 +6,7 @@ package truetype
 
 import (
 	"errors"
+	"io"
 
 	"github.com/benoitkugler/textlayout/fonts"
 	type1c "github.com/benoitkugler/textlayout/fonts/type1C"
@@ -48,6 +49,7 @@ type Font struct {
 	cmap         Cmap
 	cmapVar      unicodeVariations
 	cmapEncoding fonts.CmapEncoding
+	cmapCache    *cmapCache // speeds up NominalGlyph, may be nil
 
 	Names TableName
 
@@ -59,12 +61,14 @@ type Font struct {
 
 	// Optionnal, only present in variable fonts
 
-	varCoords  []float32   // coordinates in usage, may be nil
-	hvar, vvar *tableHVvar // optional
+	varCoords  []float32    // coordinates in usage, may be nil
+	cff2       *type1c.Font // optional
+	hvar, vvar *tableHVvar  // optional
 	avar       tableAvar
 	mvar       TableMvar
 	gvar       tableGvar
 	fvar       TableFvar
+	stat       *STATInfo // optional
 
 	Glyf       TableGlyf
 	vmtx, Hmtx TableHVmtx
@@ -95,6 +99,32 @@ type Font struct {
 
 	// HasHint is true if the font has a prep table.
 	HasHint bool
+
+	hinting *hintingState // TrueType instructions, loaded on demand, may be nil
+
+	tables map[Tag]tableSection // table directory, see Tables
+	file   io.ReaderAt          // the parsed file, used by RawTable
+}
+
+// Tables returns the table directory of the font, sorted by tag.
+func (font *Font) Tables() []TableInfo {
+	return tablesInfo(font.tables)
+}
+
+// RawTable returns the binary content of the table `tag`, or false if the
+// font has no such table or if it can't be read.
+// Since the tables are read on demand, the file used to parse the font
+// is retained by the font, and must not be closed (or modified) as long as
+// RawTable is used.
+// RawTable is safe for concurrent use, provided the file supports
+// concurrent calls to ReadAt.
+func (font *Font) RawTable(tag Tag) ([]byte, bool) {
+	s, ok := font.tables[tag]
+	if !ok || font.file == nil {
+		return nil, false
+	}
+	out, err := readTable(font.file, s)
+	return out, err == nil
 }
 
 // LayoutTables exposes advanced layout tables.
@@ -3,7 +3,10 @@ package truetype
 import (
 	"bytes"
 	"crypto/rand"
+	"encoding/binary"
 	"fmt"
+	"math"
+	"sync"
 	"testing"
 
 	testdata "github.com/benoitkugler/textlayout-testdata/truetype"
@@ -166,6 +169,81 @@ func TestMetrics(t *testing.T) {
 	fmt.Println(ext.Width, ext.XBearing)
 }
 
+func TestScale(t *testing.T) {
+	for _, filename := range []string{"DejaVuSerif.ttf", "ToyCMAP12.otf"} {
+		font := loadFont(t, filename)
+		upem := int16(font.Upem())
+		for _, ppem := range []float32{8, 12, 13.5, 72} {
+			if got := font.Scale(upem, ppem); got != ppem {
+				t.Fatalf("expected em square of %g pixels, got %g", ppem, got)
+			}
+			if got := font.Scale(-upem/2, ppem); got != -ppem/2 {
+				t.Fatalf("expected half em of %g pixels, got %g", -ppem/2, got)
+			}
+			// the factor is rounded
+			if got := float32(upem) * font.ScaleFactor(ppem); math.Abs(float64(got-ppem)) > 1e-4 {
+				t.Fatalf("expected em square of %g pixels, got %g", ppem, got)
+			}
+		}
+	}
+}
+
+func TestCoversViaDecomposition(t *testing.T) {
+	font := loadFont(t, "Castoro-Regular.ttf")
+	for _, r := range []rune{
+		'a', 'é',
+		0x01F0, // j with caron : j + U+030C
+		0x01D6, // u with diaeresis and macron : ü + U+0304, then u + U+0308 + U+0304
+	} {
+		if !font.CoversViaDecomposition(r) {
+			t.Fatalf("expected coverage for %U", r)
+		}
+	}
+	if _, ok := font.NominalGlyph(0x01F0); ok {
+		t.Fatal("expected no precomposed glyph")
+	}
+	// U+0F73 decomposes to U+0F71 U+0F72
+	if font.CoversViaDecomposition(0x0F73) || font.CoversViaDecomposition(0x4E00) {
+		t.Fatal("unexpected coverage")
+	}
+}
+
+func TestOpticalSize(t *testing.T) {
+	font := loadFont(t, "TestCFF2VF.otf")
+	info, ok := font.OpticalSize()
+	if !ok {
+		t.Fatal("missing size feature")
+	}
+	if exp := (OpticalSizeInfo{DesignSize: 10}); info != exp {
+		t.Fatalf("expected %v, got %v", exp, info)
+	}
+
+	font = loadFont(t, "DejaVuSerif.ttf")
+	if _, ok := font.OpticalSize(); ok {
+		t.Fatal("unexpected size feature")
+	}
+}
+
+func TestOpticalSizeValid(t *testing.T) {
+	for _, test := range []struct {
+		info  OpticalSizeInfo
+		valid bool
+	}{
+		{OpticalSizeInfo{DesignSize: 10}, true},
+		{OpticalSizeInfo{}, false},
+		{OpticalSizeInfo{DesignSize: 10, SubfamilyNameID: 256, RangeStart: 8, RangeEnd: 12}, true},
+		{OpticalSizeInfo{DesignSize: 10, SubfamilyNameID: 256, RangeStart: 10, RangeEnd: 12}, true},
+		{OpticalSizeInfo{DesignSize: 10, SubfamilyNameID: 256, RangeStart: 8, RangeEnd: 10}, true},
+		{OpticalSizeInfo{DesignSize: 10, SubfamilyNameID: 256, RangeStart: 11, RangeEnd: 12}, false},
+		{OpticalSizeInfo{DesignSize: 10, SubfamilyNameID: 256, RangeStart: 8, RangeEnd: 9}, false},
+		{OpticalSizeInfo{DesignSize: 10, SubfamilyNameID: 10, RangeStart: 8, RangeEnd: 12}, false},
+	} {
+		if got := test.info.isValid(); got != test.valid {
+			t.Fatalf("%v: expected %v, got %v", test.info, test.valid, got)
+		}
+	}
+}
+
 func TestScanDescription(t *testing.T) {
 	for _, filename := range []string{
 		"Roboto-BoldItalic.ttf",
@@ -199,3 +277,75 @@ func TestScanDescription(t *testing.T) {
 		}
 	}
 }
+
+func tableChecksum(data []byte) uint32 {
+	var sum uint32
+	for len(data)%4 != 0 {
+		data = append(data, 0)
+	}
+	for i := 0; i < len(data); i += 4 {
+		sum += binary.BigEndian.Uint32(data[i:])
+	}
+	return sum
+}
+
+func TestRawTables(t *testing.T) {
+	for _, filename := range []string{
+		"Roboto-BoldItalic.ttf",
+		"open-sans-v15-latin-regular.woff",
+	} {
+		font := loadFont(t, filename)
+		tables := font.Tables()
+		if len(tables) == 0 {
+			t.Fatal("missing tables")
+		}
+		var hasHead bool
+		for i, table := range tables {
+			if i > 0 && tables[i-1].Tag >= table.Tag {
+				t.Fatalf("tables not sorted: %s %s", tables[i-1].Tag, table.Tag)
+			}
+			hasHead = hasHead || table.Tag == tagHead
+			if table.Tag == tagHead { // checksum is not meaningful for head
+				continue
+			}
+			raw, ok := font.RawTable(table.Tag)
+			if !ok {
+				t.Fatalf("can't read table %s", table.Tag)
+			}
+			if sum := tableChecksum(raw); sum != table.Checksum {
+				t.Fatalf("%s: invalid checksum for table %s: %d != %d", filename, table.Tag, sum, table.Checksum)
+			}
+		}
+		if !hasHead {
+			t.Fatal("missing head table")
+		}
+
+		head, ok := font.RawTable(tagHead)
+		if !ok || len(head) != 54 {
+			t.Fatalf("invalid head table %v", head)
+		}
+		if magic := binary.BigEndian.Uint32(head[12:]); magic != 0x5F0F3CF5 {
+			t.Fatalf("invalid head magic number %x", magic)
+		}
+		if upem := binary.BigEndian.Uint16(head[18:]); upem != font.Upem() {
+			t.Fatalf("expected upem %d, got %d", font.Upem(), upem)
+		}
+
+		if _, ok := font.RawTable(MustNewTag("XXXX")); ok {
+			t.Fatal("unexpected table")
+		}
+
+		// RawTable may be used concurrently
+		var wg sync.WaitGroup
+		for _, table := range tables {
+			wg.Add(1)
+			go func(table TableInfo) {
+				defer wg.Done()
+				if _, ok := font.RawTable(table.Tag); !ok {
+					t.Errorf("can't read table %s", table.Tag)
+				}
+			}(table)
+		}
+		wg.Wait()
+	}
+}
@@ -0,0 +1,101 @@
+//go:build go1.18
+// +build go1.18
+
+package truetype
+
+import (
+	"bytes"
+	"testing"
+
+	testdata "github.com/benoitkugler/textlayout-testdata/truetype"
+)
+
+// Run with, for instance,
+//	go test -fuzz FuzzParseFont ./fonts/truetype
+// Without the -fuzz flag, only the seed corpus is checked.
+
+// small fonts, used as seed corpus
+var fuzzSeedFonts = []string{
+	"ToyCMAP12.otf",
+	"ToyCMAP14.otf",
+	"ToyFeat.ttf",
+	"ToyGPOSCursive.ttf",
+	"ToyGSUBLigature.ttf",
+	"ToyIndicGSUB.ttf",
+	"ToyKern1.ttf",
+	"ToySbix.ttf",
+	"ToyTrak.ttf",
+	"ToyVar1.ttf",
+	"ToyCBLC1.ttf",
+	"ToyTTC.ttc",
+	"04B_30.ttf",
+}
+
+// addSeeds adds the font files (if `tag` is zero) or the raw `tag`
+// tables found in the seed fonts.
+func addSeeds(f *testing.F, tag Tag) {
+	for _, filename := range fuzzSeedFonts {
+		file, err := testdata.Files.ReadFile(filename)
+		if err != nil {
+			f.Fatal(err)
+		}
+		if tag == 0 {
+			f.Add(file)
+			continue
+		}
+		parsers, err := NewFontParsers(bytes.NewReader(file))
+		if err != nil {
+			f.Fatal(err)
+		}
+		for _, pr := range parsers {
+			if table, err := pr.GetRawTable(tag); err == nil {
+				f.Add(table)
+			}
+		}
+	}
+}
+
+func FuzzParseFont(f *testing.F) {
+	addSeeds(f, 0)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		font, err := Parse(bytes.NewReader(data))
+		if err != nil {
+			return
+		}
+		// exercise the lazy accessors
+		font.LayoutTables()
+		font.GlyphData(0, 12, 12)
+		font.NominalGlyph('a')
+	})
+}
+
+func FuzzParseGPOS(f *testing.F) {
+	addSeeds(f, TagGpos)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		_, _ = parseTableGPOS(data)
+	})
+}
+
+func FuzzParseGSUB(f *testing.F) {
+	addSeeds(f, TagGsub)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		_, _ = parseTableGSUB(data)
+	})
+}
+
+func FuzzParseCmap(f *testing.F) {
+	addSeeds(f, tagCmap)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		cmaps, err := parseTableCmap(data)
+		if err != nil {
+			return
+		}
+		for _, cmap := range cmaps.Cmaps {
+			iter := cmap.Cmap.Iter()
+			for i := 0; i < 1000 && iter.Next(); i++ {
+				r, _ := iter.Char()
+				cmap.Cmap.Lookup(r)
+			}
+		}
+	})
+}
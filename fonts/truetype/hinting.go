@@ -0,0 +1,175 @@
+package truetype
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/benoitkugler/textlayout/fonts"
+)
+
+// hintingTables stores the font wide data
+// required to execute TrueType instructions.
+type hintingTables struct {
+	fpgm, prep []byte
+	cvt        []int16 // in font units
+
+	// from the 'maxp' table, version 1.0
+	maxTwilightPoints uint16
+	maxStorage        uint16
+	maxStackElements  uint16
+}
+
+// maxHintedSizes is the number of sizes for which
+// the state of the interpreter is cached, see hintingState
+const maxHintedSizes = 8
+
+// hintingState loads the hinting tables of a font on the first hinted glyph,
+// so that fonts which are never hinted do not pay for them.
+// It also caches the state of the interpreter after the 'fpgm' and 'prep'
+// programs, for the most recently used sizes, since running them
+// is costly compared to the glyph programs.
+// It is safe for concurrent use.
+type hintingState struct {
+	once   sync.Once
+	tables hintingTables
+	err    error // from loading the tables
+
+	lock     sync.Mutex
+	maxSizes int              // 0 disables caching
+	sizes    []preparedHinter // the most recently used last
+}
+
+type preparedHinter struct {
+	ppem uint16
+	h    *hinter // never modified once prepared: see hinter.clone
+	err  error   // error from the font programs
+}
+
+func newHintingState() *hintingState { return &hintingState{maxSizes: maxHintedSizes} }
+
+// hinter returns an hinter ready to execute glyph programs, reusing the
+// font programs previously run for `ppem`.
+func (s *hintingState) hinter(f *Font, ppem uint16) (*hinter, error) {
+	s.once.Do(func() { s.tables, s.err = f.hintingTables() })
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	if s.maxSizes == 0 {
+		h := newHinter(&s.tables, f.upem, ppem)
+		return h, h.loadFont()
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	var (
+		prepared preparedHinter
+		found    bool
+	)
+	for i, p := range s.sizes {
+		if p.ppem == ppem {
+			prepared, found = p, true
+			s.sizes = append(s.sizes[:i], s.sizes[i+1:]...)
+			break
+		}
+	}
+	if !found {
+		h := newHinter(&s.tables, f.upem, ppem)
+		prepared = preparedHinter{ppem: ppem, h: h, err: h.loadFont()}
+		if len(s.sizes) >= s.maxSizes { // evict the least recently used size
+			s.sizes = append(s.sizes[:0], s.sizes[len(s.sizes)-s.maxSizes+1:]...)
+		}
+	}
+	s.sizes = append(s.sizes, prepared)
+
+	if prepared.err != nil {
+		return nil, prepared.err
+	}
+	return prepared.h.clone(), nil
+}
+
+// parses the fields of the 'maxp' table related to hinting
+func (t *hintingTables) parseMaxp(data []byte) error {
+	if len(data) < 32 || binary.BigEndian.Uint32(data) != 0x00010000 {
+		return errors.New("invalid maxp table for hinting")
+	}
+	t.maxTwilightPoints = binary.BigEndian.Uint16(data[16:])
+	t.maxStorage = binary.BigEndian.Uint16(data[18:])
+	t.maxStackElements = binary.BigEndian.Uint16(data[24:])
+	return nil
+}
+
+// hintingTables loads the 'fpgm', 'prep' and 'cvt ' tables.
+// Missing tables are not an error, since they are all optional.
+func (f *Font) hintingTables() (out hintingTables, err error) {
+	out.fpgm, _ = f.RawTable(tagFpgm)
+	out.prep, _ = f.RawTable(TagPrep)
+	if cvt, ok := f.RawTable(tagCvt); ok {
+		values, _ := parseUint16s(cvt, len(cvt)/2)
+		out.cvt = make([]int16, len(values))
+		for i, v := range values {
+			out.cvt[i] = int16(v)
+		}
+	}
+
+	maxp, ok := f.RawTable(tagMaxp)
+	if !ok {
+		return out, errors.New("missing maxp table for hinting")
+	}
+	err = out.parseMaxp(maxp)
+	return out, err
+}
+
+// HintedGlyphOutline returns the outline of `gid`, grid-fitted by the
+// TrueType instructions of the font for a size of `ppem` pixels per em.
+// The returned points are expressed in font units, so that, once scaled
+// by ppem / Upem(), the hinted features land on the pixel grid.
+//
+// Only the vertical direction is hinted : the horizontal
+// moves are discarded, as the FreeType v40 interpreter does.
+// An error is returned for glyphs without instructions, for composite
+// glyphs, and when the interpreter fails (for instance because of an
+// unsupported instruction), in which case the unhinted outline should be used.
+func (f *Font) HintedGlyphOutline(gid GID, ppem uint16) (fonts.GlyphOutline, error) {
+	if int(gid) >= len(f.Glyf) {
+		return fonts.GlyphOutline{}, fmt.Errorf("out of range glyph %d", gid)
+	}
+	if ppem == 0 {
+		return fonts.GlyphOutline{}, errors.New("invalid ppem 0")
+	}
+	glyph, ok := f.Glyf[gid].data.(simpleGlyphData)
+	if !ok {
+		return fonts.GlyphOutline{}, fmt.Errorf("hinting not supported for glyph %d", gid)
+	}
+	if len(glyph.instructions) == 0 {
+		return fonts.GlyphOutline{}, fmt.Errorf("no instructions for glyph %d", gid)
+	}
+
+	var points []contourPoint
+	f.getPointsForGlyph(gid, 0, &points)
+	if len(points) < phantomCount {
+		return fonts.GlyphOutline{}, fmt.Errorf("invalid glyph %d", gid)
+	}
+
+	if f.hinting == nil {
+		return fonts.GlyphOutline{}, errors.New("hinting: missing font programs")
+	}
+	h, err := f.hinting.hinter(f, ppem)
+	if err != nil {
+		return fonts.GlyphOutline{}, err
+	}
+	hinted, err := h.hintGlyph(points, glyph.endPtsOfContours, glyph.instructions)
+	if err != nil {
+		return fonts.GlyphOutline{}, err
+	}
+
+	// convert back to font units, keeping the original horizontal positions
+	factor := float32(f.upem) / float32(64*int32(ppem))
+	for i := range points {
+		points[i].Y = float32(hinted[i].y) * factor
+	}
+	segments := buildSegments(points[:len(points)-phantomCount])
+	return fonts.GlyphOutline{Segments: segments}, nil
+}
@@ -0,0 +1,1363 @@
+package truetype
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// this file implements an interpreter for the TrueType instructions.
+// See https://docs.microsoft.com/en-us/typography/opentype/spec/tt_instructions
+// and the FreeType implementation (ttinterp.c).
+//
+// Coordinates and distances are expressed in 26.6 fixed point numbers,
+// and vectors in 2.14 fixed point numbers.
+
+const (
+	maxCallDepth        = 64      // protect against malicious fonts
+	maxInstructionCount = 1000000 // protect against infinite loops
+	stackSlack          = 32      // some fonts underestimate maxStackElements
+)
+
+const (
+	touchedX = 1 << iota
+	touchedY
+)
+
+type hintPoint struct{ x, y int32 }
+
+// hintZone is either the twilight zone or the glyph zone.
+type hintZone struct {
+	orig, cur []hintPoint
+	touched   []uint8
+	onCurve   []bool
+	ends      []int // last point of each contour, only for the glyph zone
+}
+
+func newHintZone(size int) hintZone {
+	return hintZone{
+		orig:    make([]hintPoint, size),
+		cur:     make([]hintPoint, size),
+		touched: make([]uint8, size),
+		onCurve: make([]bool, size),
+	}
+}
+
+type roundState uint8
+
+const (
+	roundToGrid roundState = iota
+	roundToHalfGrid
+	roundToDoubleGrid
+	roundDownToGrid
+	roundUpToGrid
+	roundOff
+	roundSuper
+)
+
+type graphicsState struct {
+	pv, fv, dv [2]int32 // projection, freedom and dual projection vectors
+	rp         [3]int32 // reference points
+	zp         [3]int32 // zone pointers, 0 for the twilight zone, 1 for the glyph zone
+	loop       int32
+
+	minDist                       int32
+	cvtCutIn                      int32
+	singleWidthCutIn, singleWidth int32
+	deltaBase, deltaShift         int32
+
+	round                                   roundState
+	roundPeriod, roundPhase, roundThreshold int32
+
+	autoFlip        bool
+	instructControl int32
+}
+
+var defaultGraphicsState = graphicsState{
+	pv:             [2]int32{0x4000, 0},
+	fv:             [2]int32{0x4000, 0},
+	dv:             [2]int32{0x4000, 0},
+	zp:             [3]int32{1, 1, 1},
+	loop:           1,
+	minDist:        64,
+	cvtCutIn:       68, // 17/16 pixel
+	deltaBase:      9,
+	deltaShift:     3,
+	round:          roundToGrid,
+	roundPeriod:    64,
+	roundThreshold: 32,
+	autoFlip:       true,
+}
+
+// resetVectors restores the values which are not
+// inherited from the 'prep' program by the glyph programs
+func (gs *graphicsState) resetVectors() {
+	gs.pv, gs.fv, gs.dv = defaultGraphicsState.pv, defaultGraphicsState.fv, defaultGraphicsState.dv
+	gs.rp = [3]int32{}
+	gs.zp = [3]int32{1, 1, 1}
+	gs.loop = 1
+}
+
+var errHintingStackUnderflow = errors.New("hinting: stack underflow")
+
+// hinter executes the TrueType programs for one size
+type hinter struct {
+	tables *hintingTables
+	upem   int32
+	ppem   int32
+
+	cvt       []int32
+	storage   []int32
+	stack     []int32
+	functions map[int32][]byte
+
+	gs    graphicsState
+	zones [2]hintZone // twilight and glyph zone
+
+	instructionCount int
+	inPrep           bool
+
+	// sticky error, checked after each instruction
+	err error
+}
+
+func newHinter(tables *hintingTables, upem, ppem uint16) *hinter {
+	h := &hinter{
+		tables:    tables,
+		upem:      int32(upem),
+		ppem:      int32(ppem),
+		storage:   make([]int32, tables.maxStorage),
+		stack:     make([]int32, 0, int(tables.maxStackElements)+stackSlack),
+		functions: make(map[int32][]byte),
+		gs:        defaultGraphicsState,
+	}
+	if h.upem == 0 {
+		h.upem = 1000
+	}
+	h.cvt = make([]int32, len(tables.cvt))
+	for i, v := range tables.cvt {
+		h.cvt[i] = h.scale(int32(v))
+	}
+	h.zones[0] = newHintZone(int(tables.maxTwilightPoints))
+	return h
+}
+
+// clone returns a deep copy of the state of `h`, so that
+// glyph programs may be run without modifying `h`
+func (h *hinter) clone() *hinter {
+	out := *h
+	out.cvt = append([]int32(nil), h.cvt...)
+	out.storage = append([]int32(nil), h.storage...)
+	out.stack = make([]int32, 0, cap(h.stack))
+	out.functions = make(map[int32][]byte, len(h.functions))
+	for k, v := range h.functions {
+		out.functions[k] = v
+	}
+	twilight := h.zones[0]
+	out.zones[0] = hintZone{
+		orig:    append([]hintPoint(nil), twilight.orig...),
+		cur:     append([]hintPoint(nil), twilight.cur...),
+		touched: append([]uint8(nil), twilight.touched...),
+		onCurve: append([]bool(nil), twilight.onCurve...),
+	}
+	out.zones[1] = hintZone{}
+	return &out
+}
+
+// scale converts from font units to 26.6 pixels
+func (h *hinter) scale(v int32) int32 {
+	return int32(mulDiv64(int64(v), 64*int64(h.ppem), int64(h.upem)))
+}
+
+// loadFont executes the 'fpgm' and 'prep' programs
+func (h *hinter) loadFont() error {
+	if len(h.tables.fpgm) == 0 && len(h.tables.prep) == 0 {
+		return errors.New("hinting: missing font programs")
+	}
+	if err := h.run(h.tables.fpgm); err != nil {
+		return fmt.Errorf("hinting: in font program: %s", err)
+	}
+	h.gs = defaultGraphicsState
+	h.inPrep = true
+	err := h.run(h.tables.prep)
+	h.inPrep = false
+	if err != nil {
+		return fmt.Errorf("hinting: in control value program: %s", err)
+	}
+	return nil
+}
+
+// hintGlyph executes the glyph program, and returns the
+// grid-fitted points, including the phantom points
+func (h *hinter) hintGlyph(points []contourPoint, ends []uint16, instructions []byte) ([]hintPoint, error) {
+	if h.gs.instructControl&1 != 0 {
+		return nil, errors.New("hinting: glyph instructions are disabled")
+	}
+	if h.gs.instructControl&2 != 0 {
+		h.gs = defaultGraphicsState
+	}
+	h.gs.resetVectors()
+
+	zone := newHintZone(len(points))
+	for i, p := range points {
+		pt := hintPoint{
+			x: int32(math.Round(float64(p.X) * 64 * float64(h.ppem) / float64(h.upem))),
+			y: int32(math.Round(float64(p.Y) * 64 * float64(h.ppem) / float64(h.upem))),
+		}
+		zone.orig[i], zone.cur[i] = pt, pt
+		zone.onCurve[i] = p.isOnCurve
+	}
+	zone.ends = make([]int, len(ends))
+	for i, e := range ends {
+		zone.ends[i] = int(e)
+	}
+	// the phantom points are aligned on the grid
+	phantoms := zone.cur[len(points)-phantomCount:]
+	phantoms[phantomLeft].x = roundGrid(phantoms[phantomLeft].x)
+	phantoms[phantomRight].x = roundGrid(phantoms[phantomRight].x)
+	phantoms[phantomTop].y = roundGrid(phantoms[phantomTop].y)
+	phantoms[phantomBottom].y = roundGrid(phantoms[phantomBottom].y)
+	h.zones[1] = zone
+
+	h.stack = h.stack[:0]
+	if err := h.run(instructions); err != nil {
+		return nil, fmt.Errorf("hinting: in glyph program: %s", err)
+	}
+	return h.zones[1].cur, nil
+}
+
+func (h *hinter) run(program []byte) error {
+	h.err = nil
+	h.execute(program, 0)
+	return h.err
+}
+
+// ------------------------------ helpers ------------------------------
+
+func mulDiv64(a, b, c int64) int64 {
+	if c == 0 {
+		return 0
+	}
+	v := a * b
+	if (v < 0) != (c < 0) {
+		return (v - c/2) / c
+	}
+	return (v + c/2) / c
+}
+
+func roundGrid(v int32) int32 {
+	if v >= 0 {
+		return (v + 32) &^ 63
+	}
+	return -((-v + 32) &^ 63)
+}
+
+func abs32(v int32) int32 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// normalizeVector returns the unit vector (in 2.14) with direction (x, y),
+// or the x axis for a zero vector
+func normalizeVector(x, y int32) [2]int32 {
+	if x == 0 && y == 0 {
+		return [2]int32{0x4000, 0}
+	}
+	l := math.Hypot(float64(x), float64(y))
+	return [2]int32{
+		int32(math.Round(float64(x) * 0x4000 / l)),
+		int32(math.Round(float64(y) * 0x4000 / l)),
+	}
+}
+
+func dotVector(v [2]int32, x, y int32) int32 {
+	return int32((int64(x)*int64(v[0]) + int64(y)*int64(v[1]) + 0x2000) >> 14)
+}
+
+func (h *hinter) fail(format string, args ...interface{}) {
+	if h.err == nil {
+		h.err = fmt.Errorf(format, args...)
+	}
+}
+
+func (h *hinter) push(v int32) {
+	if len(h.stack) == cap(h.stack) {
+		h.fail("stack overflow")
+		return
+	}
+	h.stack = append(h.stack, v)
+}
+
+func (h *hinter) pop() int32 {
+	if len(h.stack) == 0 {
+		if h.err == nil {
+			h.err = errHintingStackUnderflow
+		}
+		return 0
+	}
+	v := h.stack[len(h.stack)-1]
+	h.stack = h.stack[:len(h.stack)-1]
+	return v
+}
+
+// zone returns the zone pointed by the zone pointer `zp`, and checks
+// that `p` is a valid point index
+func (h *hinter) zone(zp int, p int32) *hintZone {
+	z := &h.zones[h.gs.zp[zp]]
+	if p < 0 || int(p) >= len(z.cur) {
+		h.fail("invalid point index %d", p)
+		return nil
+	}
+	return z
+}
+
+func (h *hinter) project(x, y int32) int32     { return dotVector(h.gs.pv, x, y) }
+func (h *hinter) dualProject(x, y int32) int32 { return dotVector(h.gs.dv, x, y) }
+
+// cur returns the current position of point `p` of the zone pointed by `zp`
+func (h *hinter) cur(zp int, p int32) hintPoint {
+	if z := h.zone(zp, p); z != nil {
+		return z.cur[p]
+	}
+	return hintPoint{}
+}
+
+// orig returns the original position of point `p` of the zone pointed by `zp`
+func (h *hinter) orig(zp int, p int32) hintPoint {
+	if z := h.zone(zp, p); z != nil {
+		return z.orig[p]
+	}
+	return hintPoint{}
+}
+
+// move moves the point `p` of the zone pointed by `zp` along the freedom
+// vector, so that its projection changes by `d`
+func (h *hinter) move(zp int, p int32, d int32, touch bool) {
+	z := h.zone(zp, p)
+	if z == nil {
+		return
+	}
+	fv, pv := h.gs.fv, h.gs.pv
+	fdot := int64(dotVector(fv, pv[0], pv[1]))
+	if fdot == 0 {
+		// the vectors are orthogonal : no move is possible
+		return
+	}
+	if fv[0] != 0 {
+		z.cur[p].x += int32(mulDiv64(int64(d), int64(fv[0]), fdot))
+		if touch {
+			z.touched[p] |= touchedX
+		}
+	}
+	if fv[1] != 0 {
+		z.cur[p].y += int32(mulDiv64(int64(d), int64(fv[1]), fdot))
+		if touch {
+			z.touched[p] |= touchedY
+		}
+	}
+}
+
+func (h *hinter) round(v int32) int32 {
+	gs := &h.gs
+	switch gs.round {
+	case roundToGrid:
+		return roundGrid(v)
+	case roundToHalfGrid:
+		if v >= 0 {
+			return (v &^ 63) + 32
+		}
+		return -((-v &^ 63) + 32)
+	case roundToDoubleGrid:
+		if v >= 0 {
+			return (v + 16) &^ 31
+		}
+		return -((-v + 16) &^ 31)
+	case roundDownToGrid:
+		if v >= 0 {
+			return v &^ 63
+		}
+		return -(-v &^ 63)
+	case roundUpToGrid:
+		if v >= 0 {
+			return (v + 63) &^ 63
+		}
+		return -((-v + 63) &^ 63)
+	case roundSuper:
+		if v >= 0 {
+			r := (v - gs.roundPhase + gs.roundThreshold) / gs.roundPeriod * gs.roundPeriod
+			r += gs.roundPhase
+			if r < 0 {
+				r = gs.roundPhase
+			}
+			return r
+		}
+		r := (-v - gs.roundPhase + gs.roundThreshold) / gs.roundPeriod * gs.roundPeriod
+		r += gs.roundPhase
+		if r < 0 {
+			r = gs.roundPhase
+		}
+		return -r
+	default: // roundOff
+		return v
+	}
+}
+
+// setSuperRound implements SROUND and S45ROUND
+func (h *hinter) setSuperRound(gridPeriod int32, selector int32) {
+	gs := &h.gs
+	switch selector & 0xC0 {
+	case 0:
+		gs.roundPeriod = gridPeriod / 2
+	case 0x40:
+		gs.roundPeriod = gridPeriod
+	case 0x80:
+		gs.roundPeriod = gridPeriod * 2
+	default:
+		gs.roundPeriod = gridPeriod
+	}
+	switch selector & 0x30 {
+	case 0:
+		gs.roundPhase = 0
+	case 0x10:
+		gs.roundPhase = gs.roundPeriod / 4
+	case 0x20:
+		gs.roundPhase = gs.roundPeriod / 2
+	default:
+		gs.roundPhase = gs.roundPeriod * 3 / 4
+	}
+	if selector&0x0F == 0 {
+		gs.roundThreshold = gs.roundPeriod - 1
+	} else {
+		gs.roundThreshold = (selector&0x0F - 4) * gs.roundPeriod / 8
+	}
+	gs.round = roundSuper
+}
+
+// applyMinDist keeps `dist` at least minDist away from 0,
+// with the sign of `ref`
+func (h *hinter) applyMinDist(ref, dist int32) int32 {
+	if ref >= 0 {
+		if dist < h.gs.minDist {
+			return h.gs.minDist
+		}
+		return dist
+	}
+	if dist > -h.gs.minDist {
+		return -h.gs.minDist
+	}
+	return dist
+}
+
+func (h *hinter) readCvt(index int32) int32 {
+	if index < 0 || int(index) >= len(h.cvt) {
+		h.fail("invalid cvt index %d", index)
+		return 0
+	}
+	return h.cvt[index]
+}
+
+func (h *hinter) writeCvt(index, value int32) {
+	if index < 0 || int(index) >= len(h.cvt) {
+		h.fail("invalid cvt index %d", index)
+		return
+	}
+	h.cvt[index] = value
+}
+
+// instructionLength returns the size of the instruction at the start of `program`,
+// including its inline data
+func instructionLength(program []byte) int {
+	switch op := program[0]; {
+	case op == 0x40: // NPUSHB
+		if len(program) < 2 {
+			return len(program)
+		}
+		return 2 + int(program[1])
+	case op == 0x41: // NPUSHW
+		if len(program) < 2 {
+			return len(program)
+		}
+		return 2 + 2*int(program[1])
+	case 0xB0 <= op && op <= 0xB7: // PUSHB
+		return 1 + int(op-0xB0+1)
+	case 0xB8 <= op && op <= 0xBF: // PUSHW
+		return 1 + 2*int(op-0xB8+1)
+	default:
+		return 1
+	}
+}
+
+// skipBranch returns the position after the ELSE (if `stopAtElse` is true)
+// or EIF matching the IF starting before `pc`
+func skipBranch(program []byte, pc int, stopAtElse bool) (int, bool) {
+	level := 0
+	for pc < len(program) {
+		switch program[pc] {
+		case 0x58: // IF
+			level++
+		case 0x1B: // ELSE
+			if level == 0 && stopAtElse {
+				return pc + 1, true
+			}
+		case 0x59: // EIF
+			if level == 0 {
+				return pc + 1, true
+			}
+			level--
+		}
+		pc += instructionLength(program[pc:])
+	}
+	return pc, false
+}
+
+// ------------------------------ interpreter ------------------------------
+
+// execute runs `program`, recording errors in h.err
+func (h *hinter) execute(program []byte, depth int) {
+	if depth > maxCallDepth {
+		h.fail("maximum call depth reached")
+		return
+	}
+	gs := &h.gs
+	for pc := 0; pc < len(program) && h.err == nil; {
+		h.instructionCount++
+		if h.instructionCount > maxInstructionCount {
+			h.fail("maximum number of instructions reached")
+			return
+		}
+
+		op := program[pc]
+		next := pc + instructionLength(program[pc:])
+		if next > len(program) {
+			h.fail("invalid instruction (EOF)")
+			return
+		}
+
+		switch {
+		case op <= 0x05: // SVTCA, SPVTCA, SFVTCA
+			axis := [2]int32{0, 0x4000} // y axis
+			if op&1 != 0 {
+				axis = [2]int32{0x4000, 0}
+			}
+			if op <= 0x03 {
+				gs.pv, gs.dv = axis, axis
+			}
+			if op <= 0x01 || op >= 0x04 {
+				gs.fv = axis
+			}
+		case op <= 0x09: // SPVTL, SFVTL
+			p2, p1 := h.pop(), h.pop()
+			a, b := h.cur(2, p2), h.cur(1, p1)
+			dx, dy := b.x-a.x, b.y-a.y
+			if op&1 != 0 {
+				dx, dy = -dy, dx
+			}
+			v := normalizeVector(dx, dy)
+			if op <= 0x07 {
+				gs.pv, gs.dv = v, v
+			} else {
+				gs.fv = v
+			}
+		case op == 0x0A, op == 0x0B: // SPVFS, SFVFS
+			y, x := h.pop(), h.pop()
+			v := normalizeVector(int32(int16(x)), int32(int16(y)))
+			if op == 0x0A {
+				gs.pv, gs.dv = v, v
+			} else {
+				gs.fv = v
+			}
+		case op == 0x0C: // GPV
+			h.push(gs.pv[0])
+			h.push(gs.pv[1])
+		case op == 0x0D: // GFV
+			h.push(gs.fv[0])
+			h.push(gs.fv[1])
+		case op == 0x0E: // SFVTPV
+			gs.fv = gs.pv
+		case op == 0x0F: // ISECT
+			h.isect()
+		case op <= 0x12: // SRP0, SRP1, SRP2
+			gs.rp[op-0x10] = h.pop()
+		case op <= 0x16: // SZP0, SZP1, SZP2, SZPS
+			z := h.pop()
+			if z != 0 && z != 1 {
+				h.fail("invalid zone %d", z)
+				break
+			}
+			if op == 0x16 {
+				gs.zp = [3]int32{z, z, z}
+			} else {
+				gs.zp[op-0x13] = z
+			}
+		case op == 0x17: // SLOOP
+			gs.loop = h.pop()
+			if gs.loop < 0 {
+				h.fail("invalid loop value %d", gs.loop)
+			}
+		case op == 0x18: // RTG
+			gs.round = roundToGrid
+		case op == 0x19: // RTHG
+			gs.round = roundToHalfGrid
+		case op == 0x1A: // SMD
+			gs.minDist = h.pop()
+		case op == 0x1B: // ELSE, reached at the end of the IF branch
+			var ok bool
+			next, ok = skipBranch(program, next, false)
+			if !ok {
+				h.fail("unterminated ELSE")
+			}
+		case op == 0x1C: // JMPR
+			next = pc + int(h.pop())
+		case op == 0x1D: // SCVTCI
+			gs.cvtCutIn = h.pop()
+		case op == 0x1E: // SSWCI
+			gs.singleWidthCutIn = h.pop()
+		case op == 0x1F: // SSW
+			gs.singleWidth = h.scale(h.pop())
+		case op == 0x20: // DUP
+			v := h.pop()
+			h.push(v)
+			h.push(v)
+		case op == 0x21: // POP
+			h.pop()
+		case op == 0x22: // CLEAR
+			h.stack = h.stack[:0]
+		case op == 0x23: // SWAP
+			b, a := h.pop(), h.pop()
+			h.push(b)
+			h.push(a)
+		case op == 0x24: // DEPTH
+			h.push(int32(len(h.stack)))
+		case op == 0x25, op == 0x26: // CINDEX, MINDEX
+			k := int(h.pop())
+			if k <= 0 || k > len(h.stack) {
+				h.fail("invalid stack index %d", k)
+				break
+			}
+			i := len(h.stack) - k
+			v := h.stack[i]
+			if op == 0x26 {
+				copy(h.stack[i:], h.stack[i+1:])
+				h.stack = h.stack[:len(h.stack)-1]
+			}
+			h.push(v)
+		case op == 0x27: // ALIGNPTS
+			p2, p1 := h.pop(), h.pop()
+			a, b := h.cur(0, p2), h.cur(1, p1)
+			d := h.project(a.x-b.x, a.y-b.y) / 2
+			h.move(1, p1, d, true)
+			h.move(0, p2, -d, true)
+		case op == 0x29: // UTP
+			p := h.pop()
+			if z := h.zone(0, p); z != nil {
+				if gs.fv[0] != 0 {
+					z.touched[p] &^= touchedX
+				}
+				if gs.fv[1] != 0 {
+					z.touched[p] &^= touchedY
+				}
+			}
+		case op == 0x2A: // LOOPCALL
+			f, count := h.pop(), h.pop()
+			body, ok := h.functions[f]
+			if !ok {
+				h.fail("undefined function %d", f)
+				break
+			}
+			for ; count > 0 && h.err == nil; count-- {
+				h.execute(body, depth+1)
+			}
+		case op == 0x2B: // CALL
+			f := h.pop()
+			body, ok := h.functions[f]
+			if !ok {
+				h.fail("undefined function %d", f)
+				break
+			}
+			h.execute(body, depth+1)
+		case op == 0x2C: // FDEF
+			f := h.pop()
+			start := next
+			for next < len(program) && program[next] != 0x2D { // ENDF
+				next += instructionLength(program[next:])
+			}
+			if next >= len(program) {
+				h.fail("unterminated function %d", f)
+				break
+			}
+			h.functions[f] = program[start:next]
+			next++
+		case op == 0x2D: // ENDF
+			return
+		case op == 0x2E, op == 0x2F: // MDAP
+			p := h.pop()
+			var d int32
+			if op == 0x2F {
+				pt := h.cur(0, p)
+				dist := h.project(pt.x, pt.y)
+				d = h.round(dist) - dist
+			}
+			h.move(0, p, d, true)
+			gs.rp[0], gs.rp[1] = p, p
+		case op == 0x30, op == 0x31: // IUP
+			h.interpolateUntouched(op == 0x31)
+		case op == 0x32, op == 0x33, // SHP
+			op == 0x34, op == 0x35, // SHC
+			op == 0x36, op == 0x37: // SHZ
+			h.shift(op)
+		case op == 0x38: // SHPIX
+			d := h.pop()
+			for ; gs.loop > 0 && h.err == nil; gs.loop-- {
+				p := h.pop()
+				if z := h.zone(2, p); z != nil {
+					if gs.fv[0] != 0 {
+						z.cur[p].x += int32((int64(d)*int64(gs.fv[0]) + 0x2000) >> 14)
+						z.touched[p] |= touchedX
+					}
+					if gs.fv[1] != 0 {
+						z.cur[p].y += int32((int64(d)*int64(gs.fv[1]) + 0x2000) >> 14)
+						z.touched[p] |= touchedY
+					}
+				}
+			}
+			gs.loop = 1
+		case op == 0x39: // IP
+			h.interpolate()
+		case op == 0x3A, op == 0x3B: // MSIRP
+			d, p := h.pop(), h.pop()
+			if gs.zp[1] == 0 { // twilight zone
+				if z := h.zone(1, p); z != nil {
+					z.orig[p] = h.orig(0, gs.rp[0])
+					z.cur[p] = z.orig[p]
+				}
+			}
+			a, b := h.cur(1, p), h.cur(0, gs.rp[0])
+			dist := h.project(a.x-b.x, a.y-b.y)
+			h.move(1, p, d-dist, true)
+			gs.rp[1], gs.rp[2] = gs.rp[0], p
+			if op == 0x3B {
+				gs.rp[0] = p
+			}
+		case op == 0x3C: // ALIGNRP
+			for ; gs.loop > 0 && h.err == nil; gs.loop-- {
+				p := h.pop()
+				a, b := h.cur(1, p), h.cur(0, gs.rp[0])
+				h.move(1, p, -h.project(a.x-b.x, a.y-b.y), true)
+			}
+			gs.loop = 1
+		case op == 0x3D: // RTDG
+			gs.round = roundToDoubleGrid
+		case op == 0x3E, op == 0x3F: // MIAP
+			n, p := h.pop(), h.pop()
+			dist := h.readCvt(n)
+			if gs.zp[0] == 0 { // twilight zone
+				if z := h.zone(0, p); z != nil {
+					z.orig[p] = hintPoint{
+						x: int32((int64(dist)*int64(gs.pv[0]) + 0x2000) >> 14),
+						y: int32((int64(dist)*int64(gs.pv[1]) + 0x2000) >> 14),
+					}
+					z.cur[p] = z.orig[p]
+				}
+			}
+			pt := h.cur(0, p)
+			orgDist := h.project(pt.x, pt.y)
+			if op == 0x3F {
+				if abs32(dist-orgDist) > gs.cvtCutIn {
+					dist = orgDist
+				}
+				dist = h.round(dist)
+			}
+			h.move(0, p, dist-orgDist, true)
+			gs.rp[0], gs.rp[1] = p, p
+		case op == 0x40: // NPUSHB
+			for _, b := range program[pc+2 : next] {
+				h.push(int32(b))
+			}
+		case op == 0x41: // NPUSHW
+			for i := pc + 2; i < next; i += 2 {
+				h.push(int32(int16(uint16(program[i])<<8 | uint16(program[i+1]))))
+			}
+		case op == 0x42: // WS
+			v, l := h.pop(), h.pop()
+			if l < 0 || int(l) >= len(h.storage) {
+				h.fail("invalid storage index %d", l)
+				break
+			}
+			h.storage[l] = v
+		case op == 0x43: // RS
+			l := h.pop()
+			if l < 0 || int(l) >= len(h.storage) {
+				h.fail("invalid storage index %d", l)
+				break
+			}
+			h.push(h.storage[l])
+		case op == 0x44: // WCVTP
+			v, l := h.pop(), h.pop()
+			h.writeCvt(l, v)
+		case op == 0x45: // RCVT
+			h.push(h.readCvt(h.pop()))
+		case op == 0x46, op == 0x47: // GC
+			p := h.pop()
+			if op == 0x46 {
+				pt := h.cur(2, p)
+				h.push(h.project(pt.x, pt.y))
+			} else {
+				pt := h.orig(2, p)
+				h.push(h.dualProject(pt.x, pt.y))
+			}
+		case op == 0x48: // SCFS
+			k, p := h.pop(), h.pop()
+			pt := h.cur(2, p)
+			h.move(2, p, k-h.project(pt.x, pt.y), true)
+			if gs.zp[2] == 0 {
+				if z := h.zone(2, p); z != nil {
+					z.orig[p] = z.cur[p]
+				}
+			}
+		case op == 0x49, op == 0x4A: // MD
+			k, l := h.pop(), h.pop()
+			if op == 0x4A {
+				a, b := h.cur(0, l), h.cur(1, k)
+				h.push(h.project(a.x-b.x, a.y-b.y))
+			} else {
+				a, b := h.orig(0, l), h.orig(1, k)
+				h.push(h.dualProject(a.x-b.x, a.y-b.y))
+			}
+		case op == 0x4B, op == 0x4C: // MPPEM, MPS
+			h.push(h.ppem)
+		case op == 0x4D: // FLIPON
+			gs.autoFlip = true
+		case op == 0x4E: // FLIPOFF
+			gs.autoFlip = false
+		case op == 0x4F: // DEBUG
+			h.pop()
+		case 0x50 <= op && op <= 0x55: // LT, LTEQ, GT, GTEQ, EQ, NEQ
+			b, a := h.pop(), h.pop()
+			var c bool
+			switch op {
+			case 0x50:
+				c = a < b
+			case 0x51:
+				c = a <= b
+			case 0x52:
+				c = a > b
+			case 0x53:
+				c = a >= b
+			case 0x54:
+				c = a == b
+			case 0x55:
+				c = a != b
+			}
+			h.push(boolToInt32(c))
+		case op == 0x56: // ODD
+			h.push(boolToInt32(h.round(h.pop())&127 == 64))
+		case op == 0x57: // EVEN
+			h.push(boolToInt32(h.round(h.pop())&127 == 0))
+		case op == 0x58: // IF
+			if h.pop() == 0 {
+				var ok bool
+				next, ok = skipBranch(program, next, true)
+				if !ok {
+					h.fail("unterminated IF")
+				}
+			}
+		case op == 0x59: // EIF
+		case op == 0x5A: // AND
+			b, a := h.pop(), h.pop()
+			h.push(boolToInt32(a != 0 && b != 0))
+		case op == 0x5B: // OR
+			b, a := h.pop(), h.pop()
+			h.push(boolToInt32(a != 0 || b != 0))
+		case op == 0x5C: // NOT
+			h.push(boolToInt32(h.pop() == 0))
+		case op == 0x5D, op == 0x71, op == 0x72: // DELTAP1, DELTAP2, DELTAP3
+			h.deltaPoints(op)
+		case op == 0x5E: // SDB
+			gs.deltaBase = h.pop()
+		case op == 0x5F: // SDS
+			gs.deltaShift = h.pop()
+			if gs.deltaShift < 0 || gs.deltaShift > 6 {
+				h.fail("invalid delta shift %d", gs.deltaShift)
+			}
+		case op == 0x60: // ADD
+			b, a := h.pop(), h.pop()
+			h.push(a + b)
+		case op == 0x61: // SUB
+			b, a := h.pop(), h.pop()
+			h.push(a - b)
+		case op == 0x62: // DIV
+			b, a := h.pop(), h.pop()
+			if b == 0 {
+				h.fail("division by zero")
+				break
+			}
+			h.push(int32(int64(a) * 64 / int64(b)))
+		case op == 0x63: // MUL
+			b, a := h.pop(), h.pop()
+			h.push(int32((int64(a)*int64(b) + 32) >> 6))
+		case op == 0x64: // ABS
+			h.push(abs32(h.pop()))
+		case op == 0x65: // NEG
+			h.push(-h.pop())
+		case op == 0x66: // FLOOR
+			h.push(h.pop() &^ 63)
+		case op == 0x67: // CEILING
+			h.push((h.pop() + 63) &^ 63)
+		case 0x68 <= op && op <= 0x6B: // ROUND
+			h.push(h.round(h.pop()))
+		case 0x6C <= op && op <= 0x6F: // NROUND, without engine compensation
+		case op == 0x70: // WCVTF
+			v, l := h.pop(), h.pop()
+			h.writeCvt(l, h.scale(v))
+		case 0x73 <= op && op <= 0x75: // DELTAC1, DELTAC2, DELTAC3
+			h.deltaCvt(op)
+		case op == 0x76: // SROUND
+			h.setSuperRound(64, h.pop())
+		case op == 0x77: // S45ROUND
+			h.setSuperRound(45, h.pop()) // 64 * sqrt(2) / 2
+		case op == 0x78, op == 0x79: // JROT, JROF
+			e, offset := h.pop(), h.pop()
+			if (e != 0) == (op == 0x78) {
+				next = pc + int(offset)
+			}
+		case op == 0x7A: // ROFF
+			gs.round = roundOff
+		case op == 0x7C: // RUTG
+			gs.round = roundUpToGrid
+		case op == 0x7D: // RDTG
+			gs.round = roundDownToGrid
+		case op == 0x7E, op == 0x7F: // SANGW, AA (obsolete)
+			h.pop()
+		case op == 0x80: // FLIPPT
+			for ; gs.loop > 0 && h.err == nil; gs.loop-- {
+				p := h.pop()
+				if p < 0 || int(p) >= len(h.zones[1].onCurve) {
+					h.fail("invalid point index %d", p)
+					break
+				}
+				h.zones[1].onCurve[p] = !h.zones[1].onCurve[p]
+			}
+			gs.loop = 1
+		case op == 0x81, op == 0x82: // FLIPRGON, FLIPRGOFF
+			hi, lo := h.pop(), h.pop()
+			if lo < 0 || hi < lo || int(hi) >= len(h.zones[1].onCurve) {
+				h.fail("invalid point range %d %d", lo, hi)
+				break
+			}
+			for p := lo; p <= hi; p++ {
+				h.zones[1].onCurve[p] = op == 0x81
+			}
+		case op == 0x85: // SCANCTRL
+			h.pop()
+		case op == 0x86, op == 0x87: // SDPVTL
+			p2, p1 := h.pop(), h.pop()
+			a, b := h.orig(2, p2), h.orig(1, p1)
+			dx, dy := b.x-a.x, b.y-a.y
+			if op&1 != 0 {
+				dx, dy = -dy, dx
+			}
+			gs.dv = normalizeVector(dx, dy)
+			a, b = h.cur(2, p2), h.cur(1, p1)
+			dx, dy = b.x-a.x, b.y-a.y
+			if op&1 != 0 {
+				dx, dy = -dy, dx
+			}
+			gs.pv = normalizeVector(dx, dy)
+		case op == 0x88: // GETINFO
+			h.push(getInfo(h.pop()))
+		case op == 0x8A: // ROLL
+			c, b, a := h.pop(), h.pop(), h.pop()
+			h.push(b)
+			h.push(c)
+			h.push(a)
+		case op == 0x8B: // MAX
+			b, a := h.pop(), h.pop()
+			if a < b {
+				a = b
+			}
+			h.push(a)
+		case op == 0x8C: // MIN
+			b, a := h.pop(), h.pop()
+			if a > b {
+				a = b
+			}
+			h.push(a)
+		case op == 0x8D: // SCANTYPE
+			h.pop()
+		case op == 0x8E: // INSTCTRL
+			s, v := h.pop(), h.pop()
+			if !h.inPrep || s < 1 || s > 2 {
+				break
+			}
+			flag := int32(1) << (s - 1)
+			if v != 0 {
+				gs.instructControl |= flag
+			} else {
+				gs.instructControl &^= flag
+			}
+		case 0xB0 <= op && op <= 0xB7: // PUSHB
+			for _, b := range program[pc+1 : next] {
+				h.push(int32(b))
+			}
+		case 0xB8 <= op && op <= 0xBF: // PUSHW
+			for i := pc + 1; i < next; i += 2 {
+				h.push(int32(int16(uint16(program[i])<<8 | uint16(program[i+1]))))
+			}
+		case 0xC0 <= op && op <= 0xDF: // MDRP
+			h.moveDirectRelative(op)
+		case 0xE0 <= op: // MIRP
+			h.moveIndirectRelative(op)
+		default: // including IDEF
+			h.fail("unsupported instruction 0x%02x", op)
+		}
+
+		if next < 0 {
+			h.fail("invalid jump")
+		}
+		pc = next
+	}
+}
+
+func boolToInt32(b bool) int32 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// getInfo mimics the FreeType v40 interpreter
+func getInfo(selector int32) int32 {
+	var out int32
+	if selector&1 != 0 {
+		out |= 40 // version
+	}
+	if selector&64 != 0 {
+		out |= 1 << 13 // subpixel hinting
+	}
+	if selector&1024 != 0 {
+		out |= 1 << 17 // symmetrical smoothing
+	}
+	return out
+}
+
+func (h *hinter) isect() {
+	b1, b0, a1, a0, p := h.pop(), h.pop(), h.pop(), h.pop(), h.pop()
+	pa0, pa1 := h.cur(1, a0), h.cur(1, a1)
+	pb0, pb1 := h.cur(0, b0), h.cur(0, b1)
+	z := h.zone(2, p)
+	if z == nil {
+		return
+	}
+
+	dax, day := int64(pa1.x-pa0.x), int64(pa1.y-pa0.y)
+	dbx, dby := int64(pb1.x-pb0.x), int64(pb1.y-pb0.y)
+	dx, dy := int64(pb0.x-pa0.x), int64(pb0.y-pa0.y)
+	discriminant := dax*dby - day*dbx
+	if discriminant == 0 {
+		// parallel lines : use the middle of the points
+		z.cur[p] = hintPoint{
+			x: (pa0.x + pa1.x + pb0.x + pb1.x) / 4,
+			y: (pa0.y + pa1.y + pb0.y + pb1.y) / 4,
+		}
+	} else {
+		t := dx*dby - dy*dbx
+		z.cur[p] = hintPoint{
+			x: pa0.x + int32(mulDiv64(t, dax, discriminant)),
+			y: pa0.y + int32(mulDiv64(t, day, discriminant)),
+		}
+	}
+	z.touched[p] |= touchedX | touchedY
+}
+
+// shift implements SHP, SHC and SHZ
+func (h *hinter) shift(op byte) {
+	gs := &h.gs
+	// reference point
+	refZP, ref := 1, gs.rp[2]
+	if op&1 != 0 {
+		refZP, ref = 0, gs.rp[1]
+	}
+	cur, orig := h.cur(refZP, ref), h.orig(refZP, ref)
+	d := h.project(cur.x-orig.x, cur.y-orig.y)
+
+	switch op {
+	case 0x32, 0x33: // SHP
+		for ; gs.loop > 0 && h.err == nil; gs.loop-- {
+			h.move(2, h.pop(), d, true)
+		}
+		gs.loop = 1
+	case 0x34, 0x35: // SHC
+		c := h.pop()
+		z := &h.zones[gs.zp[2]]
+		if c < 0 || int(c) >= len(z.ends) {
+			h.fail("invalid contour %d", c)
+			return
+		}
+		start := 0
+		if c > 0 {
+			start = z.ends[c-1] + 1
+		}
+		for p := start; p <= z.ends[c]; p++ {
+			if gs.zp[2] == gs.zp[refZP] && int32(p) == ref {
+				continue
+			}
+			h.move(2, int32(p), d, false)
+		}
+	case 0x36, 0x37: // SHZ
+		e := h.pop()
+		if e != 0 && e != 1 {
+			h.fail("invalid zone %d", e)
+			return
+		}
+		saved := gs.zp[2]
+		gs.zp[2] = e
+		z := &h.zones[e]
+		limit := len(z.cur)
+		if e == 1 {
+			limit -= phantomCount
+		}
+		for p := 0; p < limit; p++ {
+			if e == gs.zp[refZP] && int32(p) == ref {
+				continue
+			}
+			h.move(2, int32(p), d, false)
+		}
+		gs.zp[2] = saved
+	}
+}
+
+// interpolate implements IP
+func (h *hinter) interpolate() {
+	gs := &h.gs
+	o1, o2 := h.orig(0, gs.rp[1]), h.orig(1, gs.rp[2])
+	c1, c2 := h.cur(0, gs.rp[1]), h.cur(1, gs.rp[2])
+	orgRange := h.dualProject(o2.x-o1.x, o2.y-o1.y)
+	curRange := h.project(c2.x-c1.x, c2.y-c1.y)
+	for ; gs.loop > 0 && h.err == nil; gs.loop-- {
+		p := h.pop()
+		o, c := h.orig(2, p), h.cur(2, p)
+		orgDist := h.dualProject(o.x-o1.x, o.y-o1.y)
+		curDist := h.project(c.x-c1.x, c.y-c1.y)
+		newDist := orgDist
+		if orgRange != 0 {
+			newDist = int32(mulDiv64(int64(orgDist), int64(curRange), int64(orgRange)))
+		}
+		h.move(2, p, newDist-curDist, true)
+	}
+	gs.loop = 1
+}
+
+// interpolateUntouched implements IUP, on the glyph zone
+func (h *hinter) interpolateUntouched(xAxis bool) {
+	z := &h.zones[1]
+	flag := uint8(touchedY)
+	coord := func(p hintPoint) int32 { return p.y }
+	set := func(p *hintPoint, v int32) { p.y = v }
+	if xAxis {
+		flag = touchedX
+		coord = func(p hintPoint) int32 { return p.x }
+		set = func(p *hintPoint, v int32) { p.x = v }
+	}
+
+	// interpolates the points strictly between t1 and t2 (cyclically, in [start, end])
+	interpolateRange := func(start, end, t1, t2 int) {
+		o1, o2 := coord(z.orig[t1]), coord(z.orig[t2])
+		c1, c2 := coord(z.cur[t1]), coord(z.cur[t2])
+		if o1 > o2 {
+			o1, o2, c1, c2 = o2, o1, c2, c1
+		}
+		for p := t1 + 1; ; p++ {
+			if p > end {
+				p = start
+			}
+			if p == t2 {
+				break
+			}
+			o := coord(z.orig[p])
+			var v int32
+			switch {
+			case o <= o1:
+				v = o + c1 - o1
+			case o >= o2:
+				v = o + c2 - o2
+			default:
+				v = c1 + int32(mulDiv64(int64(o-o1), int64(c2-c1), int64(o2-o1)))
+			}
+			set(&z.cur[p], v)
+		}
+	}
+
+	start := 0
+	for _, end := range z.ends {
+		if end >= len(z.cur) {
+			h.fail("invalid contour end %d", end)
+			return
+		}
+		first := -1
+		for p := start; p <= end; p++ {
+			if z.touched[p]&flag != 0 {
+				first = p
+				break
+			}
+		}
+		if first == -1 { // no touched point
+			start = end + 1
+			continue
+		}
+		t1 := first
+		for p := first + 1; p <= end; p++ {
+			if z.touched[p]&flag != 0 {
+				if p > t1+1 {
+					interpolateRange(start, end, t1, p)
+				}
+				t1 = p
+			}
+		}
+		if t1 == first { // only one touched point : shift the contour
+			d := coord(z.cur[first]) - coord(z.orig[first])
+			for p := start; p <= end; p++ {
+				if p != first {
+					set(&z.cur[p], coord(z.orig[p])+d)
+				}
+			}
+		} else {
+			interpolateRange(start, end, t1, first)
+		}
+		start = end + 1
+	}
+}
+
+// deltaPoints implements DELTAP1, DELTAP2 and DELTAP3
+func (h *hinter) deltaPoints(op byte) {
+	base := h.gs.deltaBase
+	switch op {
+	case 0x71:
+		base += 16
+	case 0x72:
+		base += 32
+	}
+	n := h.pop()
+	for ; n > 0 && h.err == nil; n-- {
+		p, arg := h.pop(), h.pop()
+		if d, ok := h.delta(base, arg); ok {
+			h.move(0, p, d, true)
+		}
+	}
+}
+
+// deltaCvt implements DELTAC1, DELTAC2 and DELTAC3
+func (h *hinter) deltaCvt(op byte) {
+	base := h.gs.deltaBase + 16*int32(op-0x73)
+	n := h.pop()
+	for ; n > 0 && h.err == nil; n-- {
+		c, arg := h.pop(), h.pop()
+		if d, ok := h.delta(base, arg); ok {
+			h.writeCvt(c, h.readCvt(c)+d)
+		}
+	}
+}
+
+// delta returns the exception encoded in `arg`, if it applies to the current ppem
+func (h *hinter) delta(base, arg int32) (int32, bool) {
+	if base+(arg>>4)&15 != h.ppem {
+		return 0, false
+	}
+	step := arg&15 - 8
+	if step >= 0 {
+		step++
+	}
+	return step * 64 / (1 << h.gs.deltaShift), true
+}
+
+// moveDirectRelative implements MDRP
+func (h *hinter) moveDirectRelative(op byte) {
+	gs := &h.gs
+	p := h.pop()
+	rp0 := gs.rp[0]
+
+	o, o0 := h.orig(1, p), h.orig(0, rp0)
+	orgDist := h.dualProject(o.x-o0.x, o.y-o0.y)
+	if abs32(orgDist-gs.singleWidth) < gs.singleWidthCutIn {
+		if orgDist >= 0 {
+			orgDist = gs.singleWidth
+		} else {
+			orgDist = -gs.singleWidth
+		}
+	}
+
+	dist := orgDist
+	if op&0x04 != 0 {
+		dist = h.round(orgDist)
+	}
+	if op&0x08 != 0 {
+		dist = h.applyMinDist(orgDist, dist)
+	}
+
+	c, c0 := h.cur(1, p), h.cur(0, rp0)
+	curDist := h.project(c.x-c0.x, c.y-c0.y)
+	h.move(1, p, dist-curDist, true)
+
+	gs.rp[1], gs.rp[2] = rp0, p
+	if op&0x10 != 0 {
+		gs.rp[0] = p
+	}
+}
+
+// moveIndirectRelative implements MIRP
+func (h *hinter) moveIndirectRelative(op byte) {
+	gs := &h.gs
+	n, p := h.pop(), h.pop()
+	rp0 := gs.rp[0]
+
+	var cvtDist int32
+	if n != -1 { // -1 is used for a zero distance
+		cvtDist = h.readCvt(n)
+	}
+	if abs32(cvtDist-gs.singleWidth) < gs.singleWidthCutIn {
+		if cvtDist >= 0 {
+			cvtDist = gs.singleWidth
+		} else {
+			cvtDist = -gs.singleWidth
+		}
+	}
+
+	if gs.zp[1] == 0 { // twilight zone
+		if z := h.zone(1, p); z != nil {
+			o0 := h.orig(0, rp0)
+			z.orig[p] = hintPoint{
+				x: o0.x + int32((int64(cvtDist)*int64(gs.fv[0])+0x2000)>>14),
+				y: o0.y + int32((int64(cvtDist)*int64(gs.fv[1])+0x2000)>>14),
+			}
+			z.cur[p] = z.orig[p]
+		}
+	}
+
+	o, o0 := h.orig(1, p), h.orig(0, rp0)
+	orgDist := h.dualProject(o.x-o0.x, o.y-o0.y)
+	c, c0 := h.cur(1, p), h.cur(0, rp0)
+	curDist := h.project(c.x-c0.x, c.y-c0.y)
+
+	if gs.autoFlip && (orgDist^cvtDist) < 0 {
+		cvtDist = -cvtDist
+	}
+
+	dist := cvtDist
+	if op&0x04 != 0 {
+		if gs.zp[0] == gs.zp[1] && abs32(cvtDist-orgDist) > gs.cvtCutIn {
+			dist = orgDist
+		}
+		dist = h.round(dist)
+	}
+	if op&0x08 != 0 {
+		dist = h.applyMinDist(orgDist, dist)
+	}
+
+	h.move(1, p, dist-curDist, true)
+
+	gs.rp[1], gs.rp[2] = rp0, p
+	if op&0x10 != 0 {
+		gs.rp[0] = p
+	}
+}
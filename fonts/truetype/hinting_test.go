@@ -0,0 +1,169 @@
+package truetype
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+// pixelYs returns the Y coordinates of the outline points, in pixels
+func pixelYs(f *Font, gid GID, ppem uint16, hinted bool) (out []float64) {
+	outline, _ := f.outlineGlyphData(gid)
+	if hinted {
+		outline, _ = f.HintedGlyphOutline(gid, ppem)
+	}
+	for _, seg := range outline.Segments {
+		for _, p := range seg.ArgsSlice() {
+			out = append(out, float64(p.Y)*float64(ppem)/float64(f.Upem()))
+		}
+	}
+	return out
+}
+
+func onGrid(ys []float64) bool {
+	for _, y := range ys {
+		if math.Abs(y-math.Round(y)) > 0.01 {
+			return false
+		}
+	}
+	return true
+}
+
+func TestHintedGlyphOutline(t *testing.T) {
+	for _, name := range []string{"DejaVuSerif.ttf", "Castoro-Regular.ttf"} {
+		f := loadFont(t, name)
+		gid, _ := f.NominalGlyph('H')
+		for _, ppem := range []uint16{9, 13, 17} {
+			if _, err := f.HintedGlyphOutline(gid, ppem); err != nil {
+				t.Fatalf("%s: %s", name, err)
+			}
+		}
+	}
+
+	// at 13 ppem, the cap height of DejaVuSerif is not an integer
+	f := loadFont(t, "DejaVuSerif.ttf")
+	gid, _ := f.NominalGlyph('H')
+	if onGrid(pixelYs(f, gid, 13, false)) {
+		t.Fatal("expected unhinted points outside the pixel grid")
+	}
+	// the stems of 'H' are straight : every point lands on the pixel grid
+	if ys := pixelYs(f, gid, 13, true); !onGrid(ys) {
+		t.Fatalf("expected hinted points on the pixel grid, got %v", ys)
+	}
+}
+
+func TestHintingCache(t *testing.T) {
+	f := loadFont(t, "DejaVuSerif.ttf")
+	uncached := *f
+	uncached.hinting = &hintingState{} // no caching
+	for _, r := range "Hello" {
+		gid, _ := f.NominalGlyph(r)
+		for _, ppem := range []uint16{9, 13} {
+			exp, err := uncached.HintedGlyphOutline(gid, ppem)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for range [2]int{} { // the second call uses the cache
+				got, err := f.HintedGlyphOutline(gid, ppem)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(exp, got) {
+					t.Fatalf("glyph %d, ppem %d: cached hinting differs", gid, ppem)
+				}
+			}
+		}
+	}
+	if L := len(f.hinting.sizes); L != 2 {
+		t.Fatalf("expected 2 cached sizes, got %d", L)
+	}
+
+	// the cache is bounded, keeping the most recently used sizes
+	gid, _ := f.NominalGlyph('H')
+	for ppem := uint16(10); ppem < 10+2*maxHintedSizes; ppem++ {
+		if _, err := f.HintedGlyphOutline(gid, ppem); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sizes := f.hinting.sizes
+	if len(sizes) != maxHintedSizes || sizes[len(sizes)-1].ppem != 10+2*maxHintedSizes-1 {
+		t.Fatalf("unexpected cached sizes %v", sizes)
+	}
+}
+
+func TestHintingLazy(t *testing.T) {
+	f := loadFont(t, "DejaVuSerif.ttf")
+	if f.hinting.tables.fpgm != nil {
+		t.Fatal("hinting tables should be loaded on demand")
+	}
+	gid, _ := f.NominalGlyph('H')
+	if _, err := f.HintedGlyphOutline(gid, 12); err != nil {
+		t.Fatal(err)
+	}
+	if f.hinting.tables.fpgm == nil {
+		t.Fatal("expected loaded hinting tables")
+	}
+}
+
+func TestHintedGlyphOutlineFallback(t *testing.T) {
+	// no instructions for the glyphs
+	f := loadFont(t, "FreeSerif.ttf")
+	gid, _ := f.NominalGlyph('H')
+	if _, err := f.HintedGlyphOutline(gid, 12); err == nil {
+		t.Fatal("expected error for glyph without instructions")
+	}
+
+	// IDEF is not supported
+	f = loadFont(t, "SelawikVar.ttf")
+	gid, _ = f.NominalGlyph('H')
+	if _, err := f.HintedGlyphOutline(gid, 12); err == nil {
+		t.Fatal("expected error for unsupported instruction")
+	}
+
+	// no hinting at all
+	f = loadFont(t, "ToyCMAP12.otf")
+	if _, err := f.HintedGlyphOutline(1, 12); err == nil {
+		t.Fatal("expected error for font without instructions")
+	}
+}
+
+func TestHintingInstructions(t *testing.T) {
+	h := newHinter(&hintingTables{maxStorage: 2, maxStackElements: 10}, 1000, 10)
+	// 3 + (5 + 7) * 1.0, with 1.0 encoded as 64 in 26.6
+	program := []byte{0xB2, 3, 5, 7, 0x60, 0xB0, 64, 0x63, 0x60}
+	if err := h.run(program); err != nil {
+		t.Fatal(err)
+	}
+	if len(h.stack) != 1 || h.stack[0] != 15 {
+		t.Fatalf("unexpected stack %v", h.stack)
+	}
+
+	// IF/ELSE/EIF with a function call
+	h.stack = h.stack[:0]
+	program = []byte{
+		0xB0, 0, 0x2C, 0xB0, 42, 0x2D, // FDEF 0 : PUSHB 42 ENDF
+		0xB0, 0, 0x58, 0xB0, 1, 0x1B, 0xB0, 0, 0x2B, 0x59, // PUSHB 0 IF PUSHB 1 ELSE PUSHB 0 CALL EIF
+	}
+	if err := h.run(program); err != nil {
+		t.Fatal(err)
+	}
+	if len(h.stack) != 1 || h.stack[0] != 42 {
+		t.Fatalf("unexpected stack %v", h.stack)
+	}
+
+	// unsupported and invalid programs
+	for _, program := range [][]byte{
+		{0x89},          // IDEF
+		{0x21},          // POP on empty stack
+		{0xB0, 5, 0x43}, // RS out of range
+		{0xB0, 0, 0x58}, // unterminated IF
+		{0xB0, 7, 0x2B}, // undefined function
+		{0xB1, 1},       // truncated PUSHB
+		{0xB0, 0, 0x1C}, // infinite loop with JMPR
+	} {
+		h.stack = h.stack[:0]
+		if err := h.run(program); err == nil {
+			t.Fatalf("expected error for program %v", program)
+		}
+	}
+}
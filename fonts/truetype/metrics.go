@@ -4,6 +4,7 @@ import (
 	"math"
 
 	"github.com/benoitkugler/textlayout/fonts"
+	ucd "github.com/benoitkugler/textlayout/unicodedata"
 )
 
 var _ fonts.FaceMetrics = (*Font)(nil)
@@ -33,6 +34,16 @@ func (f *Font) GlyphName(glyph GID) string {
 
 func (f *Font) Upem() uint16 { return f.upem }
 
+// ScaleFactor returns the factor converting font units
+// to pixels, for a size of `ppem` pixels per em.
+func (f *Font) ScaleFactor(ppem float32) float32 { return ppem / float32(f.upem) }
+
+// Scale converts `fontUnits` to pixels, for a size of `ppem` pixels per em.
+// It is more precise than multiplying by ScaleFactor.
+func (f *Font) Scale(fontUnits int16, ppem float32) float32 {
+	return float32(fontUnits) * ppem / float32(f.upem)
+}
+
 var (
 	metricsTagHorizontalAscender  = MustNewTag("hasc")
 	metricsTagHorizontalDescender = MustNewTag("hdsc")
@@ -156,8 +167,35 @@ func (f *Font) LineMetric(metric fonts.LineMetric) (float32, bool) {
 	return 0, false
 }
 
+// NominalGlyph returns the glyph mapped to `ch` by the cmap table,
+// without any shaping or variation selection.
 func (f *Font) NominalGlyph(ch rune) (GID, bool) {
-	return f.cmap.Lookup(ch)
+	if f.cmapCache == nil {
+		return f.cmap.Lookup(ch)
+	}
+	if gid, ok, found := f.cmapCache.get(ch); found {
+		return gid, ok
+	}
+	gid, ok := f.cmap.Lookup(ch)
+	f.cmapCache.set(ch, gid, ok)
+	return gid, ok
+}
+
+// NominalGlyphs is the same as NominalGlyph, for a slice of runes.
+// Runes not found in the font are mapped to 0 (.notdef).
+func (f *Font) NominalGlyphs(runes []rune) []GID {
+	out := make([]GID, len(runes))
+	switch cmap := f.cmap.(type) {
+	case cmap4:
+		cmap.lookupAll(runes, out)
+	case cmap12:
+		cmap.lookupAll(runes, out)
+	default:
+		for i, r := range runes {
+			out[i], _ = f.cmap.Lookup(r)
+		}
+	}
+	return out
 }
 
 func (f *Font) VariationGlyph(ch, varSelector rune) (GID, bool) {
@@ -500,8 +538,16 @@ func (f *Font) getExtentsFromCff1(glyph GID) (fonts.GlyphExtents, bool) {
 	return bounds.ToExtents(), true
 }
 
-// func (f *fontMetrics) getExtentsFromCff2(glyph , coords []float32) (fonts.GlyphExtents, bool) {
-// }
+func (f *Font) getExtentsFromCff2(glyph GID) (fonts.GlyphExtents, bool) {
+	if f.cff2 == nil {
+		return fonts.GlyphExtents{}, false
+	}
+	_, bounds, err := f.cff2.LoadVariableGlyph(glyph, f.varCoords)
+	if err != nil {
+		return fonts.GlyphExtents{}, false
+	}
+	return bounds.ToExtents(), true
+}
 
 func (f *Font) GlyphExtents(glyph GID, xPpem, yPpem uint16) (fonts.GlyphExtents, bool) {
 	out, ok := f.getExtentsFromSbix(glyph, xPpem, yPpem)
@@ -516,6 +562,25 @@ func (f *Font) GlyphExtents(glyph GID, xPpem, yPpem uint16) (fonts.GlyphExtents,
 	if ok {
 		return out, ok
 	}
+	out, ok = f.getExtentsFromCff2(glyph)
+	if ok {
+		return out, ok
+	}
 	out, ok = f.getExtentsFromCBDT(glyph, xPpem, yPpem)
 	return out, ok
 }
+
+// CoversViaDecomposition returns true if the font supports `r`, either directly,
+// or through the components of its canonical decomposition, which are
+// then checked recursively. In the later case, the shaper decomposes `r`
+// during normalization, so that the text may still be rendered with this font.
+func (f *Font) CoversViaDecomposition(r rune) bool {
+	if _, ok := f.NominalGlyph(r); ok {
+		return true
+	}
+	a, b, ok := ucd.Decompose(r)
+	if !ok {
+		return false
+	}
+	return f.CoversViaDecomposition(a) && (b == 0 || f.CoversViaDecomposition(b))
+}
@@ -0,0 +1,75 @@
+package truetype
+
+import "encoding/binary"
+
+var tagSize = MustNewTag("size")
+
+// OpticalSizeInfo is the content of the 'size' GPOS feature,
+// which describes the range of sizes a font is designed for.
+// See https://docs.microsoft.com/en-us/typography/opentype/spec/features_pt#size
+type OpticalSizeInfo struct {
+	// DesignSize is the size the font is designed for, in points.
+	DesignSize float32
+
+	// SubfamilyID identifies the fonts of a family which only
+	// differ by their optical size, or is 0.
+	SubfamilyID uint16
+	// SubfamilyNameID is the name of the subfamily (such as "Caption"),
+	// to be used in menus, or 0.
+	SubfamilyNameID NameID
+
+	// RangeStart (exclusive) and RangeEnd (inclusive) delimit the sizes, in points,
+	// for which the font should be preferred. Both are zero if
+	// the range is not specified.
+	RangeStart, RangeEnd float32
+}
+
+// parseSizeParams parses the FeatureParams of the 'size' feature,
+// starting at `featureOffset` in `featureList`, returning nil if they are invalid.
+// Since old fonts used an offset relative to the FeatureList,
+// instead of the feature table, both are tried.
+func parseSizeParams(featureList []byte, featureOffset, paramsOffset int) *OpticalSizeInfo {
+	if paramsOffset == 0 {
+		return nil
+	}
+	for _, offset := range [2]int{featureOffset + paramsOffset, paramsOffset} {
+		if len(featureList) < offset+10 {
+			continue
+		}
+		data := featureList[offset:]
+		info := OpticalSizeInfo{
+			DesignSize:      float32(binary.BigEndian.Uint16(data)) / 10,
+			SubfamilyID:     binary.BigEndian.Uint16(data[2:]),
+			SubfamilyNameID: NameID(binary.BigEndian.Uint16(data[4:])),
+			RangeStart:      float32(binary.BigEndian.Uint16(data[6:])) / 10,
+			RangeEnd:        float32(binary.BigEndian.Uint16(data[8:])) / 10,
+		}
+		if info.isValid() {
+			return &info
+		}
+	}
+	return nil
+}
+
+// isValid applies the consistency checks recommended by the specification
+func (info OpticalSizeInfo) isValid() bool {
+	if info.DesignSize == 0 {
+		return false
+	}
+	if info.SubfamilyID == 0 && info.SubfamilyNameID == 0 && info.RangeStart == 0 && info.RangeEnd == 0 {
+		return true
+	}
+	return info.RangeStart <= info.DesignSize && info.DesignSize <= info.RangeEnd &&
+		256 <= info.SubfamilyNameID && info.SubfamilyNameID <= 32767
+}
+
+// OpticalSize returns the optical size information stored in the
+// 'size' GPOS feature, or false if the font has no such feature.
+func (f *Font) OpticalSize() (OpticalSizeInfo, bool) {
+	for _, feature := range f.layoutTables.GPOS.Features {
+		if feature.Tag == tagSize && feature.size != nil {
+			return *feature.size, true
+		}
+	}
+	return OpticalSizeInfo{}, false
+}
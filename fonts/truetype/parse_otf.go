@@ -104,8 +104,9 @@ func parseOTF(file fonts.Resource, offset uint32, relativeOffset bool) (*FontPar
 		}
 
 		sec := tableSection{
-			offset: entry.Offset,
-			length: entry.Length,
+			offset:   entry.Offset,
+			length:   entry.Length,
+			checksum: entry.CheckSum,
 		}
 		// adapt the relative offsets
 		if relativeOffset {
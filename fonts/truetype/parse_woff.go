@@ -109,9 +109,10 @@ func parseWOFF(file fonts.Resource, offset uint32, relativeOffset bool) (*FontPa
 		}
 
 		sec := tableSection{
-			offset:  entry.Offset,
-			length:  entry.CompLength,
-			zLength: entry.OrigLength,
+			offset:   entry.Offset,
+			length:   entry.CompLength,
+			zLength:  entry.OrigLength,
+			checksum: entry.OrigChecksum,
 		}
 		// adapt the relative offsets
 		if relativeOffset {
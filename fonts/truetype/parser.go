@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"sort"
 
 	"github.com/benoitkugler/textlayout/fonts"
 	type1c "github.com/benoitkugler/textlayout/fonts/type1C"
@@ -87,31 +88,60 @@ func NewFontParsers(file fonts.Resource) ([]*FontParser, error) {
 
 // tableSection represents a table within the font file.
 type tableSection struct {
-	offset  uint32 // Offset into the file this table starts.
-	length  uint32 // Length of this table within the file.
-	zLength uint32 // Uncompressed length of this table.
+	offset   uint32 // Offset into the file this table starts.
+	length   uint32 // Length of this table within the file.
+	zLength  uint32 // Uncompressed length of this table.
+	checksum uint32 // Checksum of the (uncompressed) table, as stored in the directory.
 }
 
-func (pr *FontParser) findTableBuffer(s tableSection) ([]byte, error) {
-	var buf []byte
+func (s tableSection) isCompressed() bool { return s.length != 0 && s.length < s.zLength }
+
+// uncompressedLength returns the length of the table content
+func (s tableSection) uncompressedLength() uint32 {
+	if s.isCompressed() {
+		return s.zLength
+	}
+	return s.length
+}
 
-	if s.length != 0 && s.length < s.zLength {
-		zbuf := io.NewSectionReader(pr.file, int64(s.offset), int64(s.length))
-		r, err := zlib.NewReader(zbuf)
+// readTable only uses ReadAt, so that it may be used concurrently,
+// and does not trust the lengths of the table directory for the allocation.
+func readTable(file io.ReaderAt, s tableSection) ([]byte, error) {
+	var r io.Reader = io.NewSectionReader(file, int64(s.offset), int64(s.length))
+	if s.isCompressed() {
+		zr, err := zlib.NewReader(r)
 		if err != nil {
 			return nil, err
 		}
-		defer r.Close()
+		defer zr.Close()
+		r = zr
+	}
+	size := s.uncompressedLength()
+	buf, err := io.ReadAll(io.LimitReader(r, int64(size)))
+	if err != nil {
+		return nil, err
+	}
+	if len(buf) != int(size) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return buf, nil
+}
 
-		buf = make([]byte, s.zLength)
-		if _, err := io.ReadFull(r, buf); err != nil {
-			return nil, err
-		}
-	} else {
-		buf = make([]byte, s.length)
-		if _, err := pr.file.ReadAt(buf, int64(s.offset)); err != nil {
-			return nil, err
-		}
+func (pr *FontParser) findTableBuffer(s tableSection) ([]byte, error) {
+	if s.isCompressed() {
+		return readTable(pr.file, s)
+	}
+
+	size, err := pr.file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+	if int64(s.offset)+int64(s.length) > size {
+		return nil, fmt.Errorf("invalid table section (%d > %d)", int64(s.offset)+int64(s.length), size)
+	}
+	buf := make([]byte, s.length)
+	if _, err := pr.file.ReadAt(buf, int64(s.offset)); err != nil {
+		return nil, err
 	}
 	return buf, nil
 }
@@ -122,6 +152,27 @@ func (pr *FontParser) HasTable(tag Tag) bool {
 	return has
 }
 
+// TableInfo describes an entry of the table directory of a font file.
+type TableInfo struct {
+	Tag Tag
+	// Offset and Length locate the table in the file.
+	// For WOFF files, they refer to the compressed data.
+	Offset, Length uint32
+	Checksum       uint32
+}
+
+// Tables returns the table directory of the font, sorted by tag.
+func (pr *FontParser) Tables() []TableInfo { return tablesInfo(pr.tables) }
+
+func tablesInfo(tables map[Tag]tableSection) []TableInfo {
+	out := make([]TableInfo, 0, len(tables))
+	for tag, s := range tables {
+		out = append(out, TableInfo{Tag: tag, Offset: s.offset, Length: s.length, Checksum: s.checksum})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Tag < out[j].Tag })
+	return out
+}
+
 // GetRawTable returns the binary content of the given table,
 // or an error if not found.
 // Note that many tables are already interpreted by this package,
@@ -213,6 +264,24 @@ func (pr *FontParser) cffTable(numGlyphs int) (*type1c.Font, error) {
 	return out, nil
 }
 
+func (pr *FontParser) cff2Table(numGlyphs int) (*type1c.Font, error) {
+	buf, err := pr.GetRawTable(tagCFF2)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := type1c.ParseCFF2(bytes.NewReader(buf))
+	if err != nil {
+		return nil, err
+	}
+
+	if N := out.NumGlyphs(); N != numGlyphs {
+		return nil, fmt.Errorf("invalid number of glyphs in CFF2 table (%d != %d)", N, numGlyphs)
+	}
+
+	return out, nil
+}
+
 func (pr *FontParser) sbixTable(numGlyphs int) (tableSbix, error) {
 	buf, err := pr.GetRawTable(tagSbix)
 	if err != nil {
@@ -479,12 +548,12 @@ func (pr *FontParser) tryAndLoadFvarTable(names TableName) (TableFvar, error) {
 func (pr *FontParser) tryAndLoadAvarTable(fvar TableFvar) (tableAvar, error) {
 	s, found := pr.tables[tagAvar]
 	if !found {
-		return nil, nil
+		return tableAvar{}, nil
 	}
 
 	buf, err := pr.findTableBuffer(s)
 	if err != nil {
-		return nil, err
+		return tableAvar{}, err
 	}
 
 	return parseTableAvar(buf, len(fvar.Axis))
@@ -526,6 +595,16 @@ func (pr *FontParser) mvarTable(fvar TableFvar) (TableMvar, error) {
 	return parseTableMvar(buf, len(fvar.Axis))
 }
 
+// STATTable returns the Style Attributes table identified with the 'STAT' tag.
+func (pr *FontParser) STATTable() (STATInfo, error) {
+	buf, err := pr.GetRawTable(tagSTAT)
+	if err != nil {
+		return STATInfo{}, err
+	}
+
+	return parseTableSTAT(buf)
+}
+
 func (pr *FontParser) vorgTable() (tableVorg, error) {
 	buf, err := pr.GetRawTable(tagVorg)
 	if err != nil {
@@ -654,6 +733,7 @@ func (pr *FontParser) loadTables() (*Font, error) {
 		err error
 	)
 	out.Type = pr.Type
+	out.tables, out.file = pr.tables, pr.file
 
 	out.NumGlyphs, err = pr.NumGlyphs()
 	if err != nil {
@@ -690,6 +770,7 @@ func (pr *FontParser) loadTables() (*Font, error) {
 
 	out.sbix, _ = pr.sbixTable(out.NumGlyphs)
 	out.cff, _ = pr.cffTable(out.NumGlyphs)
+	out.cff2, _ = pr.cff2Table(out.NumGlyphs)
 	out.post, _ = pr.PostTable(out.NumGlyphs)
 	out.svg, _ = pr.svgTable()
 
@@ -710,11 +791,15 @@ func (pr *FontParser) loadTables() (*Font, error) {
 	}
 
 	out.cmap, out.cmapEncoding = cmaps.BestEncoding()
+	out.cmapCache = new(cmapCache)
 	out.cmapVar = cmaps.unicodeVariation
 
 	if vorg, err := pr.vorgTable(); err == nil {
 		out.vorg = &vorg
 	}
+	if stat, err := pr.STATTable(); err == nil {
+		out.stat = &stat
+	}
 
 	out.layoutTables = pr.loadLayoutTables(out.NumGlyphs, out.fvar)
 
@@ -730,6 +815,7 @@ func (pr *FontParser) loadTables() (*Font, error) {
 	if pr.HasTable(TagPrep) {
 		out.HasHint = true
 	}
+	out.hinting = newHintingState()
 
 	err = pr.loadSummary(&out)
 	if err != nil {
@@ -60,13 +60,23 @@ func (colorBitmap bitmapTable) glyphData(gid GID, xPpem, yPpem uint16) (fonts.Gl
 	return out, nil
 }
 
-// look for data in 'glyf' and 'cff' tables
+// ErrGlyphNotFound is returned by LoadGlyphData when the font
+// has no data for the requested glyph, either because the glyph index
+// is out of range or because its tables are not supported.
+var ErrGlyphNotFound = errors.New("glyph not found")
+
+// look for data in 'glyf', 'CFF ' and 'CFF2' tables
 func (f *Font) outlineGlyphData(gid GID) (fonts.GlyphOutline, bool) {
 	out, err := f.glyphDataFromCFF1(gid)
 	if err == nil {
 		return out, true
 	}
 
+	out, err = f.glyphDataFromCFF2(gid)
+	if err == nil {
+		return out, true
+	}
+
 	out, err = f.glyphDataFromGlyf(gid)
 	if err == nil {
 		return out, true
@@ -75,18 +85,34 @@ func (f *Font) outlineGlyphData(gid GID) (fonts.GlyphOutline, bool) {
 	return fonts.GlyphOutline{}, false
 }
 
+// GlyphData implements fonts.FaceRenderer. It returns nil if the glyph
+// is not found: use LoadGlyphData to distinguish this case from an empty glyph.
 func (f *Font) GlyphData(gid GID, xPpem, yPpem uint16) fonts.GlyphData {
-	var out fonts.GlyphData
+	out, err := f.LoadGlyphData(gid, xPpem, yPpem)
+	if err != nil {
+		return nil
+	}
+	return out
+}
+
+// LoadGlyphData is the same as GlyphData, but returns ErrGlyphNotFound (possibly wrapped)
+// when the font has no data for `gid`.
+// Glyphs without contours, such as a space, are returned as an
+// empty fonts.GlyphOutline, with a nil error.
+func (f *Font) LoadGlyphData(gid GID, xPpem, yPpem uint16) (fonts.GlyphData, error) {
+	if int(gid) >= f.NumGlyphs {
+		return nil, fmt.Errorf("%w: out of range glyph %d", ErrGlyphNotFound, gid)
+	}
 
 	// try every table
 	out, err := f.sbix.glyphData(gid, xPpem, yPpem)
 	if err == nil {
-		return out
+		return out, nil
 	}
 
 	out, err = f.bitmap.glyphData(gid, xPpem, yPpem)
 	if err == nil {
-		return out
+		return out, nil
 	}
 
 	out_, ok := f.svg.glyphData(gid)
@@ -95,12 +121,12 @@ func (f *Font) GlyphData(gid GID, xPpem, yPpem uint16) fonts.GlyphData {
 		// For every SVG glyph description, there must be a corresponding TrueType,
 		// CFF or CFF2 glyph description in the font.
 		out_.Outline, _ = f.outlineGlyphData(gid)
-		return out_
+		return out_, nil
 	}
 
 	if out, ok := f.outlineGlyphData(gid); ok {
-		return out
+		return out, nil
 	}
 
-	return nil
+	return nil, fmt.Errorf("%w: no supported data for glyph %d", ErrGlyphNotFound, gid)
 }
@@ -135,8 +135,14 @@ func (f *Font) glyphDataFromGlyf(glyph GID) (fonts.GlyphOutline, error) {
 	if int(glyph) >= len(f.Glyf) {
 		return fonts.GlyphOutline{}, fmt.Errorf("out of range glyph %d", glyph)
 	}
+	if invalid, ok := f.Glyf[glyph].data.(invalidGlyphData); ok {
+		return fonts.GlyphOutline{}, invalid.err
+	}
 	var points []contourPoint
 	f.getPointsForGlyph(glyph, 0, &points)
+	if len(points) < phantomCount { // invalid composite glyph
+		return fonts.GlyphOutline{}, fmt.Errorf("invalid glyph %d", glyph)
+	}
 	segments := buildSegments(points[:len(points)-phantomCount])
 	return fonts.GlyphOutline{Segments: segments}, nil
 }
@@ -151,3 +157,15 @@ func (f *Font) glyphDataFromCFF1(glyph GID) (fonts.GlyphOutline, error) {
 	}
 	return fonts.GlyphOutline{Segments: segments}, nil
 }
+
+// the outlines are interpolated for the current variation coordinates
+func (f *Font) glyphDataFromCFF2(glyph GID) (fonts.GlyphOutline, error) {
+	if f.cff2 == nil {
+		return fonts.GlyphOutline{}, errors.New("no CFF2 table")
+	}
+	segments, _, err := f.cff2.LoadVariableGlyph(glyph, f.varCoords)
+	if err != nil {
+		return fonts.GlyphOutline{}, err
+	}
+	return fonts.GlyphOutline{Segments: segments}, nil
+}
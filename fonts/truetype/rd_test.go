@@ -2,6 +2,7 @@ package truetype
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"testing"
 
@@ -210,3 +211,129 @@ func TestMixedGlyphs(t *testing.T) {
 		}
 	}
 }
+
+func TestGlyphDataKind(t *testing.T) {
+	for _, test := range []struct {
+		filename string
+		gid      GID
+		kind     fonts.GlyphDataKind
+	}{
+		{"NotoColorEmoji.ttf", 10, fonts.GlyphDataBitmap},
+		{"DejaVuSerif.ttf", 10, fonts.GlyphDataOutline},
+		{"chromacheck-svg.ttf", 1, fonts.GlyphDataSVG},
+	} {
+		font := loadFont(t, test.filename)
+		data := font.GlyphData(test.gid, 94, 94)
+		if data == nil || data.Kind() != test.kind {
+			t.Fatalf("%s: unexpected glyph data %T", test.filename, data)
+		}
+		_, isOutline := fonts.AsOutline(data)
+		_, isSVG := fonts.AsSVG(data)
+		_, isBitmap := fonts.AsBitmap(data)
+		if isOutline != (test.kind == fonts.GlyphDataOutline) || isSVG != (test.kind == fonts.GlyphDataSVG) ||
+			isBitmap != (test.kind == fonts.GlyphDataBitmap) {
+			t.Fatalf("%s: inconsistent accessors", test.filename)
+		}
+	}
+
+	if _, ok := fonts.AsOutline(nil); ok {
+		t.Fatal("unexpected outline for nil data")
+	}
+	var unset fonts.GlyphDataKind
+	if unset == fonts.GlyphDataOutline || unset == fonts.GlyphDataSVG || unset == fonts.GlyphDataBitmap {
+		t.Fatal("the zero GlyphDataKind should not be a valid kind")
+	}
+}
+
+func TestLoadGlyphData(t *testing.T) {
+	for _, filename := range []string{"DejaVuSerif.ttf", "Raleway-v4020-Regular.otf"} {
+		font := loadFont(t, filename)
+
+		gid, ok := font.NominalGlyph(' ')
+		if !ok {
+			t.Fatalf("%s: missing space glyph", filename)
+		}
+		data, err := font.LoadGlyphData(gid, 94, 94)
+		if err != nil {
+			t.Fatalf("%s: unexpected error for space glyph: %s", filename, err)
+		}
+		outline, ok := fonts.AsOutline(data)
+		if !ok || len(outline.Segments) != 0 {
+			t.Fatalf("%s: expected empty outline for space glyph, got %v", filename, data)
+		}
+		if font.GlyphData(gid, 94, 94) == nil {
+			t.Fatalf("%s: unexpected nil data for space glyph", filename)
+		}
+
+		outOfRange := GID(font.NumGlyphs + 10)
+		if _, err = font.LoadGlyphData(outOfRange, 94, 94); !errors.Is(err, ErrGlyphNotFound) {
+			t.Fatalf("%s: expected ErrGlyphNotFound, got %v", filename, err)
+		}
+		if font.GlyphData(outOfRange, 94, 94) != nil {
+			t.Fatalf("%s: expected nil data for out of range glyph", filename)
+		}
+	}
+}
+
+func TestCFF2Outlines(t *testing.T) {
+	font := loadFont(t, "TestCFF2VF.otf")
+	if font.cff2 == nil {
+		t.Fatal("missing CFF2 table")
+	}
+	gid, _ := font.NominalGlyph('A')
+
+	load := func(weight float32) []fonts.Segment {
+		SetVariations(font, []Variation{{Tag: MustNewTag("wght"), Value: weight}})
+		data, err := font.LoadGlyphData(gid, 0, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		outline, ok := fonts.AsOutline(data)
+		if !ok || len(outline.Segments) == 0 {
+			t.Fatalf("expected an outline, got %v", data)
+		}
+		return outline.Segments
+	}
+
+	regular, light, bold := load(400), load(200), load(900)
+	if len(light) != len(regular) || len(bold) != len(regular) {
+		t.Fatal("instances should have the same number of segments")
+	}
+	// the glyph gets wider
+	extents := func(weight float32) fonts.GlyphExtents {
+		SetVariations(font, []Variation{{Tag: MustNewTag("wght"), Value: weight}})
+		ext, _ := font.GlyphExtents(gid, 0, 0)
+		return ext
+	}
+	regularExt, lightExt, boldExt := extents(400), extents(200), extents(900)
+	if !(lightExt.Width < regularExt.Width && regularExt.Width < boldExt.Width) {
+		t.Fatalf("unexpected widths %v %v %v", lightExt, regularExt, boldExt)
+	}
+
+	// the default instance is used without variations
+	SetVariations(font, nil)
+	segments, _, err := font.cff2.LoadGlyph(gid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(segments) != fmt.Sprint(regular) {
+		t.Fatalf("expected default outlines %v, got %v", regular, segments)
+	}
+
+	// this font only has a [0, 1] region above the default,
+	// so that intermediate instances are linear interpolations
+	// (up to rounding errors)
+	font.SetVarCoordinates([]float32{1})
+	max, _ := font.LoadGlyphData(gid, 0, 0)
+	font.SetVarCoordinates([]float32{0.5})
+	middle, _ := font.LoadGlyphData(gid, 0, 0)
+	maxSegments, middleSegments := max.(fonts.GlyphOutline).Segments, middle.(fonts.GlyphOutline).Segments
+	for i, seg := range middleSegments {
+		for j, pt := range seg.Args {
+			exp := fonts.SegmentPoint{X: (regular[i].Args[j].X + maxSegments[i].Args[j].X) / 2, Y: (regular[i].Args[j].Y + maxSegments[i].Args[j].Y) / 2}
+			if dx, dy := pt.X-exp.X, pt.Y-exp.Y; dx < -4 || dx > 4 || dy < -4 || dy > 4 {
+				t.Fatalf("expected %v, got %v", exp, pt)
+			}
+		}
+	}
+}
@@ -29,6 +29,8 @@ var (
 	tagPost = MustNewTag("post")
 	TagSilf = MustNewTag("Silf")
 	TagPrep = MustNewTag("prep")
+	tagFpgm = MustNewTag("fpgm")
+	tagCvt  = MustNewTag("cvt ")
 	tagLoca = MustNewTag("loca")
 	tagGlyf = MustNewTag("glyf")
 	tagCFF  = MustNewTag("CFF ")
@@ -49,6 +51,7 @@ var (
 	tagMvar = MustNewTag("MVAR")
 	tagHvar = MustNewTag("HVAR")
 	tagVvar = MustNewTag("VVAR")
+	tagSTAT = MustNewTag("STAT")
 
 	tagFeat = MustNewTag("feat")
 	tagMort = MustNewTag("mort")
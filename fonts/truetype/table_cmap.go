@@ -4,6 +4,8 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"sync/atomic"
+	"unicode"
 
 	"github.com/benoitkugler/textlayout/fonts"
 	"golang.org/x/text/encoding/charmap"
@@ -175,6 +177,43 @@ func (s cmap4) Lookup(r rune) (GID, bool) {
 	return 0, false
 }
 
+// lookupAll stores the glyphs for `runes` in `out`, starting
+// with the segment of the previous rune, since consecutive runes
+// are likely to be in the same range.
+func (s cmap4) lookupAll(runes []rune, out []GID) {
+	last := -1
+	for k, r := range runes {
+		out[k] = 0
+		if uint32(r) > 0xffff {
+			continue
+		}
+		c := uint16(r)
+		if last == -1 || c < s[last].start || s[last].end < c {
+			last = -1
+			for i, j := 0, len(s); i < j; {
+				h := i + (j-i)/2
+				if c < s[h].start {
+					j = h
+				} else if s[h].end < c {
+					i = h + 1
+				} else {
+					last = h
+					break
+				}
+			}
+			if last == -1 {
+				continue
+			}
+		}
+		entry := s[last]
+		if entry.indexes == nil {
+			out[k] = GID(c + entry.delta)
+		} else if glyph := entry.indexes[c-entry.start]; glyph != 0 {
+			out[k] = GID(glyph + entry.delta)
+		}
+	}
+}
+
 type cmap6or10 struct {
 	entries   []uint16
 	firstCode rune
@@ -256,6 +295,34 @@ func (s cmap12) Lookup(r rune) (GID, bool) {
 	return 0, false
 }
 
+// lookupAll stores the glyphs for `runes` in `out`, starting
+// with the segment of the previous rune.
+func (s cmap12) lookupAll(runes []rune, out []GID) {
+	last := -1
+	for k, r := range runes {
+		out[k] = 0
+		c := uint32(r)
+		if last == -1 || c < s[last].start || s[last].end < c {
+			last = -1
+			for i, j := 0, len(s); i < j; {
+				h := i + (j-i)/2
+				if c < s[h].start {
+					j = h
+				} else if s[h].end < c {
+					i = h + 1
+				} else {
+					last = h
+					break
+				}
+			}
+			if last == -1 {
+				continue
+			}
+		}
+		out[k] = GID(c - s[last].start + s[last].value)
+	}
+}
+
 type cmap13 []cmapEntry32
 
 type cmap13Iter struct {
@@ -506,13 +573,16 @@ func parseCmapFormat4(input []byte, offset uint32) (cmap4, error) {
 			delta: binary.BigEndian.Uint16(input[2+2*(2*segCount+i):]),
 		}
 		idRangeOffset := int(binary.BigEndian.Uint16(input[2+2*(3*segCount+i):]))
+		if cm.end < cm.start {
+			return nil, fmt.Errorf("invalid cmap subtable format 4: segment %d ends before its start", i)
+		}
 
 		// some fonts use 0xFFFF for idRangeOff for the last segment
 		if cm.start != 0xFFFF && idRangeOffset != 0 {
 			// we resolve the indexes
 			cm.indexes = make([]gid, cm.end-cm.start+1)
 			indexStart := idRangeOffset/2 + i - segCount
-			if len(glyphIDArray) < 2*(indexStart+len(cm.indexes)) {
+			if indexStart < 0 || len(glyphIDArray) < 2*(indexStart+len(cm.indexes)) {
 				return nil, errors.New("invalid cmap subtable format 4 glyphs array length")
 			}
 			for j := range cm.indexes {
@@ -724,3 +794,36 @@ func parseUint24(b []byte) rune {
 	_ = b[2] // BCE
 	return rune(b[0])<<16 | rune(b[1])<<8 | rune(b[2])
 }
+
+// cmapCacheSize is the number of runes remembered by cmapCache
+const cmapCacheSize = 256
+
+// cmapCache is a small direct-mapped cache of cmap lookups,
+// speeding up the shaping of repetitive content.
+// It is safe for concurrent use: each entry packs the rune (with an offset of 1,
+// so that the zero value is an empty entry), the presence flag and the glyph
+// in one uint64, accessed atomically.
+type cmapCache [cmapCacheSize]uint64
+
+// get returns the cached lookup of `r`, or found = false
+func (cache *cmapCache) get(r rune) (gid GID, ok, found bool) {
+	if r < 0 || r > unicode.MaxRune {
+		return 0, false, false
+	}
+	entry := atomic.LoadUint64(&cache[r%cmapCacheSize])
+	if entry>>33 != uint64(r)+1 {
+		return 0, false, false
+	}
+	return GID(uint32(entry)), entry&(1<<32) != 0, true
+}
+
+func (cache *cmapCache) set(r rune, gid GID, ok bool) {
+	if r < 0 || r > unicode.MaxRune {
+		return
+	}
+	entry := (uint64(r)+1)<<33 | uint64(gid)
+	if ok {
+		entry |= 1 << 32
+	}
+	atomic.StoreUint64(&cache[r%cmapCacheSize], entry)
+}
@@ -7,7 +7,9 @@ import (
 	"fmt"
 	"log"
 	"reflect"
+	"sync"
 	"testing"
+	"unicode"
 
 	testdata "github.com/benoitkugler/textlayout-testdata/truetype"
 	"github.com/benoitkugler/textlayout/fonts"
@@ -269,3 +271,97 @@ func TestCmap12(t *testing.T) {
 		}
 	}
 }
+
+func TestNominalGlyphs(t *testing.T) {
+	for _, file := range []string{
+		"DejaVuSerif.ttf",
+		"ToyCMAP12.otf",
+		"FreeSerif.ttf",
+	} {
+		font := loadFont(t, file)
+
+		runes := []rune("aAb a0\U0010FFFD")
+		gids := font.NominalGlyphs(runes)
+		for i, r := range runes {
+			exp, _ := font.NominalGlyph(r)
+			if gids[i] != exp {
+				t.Fatalf("%s: for rune 0x%x expected %d, got %d", file, r, exp, gids[i])
+			}
+		}
+	}
+
+	font := loadFont(t, "DejaVuSerif.ttf")
+	if gid, ok := font.NominalGlyph('a'); !ok || gid == 0 {
+		t.Fatalf("expected a glyph for 'a', got %d, %v", gid, ok)
+	}
+	if gid, ok := font.NominalGlyph(0x4E2D); ok || gid != 0 {
+		t.Fatalf("expected 0, false for absent rune, got %d, %v", gid, ok)
+	}
+	if gids := font.NominalGlyphs([]rune{0x4E2D}); gids[0] != 0 {
+		t.Fatalf("expected 0 for absent rune, got %d", gids[0])
+	}
+}
+
+func TestCmapCache(t *testing.T) {
+	for _, file := range []string{
+		"DejaVuSerif.ttf",
+		"ToyCMAP12.otf",
+		"FreeSerif.ttf",
+	} {
+		font := loadFont(t, file)
+
+		// runes sharing the same cache entry, absent runes and invalid runes
+		runes := []rune{'a', 'a' + cmapCacheSize, 'a' + 2*cmapCacheSize, '0', 0x4E2D, 0x10FFFD, -1, unicode.MaxRune + 1}
+
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					for _, r := range runes {
+						font.NominalGlyph(r)
+					}
+				}
+			}()
+		}
+		wg.Wait()
+
+		for range [2]int{} { // the second pass hits the cache
+			for _, r := range runes {
+				expGID, expOk := font.cmap.Lookup(r)
+				gid, ok := font.NominalGlyph(r)
+				if gid != expGID || ok != expOk {
+					t.Fatalf("%s: for rune 0x%x expected (%d, %v), got (%d, %v)", file, r, expGID, expOk, gid, ok)
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkNominalGlyph(b *testing.B) {
+	file, err := testdata.Files.ReadFile("DejaVuSerif.ttf")
+	if err != nil {
+		b.Fatal(err)
+	}
+	font, err := Parse(bytes.NewReader(file))
+	if err != nil {
+		b.Fatal(err)
+	}
+	text := []rune("0000000000 1111111111 Lorem ipsum dolor sit amet, consectetur adipiscing elit")
+
+	b.Run("uncached", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, r := range text {
+				font.cmap.Lookup(r)
+			}
+		}
+	})
+	b.Run("cached", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, r := range text {
+				font.NominalGlyph(r)
+			}
+		}
+	})
+}
@@ -37,18 +37,27 @@ func parseTableLoca(data []byte, numGlyphs int, isLong bool) (out []uint32, err
 }
 
 // locaOffsets has length numGlyphs + 1
+// Invalid glyphs do not fail the whole table: they are stored
+// as invalidGlyphData, and the error is reported when the glyph is used.
 func parseTableGlyf(data []byte, locaOffsets []uint32) (TableGlyf, error) {
 	out := make(TableGlyf, len(locaOffsets)-1)
-	var err error
 	for i := range out {
+		// protect against truncated 'glyf' tables or invalid 'loca' offsets
+		start, end := locaOffsets[i], locaOffsets[i+1]
+		if end > uint32(len(data)) {
+			end = uint32(len(data))
+		}
 		// If a glyph has no outline, then loca[n] = loca [n+1].
-		if locaOffsets[i] == locaOffsets[i+1] {
+		// Like harfbuzz, misaligned offsets are also treated as empty glyphs.
+		if start >= end {
 			continue
 		}
-		out[i], err = parseGlyphData(data, locaOffsets[i])
+		g, err := parseGlyphData(data[:end], start)
 		if err != nil {
-			return nil, err
+			out[i].data = invalidGlyphData{fmt.Errorf("invalid glyph %d: %s", i, err)}
+			continue
 		}
+		out[i] = g
 	}
 	return out, nil
 }
@@ -80,6 +89,13 @@ type GlyphData struct {
 
 func (simpleGlyphData) isGlyphData()    {}
 func (compositeGlyphData) isGlyphData() {}
+func (invalidGlyphData) isGlyphData()   {}
+
+// invalidGlyphData is used for glyphs which can't be parsed,
+// and behave as absent glyphs.
+type invalidGlyphData struct {
+	err error
+}
 
 // does not includes phantom points
 func (g GlyphData) pointNumbersCount() int {
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 
 	testdata "github.com/benoitkugler/textlayout-testdata/truetype"
@@ -442,3 +443,72 @@ func BenchmarkParseContour(b *testing.B) {
 		parseGlyphContourPoints(data[:19], data[19:19+18], points)
 	}
 }
+
+func TestGlyfTruncated(t *testing.T) {
+	file, err := testdata.Files.ReadFile("Roboto-BoldItalic.ttf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	font, err := NewFontParser(bytes.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	head, err := font.loadHeadTable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ng, err := font.NumGlyphs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	locaData, err := font.GetRawTable(tagLoca)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loca, err := parseTableLoca(locaData, ng, head.indexToLocFormat == 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	glyfData, err := font.GetRawTable(tagGlyf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// cut the table in the middle of the last non empty glyph
+	gid := ng - 1
+	for loca[gid] == loca[gid+1] {
+		gid--
+	}
+	truncated := glyfData[:(loca[gid]+loca[gid+1])/2]
+	glyphs, err := parseTableGlyf(truncated, loca)
+	if err != nil {
+		t.Fatal(err)
+	}
+	invalid, ok := glyphs[gid].data.(invalidGlyphData)
+	if !ok {
+		t.Fatalf("expected invalid glyph %d, got %v", gid, glyphs[gid].data)
+	}
+	if exp := fmt.Sprintf("glyph %d ", gid); !strings.Contains(invalid.err.Error(), exp) {
+		t.Fatalf("expected error for glyph %d, got %s", gid, invalid.err)
+	}
+	for i, g := range glyphs[:gid] { // other glyphs are still available
+		if _, ok := g.data.(invalidGlyphData); ok {
+			t.Fatalf("unexpected invalid glyph %d", i)
+		}
+	}
+	ft := Font{Glyf: glyphs}
+	if _, err = ft.glyphDataFromGlyf(GID(gid)); err == nil {
+		t.Fatalf("expected error for glyph %d", gid)
+	}
+
+	// invalid (decreasing) offsets are treated as empty glyphs
+	misaligned := append([]uint32(nil), loca...)
+	misaligned[gid+1] = misaligned[gid] - 2
+	glyphs, err = parseTableGlyf(glyfData, misaligned)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if glyphs[gid].data != nil {
+		t.Fatalf("expected empty glyph %d, got %v", gid, glyphs[gid].data)
+	}
+}
@@ -482,7 +482,7 @@ func parseGPOSMarkToBase(data []byte, markCov Coverage) (out GPOSMarkToBase1, er
 	}
 	data = data[baseArrayOffset:]
 	baseCount := int(binary.BigEndian.Uint16(data))
-	if len(data) < 2*baseCount*int(markClassCount) {
+	if len(data) < 2+2*baseCount*markClassCount {
 		return out, errors.New("invalid mark-to-base positionning subtable (EOF)")
 	}
 	if out.BaseCoverage.Size() != baseCount {
@@ -305,6 +305,9 @@ func parseLigatureSet(data []byte) ([]LigatureGlyph, error) {
 		return nil, errors.New("invalid ligature set table")
 	}
 	count := binary.BigEndian.Uint16(data)
+	if len(data) < 2+2*int(count) {
+		return nil, errors.New("invalid ligature set table (EOF)")
+	}
 	out := make([]LigatureGlyph, count)
 	var err error
 	for i := range out {
@@ -102,6 +102,9 @@ func parseKernTable(input []byte, numGlyphs int) (TableKernx, error) {
 		return nil, fmt.Errorf("unsupported kern table version: %d", major)
 	}
 
+	if uint64(len(input)) < uint64(numTables)*uint64(subtableHeaderLength) {
+		return nil, errors.New("invalid kern table (EOF)")
+	}
 	out := make([]KernSubtable, numTables)
 	var (
 		err    error
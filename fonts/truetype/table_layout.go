@@ -135,6 +135,8 @@ type FeatureRecord struct {
 type Feature struct {
 	LookupIndices []uint16
 	paramsOffet   uint16
+
+	size *OpticalSizeInfo // only for the 'size' feature
 }
 
 type LookupOptions struct {
@@ -386,6 +388,9 @@ func (t *TableLayout) parseFeatureList(buf []byte) error {
 		if err != nil {
 			return err
 		}
+		if record.Tag == tagSize {
+			feature.size = parseSizeParams(b, int(record.Offset), int(feature.paramsOffet))
+		}
 
 		t.Features[i] = FeatureRecord{Tag: record.Tag, Feature: feature}
 	}
@@ -0,0 +1,169 @@
+package truetype
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// Flags used by STATAxisValue
+const (
+	// If set, this axis value table provides axis value information that is
+	// applicable to other fonts within the same font family.
+	OlderSiblingFontAttribute uint16 = 0x0001
+	// If set, it indicates that the axis value represents the “normal”
+	// value for the axis and may be omitted when composing name strings.
+	ElidableAxisValueName uint16 = 0x0002
+)
+
+// STATInfo exposes the content of the 'STAT' table, which describes
+// the design attributes that distinguish the faces of a font family.
+// See https://docs.microsoft.com/en-us/typography/opentype/spec/stat
+type STATInfo struct {
+	Axes   []STATAxis
+	Values []STATAxisValue
+	// ElidedFallbackName is the name to use when all the axis values
+	// of a face are elidable. It defaults to NameFontSubfamily for
+	// version 1.0 tables.
+	ElidedFallbackName NameID
+}
+
+// STATAxis is a design axis of the font family.
+type STATAxis struct {
+	Tag      Tag
+	Name     NameID
+	Ordering uint16 // used when composing names
+}
+
+// STATAxisLocation is a position on one axis.
+type STATAxisLocation struct {
+	AxisIndex uint16 // into STATInfo.Axes
+	Value     float32
+}
+
+// STATAxisValue associates a name with a position or range
+// on one or several axes.
+type STATAxisValue struct {
+	// Format is 1 for a single value, 2 for a range, 3 for a value
+	// linked to another one (usually its bold counterpart), and 4
+	// for a location on several axes.
+	Format uint16
+	Flags  uint16 // see OlderSiblingFontAttribute and ElidableAxisValueName
+	Name   NameID
+
+	// Locations has one element for formats 1, 2 and 3;
+	// for format 2, Value is the nominal value.
+	Locations []STATAxisLocation
+
+	RangeMin, RangeMax float32 // only for format 2
+	LinkedValue        float32 // only for format 3
+}
+
+func parseTableSTAT(data []byte) (out STATInfo, err error) {
+	const headerSize = 18
+	if len(data) < headerSize {
+		return out, errors.New("invalid 'STAT' table (EOF)")
+	}
+	minorVersion := binary.BigEndian.Uint16(data[2:])
+	designAxisSize := int(binary.BigEndian.Uint16(data[4:]))
+	designAxisCount := int(binary.BigEndian.Uint16(data[6:]))
+	designAxesOffset := int(binary.BigEndian.Uint32(data[8:]))
+	axisValueCount := int(binary.BigEndian.Uint16(data[12:]))
+	axisValuesOffset := int(binary.BigEndian.Uint32(data[14:]))
+
+	out.ElidedFallbackName = NameFontSubfamily
+	if minorVersion >= 1 {
+		if len(data) < headerSize+2 {
+			return out, errors.New("invalid 'STAT' table (EOF)")
+		}
+		out.ElidedFallbackName = NameID(binary.BigEndian.Uint16(data[18:]))
+	}
+
+	if designAxisCount != 0 {
+		if designAxisSize < 8 || len(data) < designAxesOffset+designAxisCount*designAxisSize {
+			return out, errors.New("invalid 'STAT' table design axes (EOF)")
+		}
+		out.Axes = make([]STATAxis, designAxisCount)
+		for i := range out.Axes {
+			record := data[designAxesOffset+i*designAxisSize:]
+			out.Axes[i] = STATAxis{
+				Tag:      Tag(binary.BigEndian.Uint32(record)),
+				Name:     NameID(binary.BigEndian.Uint16(record[4:])),
+				Ordering: binary.BigEndian.Uint16(record[6:]),
+			}
+		}
+	}
+
+	if axisValueCount == 0 {
+		return out, nil
+	}
+	if len(data) < axisValuesOffset {
+		return out, errors.New("invalid 'STAT' table axis values (EOF)")
+	}
+	offsets, err := parseUint16s(data[axisValuesOffset:], axisValueCount)
+	if err != nil {
+		return out, fmt.Errorf("invalid 'STAT' table axis values: %s", err)
+	}
+	out.Values = make([]STATAxisValue, 0, axisValueCount)
+	for _, offset := range offsets {
+		value, err := parseSTATAxisValue(data, axisValuesOffset+int(offset))
+		if err != nil {
+			return out, err
+		}
+		if value.Format == 0 { // unknown format, ignored
+			continue
+		}
+		for _, loc := range value.Locations {
+			if int(loc.AxisIndex) >= designAxisCount {
+				return out, fmt.Errorf("invalid 'STAT' table axis index %d", loc.AxisIndex)
+			}
+		}
+		out.Values = append(out.Values, value)
+	}
+	return out, nil
+}
+
+// return a zero Format for unsupported formats
+func parseSTATAxisValue(data []byte, offset int) (out STATAxisValue, err error) {
+	if len(data) < offset+8 {
+		return out, errors.New("invalid 'STAT' table axis value (EOF)")
+	}
+	data = data[offset:]
+	format := binary.BigEndian.Uint16(data)
+	out.Flags = binary.BigEndian.Uint16(data[4:])
+	out.Name = NameID(binary.BigEndian.Uint16(data[6:]))
+	switch format {
+	case 1, 2, 3:
+		size := [4]int{0, 12, 20, 16}[format]
+		if len(data) < size {
+			return out, fmt.Errorf("invalid 'STAT' table axis value format %d (EOF)", format)
+		}
+		out.Locations = []STATAxisLocation{{
+			AxisIndex: binary.BigEndian.Uint16(data[2:]),
+			Value:     fixed1616ToFloat(binary.BigEndian.Uint32(data[8:])),
+		}}
+		if format == 2 {
+			out.RangeMin = fixed1616ToFloat(binary.BigEndian.Uint32(data[12:]))
+			out.RangeMax = fixed1616ToFloat(binary.BigEndian.Uint32(data[16:]))
+		} else if format == 3 {
+			out.LinkedValue = fixed1616ToFloat(binary.BigEndian.Uint32(data[12:]))
+		}
+	case 4:
+		axisCount := int(binary.BigEndian.Uint16(data[2:]))
+		if len(data) < 8+6*axisCount {
+			return out, errors.New("invalid 'STAT' table axis value format 4 (EOF)")
+		}
+		out.Locations = make([]STATAxisLocation, axisCount)
+		for i := range out.Locations {
+			record := data[8+6*i:]
+			out.Locations[i] = STATAxisLocation{
+				AxisIndex: binary.BigEndian.Uint16(record),
+				Value:     fixed1616ToFloat(binary.BigEndian.Uint32(record[2:])),
+			}
+		}
+	default:
+		return STATAxisValue{}, nil
+	}
+	out.Format = format
+	return out, nil
+}
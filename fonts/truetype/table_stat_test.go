@@ -0,0 +1,81 @@
+package truetype
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSTAT(t *testing.T) {
+	font := loadFont(t, "SelawikVar.ttf")
+	stat, ok := font.STAT()
+	if !ok {
+		t.Fatal("missing 'STAT' table")
+	}
+
+	expAxes := []STATAxis{
+		{Tag: MustNewTag("ital"), Name: 256, Ordering: 1},
+		{Tag: MustNewTag("wght"), Name: 256, Ordering: 0},
+	}
+	if !reflect.DeepEqual(stat.Axes, expAxes) {
+		t.Fatalf("expected %v, got %v", expAxes, stat.Axes)
+	}
+	if stat.ElidedFallbackName != 259 {
+		t.Fatalf("unexpected elided fallback name %d", stat.ElidedFallbackName)
+	}
+
+	if len(stat.Values) != 6 {
+		t.Fatalf("expected 6 axis values, got %d", len(stat.Values))
+	}
+	upright := STATAxisValue{Format: 1, Flags: ElidableAxisValueName, Name: 259, Locations: []STATAxisLocation{{AxisIndex: 0, Value: 0}}}
+	if !reflect.DeepEqual(stat.Values[0], upright) {
+		t.Fatalf("expected %v, got %v", upright, stat.Values[0])
+	}
+	for i, weight := range []float32{300, 350, 400, 600, 700} {
+		value := stat.Values[i+1]
+		if value.Format != 1 || value.Name != NameID(257+i) || value.Locations[0] != (STATAxisLocation{AxisIndex: 1, Value: weight}) {
+			t.Fatalf("unexpected axis value %v", value)
+		}
+	}
+
+	font = loadFont(t, "Commissioner-VF.ttf")
+	stat, ok = font.STAT()
+	if !ok || len(stat.Axes) != 4 || len(stat.Values) == 0 {
+		t.Fatalf("invalid 'STAT' table %v", stat)
+	}
+
+	font = loadFont(t, "Roboto-BoldItalic.ttf")
+	if _, ok = font.STAT(); ok {
+		t.Fatal("unexpected 'STAT' table")
+	}
+}
+
+func TestSTATFormats(t *testing.T) {
+	data := []byte{
+		0, 1, 0, 1, // version 1.1
+		0, 8, 0, 1, 0, 0, 0, 20, // one design axis
+		0, 4, 0, 0, 0, 28, // four axis values
+		0, 2, // elided fallback name
+		'w', 'g', 'h', 't', 1, 0, 0, 0, // design axis
+		0, 8, 0, 28, 0, 44, 0, 46, // offsets to axis values
+		0, 2, 0, 0, 0, 0, 1, 1, 0, 0x64, 0, 0, 0, 0x32, 0, 0, 0, 0x96, 0, 0, // format 2
+		0, 3, 0, 0, 0, 2, 1, 2, 1, 0x90, 0, 0, 2, 0xbc, 0, 0, // format 3
+		0, 5, // unknown format
+		0, 4, 0, 1, 0, 0, 1, 3, 0, 0, 0, 0x2a, 0, 0, // format 4
+	}
+	stat, err := parseTableSTAT(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := []STATAxisValue{
+		{Format: 2, Name: 257, Locations: []STATAxisLocation{{0, 100}}, RangeMin: 50, RangeMax: 150},
+		{Format: 3, Flags: ElidableAxisValueName, Name: 258, Locations: []STATAxisLocation{{0, 400}}, LinkedValue: 700},
+		{Format: 4, Name: 259, Locations: []STATAxisLocation{{0, 42}}},
+	}
+	if !reflect.DeepEqual(stat.Values, exp) {
+		t.Fatalf("expected %v, got %v", exp, stat.Values)
+	}
+
+	if _, err = parseTableSTAT(data[:60]); err == nil {
+		t.Fatal("expected error on truncated table")
+	}
+}
@@ -97,34 +97,62 @@ func parseOneVarInstance(data []byte, axisCount uint16, withPs bool) VarInstance
 
 // -------------------------- avar table --------------------------
 
-// one segment map for each axis, in the order of axes specified in the 'fvar' table.
-type tableAvar [][]axisValueMap
+type tableAvar struct {
+	// one segment map for each axis, in the order of axes specified in the 'fvar' table.
+	axisSegmentMaps [][]axisValueMap
+
+	// version 2 only, applied after the segment maps; may be empty
+	axisIndexMap deltaSetMapping
+	store        VariationStore
+}
 
 type axisValueMap struct {
 	from, to float32 // found as int16 2.14 fixed point
 }
 
-func parseTableAvar(data []byte, axisCountRef int) (tableAvar, error) {
+func parseTableAvar(table []byte, axisCountRef int) (out tableAvar, err error) {
 	const avarHeaderSize = 2 * 4
-	if len(data) < avarHeaderSize {
-		return nil, errors.New("invalid 'avar' table (EOF)")
+	if len(table) < avarHeaderSize {
+		return out, errors.New("invalid 'avar' table (EOF)")
 	}
-	// table.majorVersion = binary.BigEndian.Uint16(data)
-	// table.minorVersion = binary.BigEndian.Uint16(data[2:])
+	majorVersion := binary.BigEndian.Uint16(table)
+	// table.minorVersion = binary.BigEndian.Uint16(table[2:])
 	// reserved
-	axisCount := binary.BigEndian.Uint16(data[6:])
-	out := make([][]axisValueMap, axisCount) // guarded by 16-bit constraint
+	axisCount := binary.BigEndian.Uint16(table[6:])
+	out.axisSegmentMaps = make([][]axisValueMap, axisCount) // guarded by 16-bit constraint
 
 	if int(axisCount) != axisCountRef {
-		return nil, errors.New("invalid 'avar' table axis count")
+		return out, errors.New("invalid 'avar' table axis count")
 	}
 
-	var err error
-	data = data[avarHeaderSize:] // start at the first segment list
-	for i := range out {
-		out[i], data, err = parseSegmentList(data)
+	data := table[avarHeaderSize:] // start at the first segment list
+	for i := range out.axisSegmentMaps {
+		out.axisSegmentMaps[i], data, err = parseSegmentList(data)
 		if err != nil {
-			return nil, err
+			return out, err
+		}
+	}
+
+	if majorVersion < 2 {
+		return out, nil
+	}
+
+	// version 2: the offsets follow the segment maps
+	if len(data) < 8 {
+		return out, errors.New("invalid 'avar' table (EOF)")
+	}
+	axisIndexMapOffset := binary.BigEndian.Uint32(data)
+	varStoreOffset := binary.BigEndian.Uint32(data[4:])
+	if axisIndexMapOffset != 0 {
+		out.axisIndexMap, err = parseDeltaSetMapping(table, axisIndexMapOffset)
+		if err != nil {
+			return out, err
+		}
+	}
+	if varStoreOffset != 0 {
+		out.store, err = parseVariationStore(table, varStoreOffset, axisCountRef)
+		if err != nil {
+			return out, err
 		}
 	}
 	return out, nil
@@ -431,12 +459,22 @@ func parseDeltaSetMapping(data []byte, offset uint32) (deltaSetMapping, error) {
 	if len(data) < int(offset)+4 {
 		return nil, errors.New("invalid delta-set mapping (EOF)")
 	}
-	format := binary.BigEndian.Uint16(data[offset:])
-	count := int(binary.BigEndian.Uint16(data[offset+2:]))
-	data = data[offset+4:]
+	// format 1 (used by 'avar' version 2) has a 32-bit count
+	format, entryFormat := data[offset], data[offset+1]
+	var count int
+	if format == 1 {
+		if len(data) < int(offset)+6 {
+			return nil, errors.New("invalid delta-set mapping (EOF)")
+		}
+		count = int(binary.BigEndian.Uint32(data[offset+2:]))
+		data = data[offset+6:]
+	} else {
+		count = int(binary.BigEndian.Uint16(data[offset+2:]))
+		data = data[offset+4:]
+	}
 
-	entrySize := int((format&0x0030)>>4 + 1)
-	innerBitSize := format&0x0F + 1
+	entrySize := int((entryFormat&0x30)>>4 + 1)
+	innerBitSize := entryFormat&0x0F + 1
 	if entrySize > 4 || len(data) < entrySize*count {
 		return nil, errors.New("invalid delta-set mapping (EOF)")
 	}
@@ -635,6 +673,9 @@ func parseTableGvar(data []byte, axisCountRef int, glyphs TableGlyf) (out tableG
 		if offsets[i] == offsets[i+1] {
 			continue
 		}
+		if offsets[i] > offsets[i+1] || int(offsets[i+1]) > len(startDataVariations) {
+			return out, errors.New("invalid 'gvar' table (EOF)")
+		}
 
 		out.variations[i], err = parseOneGlyphVariationData(startDataVariations[:offsets[i+1]], offsets[i], false,
 			axisCount, glyphs[i].pointNumbersCount()+phantomCount)
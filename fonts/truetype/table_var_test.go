@@ -2,6 +2,7 @@ package truetype
 
 import (
 	"bytes"
+	"encoding/binary"
 	"encoding/hex"
 	"fmt"
 	"log"
@@ -521,3 +522,119 @@ func TestNormalize(t *testing.T) {
 		t.Fatalf("expected %v, got %v", exp, coords)
 	}
 }
+
+func TestClosestInstance(t *testing.T) {
+	font := loadFont(t, "Commissioner-VF.ttf")
+	wght, slnt := MustNewTag("wght"), MustNewTag("slnt")
+
+	instance, distance := font.ClosestInstance(map[Tag]float32{wght: 690, slnt: -1})
+	if name := font.Names.SelectEntry(instance.Subfamily).String(); name != "Bold" {
+		t.Fatalf("expected Bold instance, got %s", name)
+	}
+	if distance <= 0 || distance > 0.1 {
+		t.Fatalf("unexpected distance %g", distance)
+	}
+
+	instance, distance = font.ClosestInstance(map[Tag]float32{wght: 700, slnt: -12})
+	if name := font.Names.SelectEntry(instance.Subfamily).String(); name != "Bold Italic" || distance != 0 {
+		t.Fatalf("expected exact Bold Italic instance, got %s (%g)", name, distance)
+	}
+
+	// missing axis use the default value
+	instance, distance = font.ClosestInstance(nil)
+	if !font.fvar.IsDefaultInstance(instance) || distance != 0 {
+		t.Fatalf("expected default instance, got %v (%g)", instance, distance)
+	}
+
+	font = loadFont(t, "Roboto-BoldItalic.ttf")
+	if instance, _ = font.ClosestInstance(map[Tag]float32{wght: 700}); instance.Coords != nil {
+		t.Fatal("unexpected instance for non variable font")
+	}
+}
+
+func TestAvar2(t *testing.T) {
+	avar := []byte{
+		0, 2, 0, 0, 0, 0, 0, 2, // version 2.0, 2 axis
+		0, 3, 0xc0, 0, 0xc0, 0, 0, 0, 0, 0, 0x40, 0, 0x40, 0, // identity segment map
+		0, 0, // empty segment map
+		0, 0, 0, 32, 0, 0, 0, 40, // offsets to the axis index map and the variation store
+		1, 0, 0, 0, 0, 2, 1, 0, // axis index map (format 1): axis 0 -> inner 1, axis 1 -> inner 0
+		0, 1, 0, 0, 0, 12, 0, 1, 0, 0, 0, 28, // variation store header
+		0, 2, 0, 1, 0, 0, 0x40, 0, 0x40, 0, 0, 0, 0, 0, 0, 0, // one region, peak on the first axis
+		0, 2, 0, 1, 0, 1, 0, 0, 0x10, 0, 0, 0, // two items: 0.25 for inner 0, 0 for inner 1
+	}
+	table, err := parseTableAvar(avar, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	font := Font{
+		fvar: TableFvar{Axis: []VarAxis{
+			{Tag: MustNewTag("wght"), Minimum: 100, Default: 400, Maximum: 900},
+			{Tag: MustNewTag("wdth"), Minimum: 50, Default: 100, Maximum: 200},
+		}},
+		avar: table,
+	}
+	for _, test := range []struct {
+		design, normalized []float32
+	}{
+		{[]float32{400, 100}, []float32{0, 0}},
+		{[]float32{100, 100}, []float32{-1, 0}},
+		{[]float32{900, 100}, []float32{1, 0.25}},
+		{[]float32{650, 75}, []float32{0.5, -0.375}},
+		{[]float32{900, 200}, []float32{1, 1}}, // clamped
+		// the delta of 24.576 is rounded
+		{[]float32{403, 100}, []float32{0.006, 25. / (1 << 14)}},
+	} {
+		if got := font.NormalizeVariations(test.design); !reflect.DeepEqual(got, test.normalized) {
+			t.Errorf("expected %v, got %v", test.normalized, got)
+		}
+	}
+
+	if _, err = parseTableAvar(avar[:50], 2); err == nil {
+		t.Fatal("expected error on truncated table")
+	}
+}
+
+func TestMvarMetrics(t *testing.T) {
+	file, err := testdata.Files.ReadFile("Mada-VF.ttf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	font := loadFont(t, "Mada-VF.ttf")
+	wght := MustNewTag("wght")
+
+	// the font has x-height and strikeout variations
+	SetVariations(font, []Variation{{Tag: wght, Value: 100}})
+	lightXHeight, _ := font.LineMetric(fonts.XHeight)
+	lightStrikeout, _ := font.LineMetric(fonts.StrikethroughPosition)
+	SetVariations(font, []Variation{{Tag: wght, Value: 1000}})
+	boldXHeight, _ := font.LineMetric(fonts.XHeight)
+	boldStrikeout, _ := font.LineMetric(fonts.StrikethroughPosition)
+	if lightXHeight == boldXHeight || lightStrikeout == boldStrikeout {
+		t.Fatalf("expected varying metrics, got %g %g, %g %g", lightXHeight, boldXHeight, lightStrikeout, boldStrikeout)
+	}
+
+	// use the deltas of the win ascent/descent for the ascender/descender
+	// (the sort order of the records is preserved)
+	pr, err := NewFontParser(bytes.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mvar := file[pr.tables[tagMvar].offset:]
+	recordSize := int(binary.BigEndian.Uint16(mvar[6:]))
+	copy(mvar[12:], "hasc")
+	copy(mvar[12+recordSize:], "hdsc")
+	font, err = Parse(bytes.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	SetVariations(font, []Variation{{Tag: wght, Value: 100}})
+	light, _ := font.FontHExtents()
+	SetVariations(font, []Variation{{Tag: wght, Value: 1000}})
+	bold, _ := font.FontHExtents()
+	if light.Ascender == bold.Ascender || light.Descender == bold.Descender {
+		t.Fatalf("expected varying ascender and descender, got %v and %v", light, bold)
+	}
+}
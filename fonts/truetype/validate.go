@@ -0,0 +1,92 @@
+package truetype
+
+import (
+	"fmt"
+	"math/bits"
+)
+
+// ValidationWarning describes an inconsistency found by Validate.
+type ValidationWarning struct {
+	Table   Tag // the table containing the suspicious value
+	Message string
+}
+
+func (w ValidationWarning) String() string { return fmt.Sprintf("%s: %s", w.Table, w.Message) }
+
+// Validate checks some invariants between the tables of the font,
+// which are not enforced when parsing, and returns the inconsistencies found.
+// Fonts with warnings may still be used, but often render incorrectly.
+func (font *Font) Validate() []ValidationWarning {
+	var out []ValidationWarning
+	warn := func(table Tag, format string, args ...interface{}) {
+		out = append(out, ValidationWarning{Table: table, Message: fmt.Sprintf(format, args...)})
+	}
+
+	upem := font.Head.UnitsPerEm
+	if upem < 16 || upem > 16384 {
+		warn(tagHead, "invalid unitsPerEm %d (expected between 16 and 16384)", upem)
+	} else if _, hasGlyf := font.tables[tagGlyf]; hasGlyf && bits.OnesCount16(upem) != 1 {
+		warn(tagHead, "unitsPerEm %d is not a power of two, as recommended for TrueType outlines", upem)
+	}
+
+	numGlyphs := font.NumGlyphs
+	if loca, ok := font.tables[tagLoca]; ok {
+		entrySize := 2
+		if font.Head.indexToLocFormat == 1 {
+			entrySize = 4
+		}
+		// padding after the last offset is allowed
+		if exp := (numGlyphs + 1) * entrySize; int(loca.uncompressedLength()) < exp {
+			warn(tagLoca, "length %d is too short for the number of glyphs %d (expected at least %d)", loca.uncompressedLength(), numGlyphs, exp)
+		}
+	}
+
+	var (
+		invalidGlyphs int
+		firstInvalid  error
+	)
+	for _, g := range font.Glyf {
+		if invalid, ok := g.data.(invalidGlyphData); ok {
+			if invalidGlyphs == 0 {
+				firstInvalid = invalid.err
+			}
+			invalidGlyphs++
+		}
+	}
+	if invalidGlyphs != 0 {
+		warn(tagGlyf, "%d glyphs can't be parsed (such as %s)", invalidGlyphs, firstInvalid)
+	}
+
+	if font.hhea != nil {
+		numMetrics := int(font.hhea.numOfLongMetrics)
+		if numMetrics == 0 || numMetrics > numGlyphs {
+			warn(tagHhea, "invalid numberOfHMetrics %d (for %d glyphs)", numMetrics, numGlyphs)
+		}
+		if hmtx, ok := font.tables[tagHmtx]; ok && numMetrics <= numGlyphs {
+			if exp := 4*numMetrics + 2*(numGlyphs-numMetrics); int(hmtx.uncompressedLength()) < exp {
+				warn(tagHmtx, "length %d is too small for %d glyphs (expected %d)", hmtx.uncompressedLength(), numGlyphs, exp)
+			}
+		}
+	}
+
+	if font.cmap != nil {
+		var (
+			invalid   int
+			firstRune rune
+		)
+		for iter := font.cmap.Iter(); iter.Next(); {
+			r, gid := iter.Char()
+			if int(gid) >= numGlyphs {
+				if invalid == 0 {
+					firstRune = r
+				}
+				invalid++
+			}
+		}
+		if invalid != 0 {
+			warn(tagCmap, "%d runes (such as U+%04X) are mapped to glyphs out of range (for %d glyphs)", invalid, firstRune, numGlyphs)
+		}
+	}
+
+	return out
+}
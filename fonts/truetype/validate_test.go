@@ -0,0 +1,63 @@
+package truetype
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	testdata "github.com/benoitkugler/textlayout-testdata/truetype"
+)
+
+func TestValidate(t *testing.T) {
+	for _, filename := range []string{
+		"Roboto-BoldItalic.ttf",
+		"Raleway-v4020-Regular.otf",
+		"open-sans-v15-latin-regular.woff",
+	} {
+		if warnings := loadFont(t, filename).Validate(); len(warnings) != 0 {
+			t.Fatalf("%s: unexpected warnings %v", filename, warnings)
+		}
+	}
+
+	file, err := testdata.Files.ReadFile("Roboto-BoldItalic.ttf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file = append([]byte(nil), file...)
+	pr, err := NewFontParser(bytes.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// use a unitsPerEm which is not a power of two, remove glyphs from maxp
+	// and truncate the loca table (a longer one is accepted)
+	binary.BigEndian.PutUint16(file[pr.tables[tagHead].offset+18:], 1500)
+	maxp := file[pr.tables[tagMaxp].offset+4:]
+	numGlyphs := binary.BigEndian.Uint16(maxp)
+	binary.BigEndian.PutUint16(maxp, numGlyphs/2)
+	numTables := int(binary.BigEndian.Uint16(file[4:]))
+	for i := 0; i < numTables; i++ {
+		record := file[12+16*i:]
+		if Tag(binary.BigEndian.Uint32(record)) == tagLoca {
+			binary.BigEndian.PutUint32(record[12:], uint32(numGlyphs)) // less than numGlyphs/2 + 1 entries
+		}
+	}
+
+	font, err := Parse(bytes.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	warnings := font.Validate()
+	var tables []Tag
+	for _, w := range warnings {
+		tables = append(tables, w.Table)
+	}
+	expected := []Tag{tagHead, tagLoca, tagHhea, tagCmap}
+	if len(tables) != len(expected) {
+		t.Fatalf("expected warnings for %v, got %v", expected, warnings)
+	}
+	for i, tag := range expected {
+		if tables[i] != tag {
+			t.Fatalf("expected warnings for %v, got %v", expected, warnings)
+		}
+	}
+}
@@ -1,5 +1,7 @@
 package truetype
 
+import "math"
+
 var _ FaceVariable = (*Font)(nil)
 
 // FaceVariable is an extension interface supporting Opentype variable fonts.
@@ -158,6 +160,15 @@ func (fvar *TableFvar) normalizeCoordinates(coords []float32) []float32 {
 
 func (f *Font) Variations() TableFvar { return f.fvar }
 
+// STAT returns the style attributes of the font,
+// or false if the font has no valid 'STAT' table.
+func (f *Font) STAT() (STATInfo, bool) {
+	if f.stat == nil {
+		return STATInfo{}, false
+	}
+	return *f.stat, true
+}
+
 // Normalizes the given design-space coordinates. The minimum and maximum
 // values for the axis are mapped to the interval [-1,1], with the default
 // axis value mapped to 0.
@@ -172,7 +183,7 @@ func (f *Font) NormalizeVariations(coords []float32) []float32 {
 	normalized := f.fvar.normalizeCoordinates(coords)
 
 	// now applying 'avar'
-	for i, av := range f.avar {
+	for i, av := range f.avar.axisSegmentMaps {
 		for j := 1; j < len(av); j++ {
 			previous, pair := av[j-1], av[j]
 			if normalized[i] < pair.from {
@@ -184,5 +195,53 @@ func (f *Font) NormalizeVariations(coords []float32) []float32 {
 		}
 	}
 
+	// 'avar' version 2 adds deltas computed from the coordinates mapped above,
+	// rounded to 2.14 as HarfBuzz does
+	if len(f.avar.store.Datas) != 0 {
+		mapped := append([]float32(nil), normalized...)
+		for i := range normalized {
+			index := f.avar.axisIndexMap.getIndex(GID(i))
+			delta := math.Round(float64(f.avar.store.GetDelta(index, mapped)))
+			v := mapped[i] + float32(delta)/(1<<14)
+			if v > 1 {
+				v = 1
+			} else if v < -1 {
+				v = -1
+			}
+			normalized[i] = v
+		}
+	}
+
 	return normalized
 }
+
+// ClosestInstance returns the named instance nearest to the given
+// design coordinates, and its (euclidean) distance in normalized space.
+// Axis missing in `coords` use their default value.
+// For non-variable fonts, an empty instance and a zero distance are returned.
+func (f *Font) ClosestInstance(coords map[Tag]float32) (VarInstance, float32) {
+	if len(f.fvar.Instances) == 0 {
+		return VarInstance{}, 0
+	}
+
+	variations := make([]Variation, 0, len(coords))
+	for tag, value := range coords {
+		variations = append(variations, Variation{Tag: tag, Value: value})
+	}
+	target := f.NormalizeVariations(f.fvar.GetDesignCoordsDefault(variations))
+
+	var (
+		best        VarInstance
+		minDistance = float32(math.Inf(+1))
+	)
+	for _, instance := range f.fvar.Instances {
+		var distance float32
+		for i, c := range f.NormalizeVariations(instance.Coords) {
+			distance += (c - target[i]) * (c - target[i])
+		}
+		if distance < minDistance {
+			best, minDistance = instance, distance
+		}
+	}
+	return best, float32(math.Sqrt(float64(minDistance)))
+}
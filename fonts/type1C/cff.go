@@ -8,6 +8,7 @@ import (
 	"errors"
 	"io"
 	"io/ioutil"
+	"math"
 	"strings"
 
 	"github.com/benoitkugler/textlayout/fonts"
@@ -44,6 +45,14 @@ type Font struct {
 	// For CIDFonts, it can be safely indexed by `fdSelect` output
 	localSubrs [][][]byte
 	fonts.PSInfo
+
+	fontMatrix [6]float64   // from the Top DICT, or defaultFontMatrix
+	fdMatrices [][6]float64 // for CIDFonts, the matrices composed with the Font DICTs' ones, or nil
+
+	// CFF2 only
+	vstore    *variationStore // nil for CFF fonts
+	vsindexes []int32         // default vsindex, same length as `localSubrs`
+	maxStack  int32           // charstrings stack limit
 }
 
 // Parse parse a .cff font file.
@@ -102,15 +111,63 @@ func (f *Font) Cmap() (fonts.Cmap, fonts.CmapEncoding) {
 	return f.cmap, fonts.EncUnicode
 }
 
+// glyphsCharset returns the charset, restricted to the glyphs of the font,
+// since predefined charsets may be longer.
+func (f *Font) glyphsCharset() []uint16 {
+	if len(f.charset) > len(f.charstrings) {
+		return f.charset[:len(f.charstrings)]
+	}
+	return f.charset
+}
+
 // GlyphName returns the name of the glyph or an empty string if not found.
 func (f *Font) GlyphName(glyph fonts.GID) string {
-	if f.fdSelect != nil || int(glyph) >= len(f.charset) {
+	if f.fdSelect != nil || int(glyph) >= len(f.glyphsCharset()) {
 		return ""
 	}
 	out, _ := f.userStrings.getString(f.charset[glyph])
 	return out
 }
 
+// GlyphByName returns the glyph with the given name, using the charset.
+// It always returns false for CIDFonts, whose glyphs have no names.
+func (f *Font) GlyphByName(name string) (fonts.GID, bool) {
+	if f.fdSelect != nil || name == "" {
+		return 0, false
+	}
+	for gid, sid := range f.glyphsCharset() {
+		if glyphName, _ := f.userStrings.getString(sid); glyphName == name {
+			return fonts.GID(gid), true
+		}
+	}
+	return 0, false
+}
+
+// RuneToGID returns the glyph for the character code `r`, using the
+// font encoding (which may be a predefined one) and the charset.
+// Since CFF encodings are single-byte, codes above 255 are never mapped.
+func (f *Font) RuneToGID(r rune) (fonts.GID, bool) {
+	if f.Encoding == nil || r < 0 || r > 255 {
+		return 0, false
+	}
+	return f.GlyphByName(f.Encoding[r])
+}
+
+// GIDForCID returns the glyph for the character identifier `cid`, using the charset,
+// which maps glyphs to CIDs for CIDFonts.
+// It always returns false for fonts which are not CIDFonts.
+func (f *Font) GIDForCID(cid int) (fonts.GID, bool) {
+	if f.fdSelect == nil || cid < 0 || cid > math.MaxUint16 {
+		return 0, false
+	}
+	for gid, c := range f.glyphsCharset() {
+		if int(c) == cid {
+			return fonts.GID(gid), true
+		}
+	}
+	return 0, false
+}
+
 // NumGlyphs returns the number of glyphs in this font.
 // It is also the maximum glyph index + 1.
 func (f *Font) NumGlyphs() int { return len(f.charstrings) }
@@ -0,0 +1,302 @@
+package type1c
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+
+	"github.com/benoitkugler/textlayout/fonts"
+	ps "github.com/benoitkugler/textlayout/fonts/psinterpreter"
+)
+
+// ParseCFF2 parses the content of the 'CFF2' table of an OpenType font,
+// as defined in https://docs.microsoft.com/en-us/typography/opentype/spec/cff2.
+// Such tables describe the glyph outlines of variable fonts, and only
+// contain one font, without names and encoding.
+// See LoadVariableGlyph to interpolate glyph outlines.
+func ParseCFF2(file fonts.Resource) (*Font, error) {
+	_, err := file.Seek(0, io.SeekStart) // file might have been used before
+	if err != nil {
+		return nil, err
+	}
+	input, err := ioutil.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+	p := cffParser{src: input, isCFF2: true}
+	return p.parseCFF2()
+}
+
+func (p *cffParser) parseCFF2() (*Font, error) {
+	// header: majorVersion, minorVersion, headerSize, topDictLength
+	buf, err := p.read(5)
+	if err != nil {
+		return nil, err
+	}
+	if buf[0] != 2 {
+		return nil, errUnsupportedCFFVersion
+	}
+	headerSize, topDictLength := buf[2], be.Uint16(buf[3:])
+
+	// the Top DICT is not stored in an INDEX
+	if err = p.seek(int32(headerSize)); err != nil {
+		return nil, err
+	}
+	buf, err = p.read(int(topDictLength))
+	if err != nil {
+		return nil, err
+	}
+	var (
+		psi     ps.Machine
+		topDict topDictData
+	)
+	if err = psi.Run(buf, nil, nil, &topDict); err != nil {
+		return nil, err
+	}
+
+	var out Font
+	out.fontMatrix = defaultFontMatrix
+	if topDict.fontMatrix != nil {
+		out.fontMatrix = *topDict.fontMatrix
+	}
+
+	// the Global Subrs INDEX immediately follows the Top DICT
+	out.globalSubrs, err = p.parseIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = p.seek(topDict.charStringsOffset); err != nil {
+		return nil, err
+	}
+	out.charstrings, err = p.parseIndex()
+	if err != nil {
+		return nil, err
+	}
+	numGlyphs := len(out.charstrings)
+	if numGlyphs > math.MaxUint16 {
+		return nil, fmt.Errorf("invalid number of glyphs %d", numGlyphs)
+	}
+
+	// "If not present, the default value of 193 is used"
+	out.maxStack = 193
+	if topDict.maxStack > 0 {
+		out.maxStack = topDict.maxStack
+	}
+
+	out.vstore = new(variationStore)
+	if topDict.vstoreOffset != 0 {
+		*out.vstore, err = parseVariationStore(p.src, topDict.vstoreOffset)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// the FDSelect is optional when there is only one Font DICT
+	if topDict.fdSelect != 0 {
+		out.fdSelect, err = p.parseFDSelect(topDict.fdSelect, uint16(numGlyphs))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err = p.seek(topDict.fdArray); err != nil {
+		return nil, err
+	}
+	fontDicts, err := p.parseTopDicts()
+	if err != nil {
+		return nil, err
+	}
+	if len(fontDicts) == 0 {
+		return nil, errors.New("missing Font DICT in CFF2 table")
+	}
+	if out.fdSelect != nil {
+		if indexExtent := out.fdSelect.extent(); len(fontDicts) < indexExtent {
+			return nil, fmt.Errorf("invalid number of font dicts: %d (for %d)", len(fontDicts), indexExtent)
+		}
+	}
+	out.localSubrs = make([][][]byte, len(fontDicts))
+	out.vsindexes = make([]int32, len(fontDicts))
+	for i, fontDict := range fontDicts {
+		var priv privateDict
+		priv, out.localSubrs[i], err = p.parsePrivateDICT(fontDict.privateDictOffset, fontDict.privateDictLength, out.vstore)
+		if err != nil {
+			return nil, err
+		}
+		out.vsindexes[i] = priv.vsindex
+	}
+
+	return &out, nil
+}
+
+// variationStore is the Item Variation Store of a CFF2 table.
+// Only the regions are needed to resolve the blend operators.
+type variationStore struct {
+	regions [][]variationRegion // for each region, for each axis
+	datas   [][]uint16          // for each item variation data, the indexes into `regions`
+}
+
+// variationRegion stores start, peak, end coordinates.
+type variationRegion [3]float32
+
+// return the factor of the region for the given normalized coordinate
+func (reg variationRegion) evaluate(coord float32) float32 {
+	start, peak, end := reg[0], reg[1], reg[2]
+	if peak == 0 || coord == peak {
+		return 1
+	}
+	// invalid regions are neutral
+	if start > peak || peak > end || (start < 0 && end > 0) {
+		return 1
+	}
+	if coord <= start || end <= coord {
+		return 0
+	}
+	if coord < peak {
+		return (coord - start) / (peak - start)
+	}
+	return (end - coord) / (end - peak)
+}
+
+func fixed214ToFloat(fi uint16) float32 { return float32(int16(fi)) / (1 << 14) }
+
+// `offset` is the offset of the vstore data, starting with its length,
+// relative to the start of the CFF2 table.
+func parseVariationStore(src []byte, offset int32) (out variationStore, err error) {
+	// skip the uint16 length
+	if offset < 0 || len(src) < int(offset)+2+8 {
+		return out, errors.New("invalid CFF2 variation store (EOF)")
+	}
+	data := src[offset+2:]
+	// format is ignored
+	regionsOffset := be.Uint32(data[2:])
+	count := be.Uint16(data[6:])
+
+	if len(data) < int(regionsOffset)+4 {
+		return out, errors.New("invalid CFF2 variation regions list (EOF)")
+	}
+	regionsData := data[regionsOffset:]
+	axisCount := int(be.Uint16(regionsData))
+	regionCount := int(be.Uint16(regionsData[2:]))
+	if len(regionsData) < 4+6*axisCount*regionCount {
+		return out, errors.New("invalid CFF2 variation regions list (EOF)")
+	}
+	out.regions = make([][]variationRegion, regionCount)
+	for i := range out.regions {
+		ri := make([]variationRegion, axisCount)
+		for j := range ri {
+			b := regionsData[4+(i*axisCount+j)*6:]
+			ri[j] = variationRegion{fixed214ToFloat(be.Uint16(b)), fixed214ToFloat(be.Uint16(b[2:])), fixed214ToFloat(be.Uint16(b[4:]))}
+		}
+		out.regions[i] = ri
+	}
+
+	if len(data) < 8+4*int(count) {
+		return out, errors.New("invalid CFF2 variation store (EOF)")
+	}
+	out.datas = make([][]uint16, count)
+	for i := range out.datas {
+		dataOffset := be.Uint32(data[8+4*i:])
+		// itemCount, shortDeltaCount, regionIndexCount; CFF2 has no deltas
+		if len(data) < int(dataOffset)+6 {
+			return out, errors.New("invalid CFF2 item variation data (EOF)")
+		}
+		itemData := data[dataOffset:]
+		regionIndexCount := int(be.Uint16(itemData[4:]))
+		if len(itemData) < 6+2*regionIndexCount {
+			return out, errors.New("invalid CFF2 item variation data (EOF)")
+		}
+		indexes := make([]uint16, regionIndexCount)
+		for j := range indexes {
+			indexes[j] = be.Uint16(itemData[6+2*j:])
+			if int(indexes[j]) >= regionCount {
+				return out, fmt.Errorf("invalid CFF2 variation region index: %d (for size %d)", indexes[j], regionCount)
+			}
+		}
+		out.datas[i] = indexes
+	}
+	return out, nil
+}
+
+// regionCount returns the number of regions used by the item variation data `vsindex`.
+func (vs *variationStore) regionCount(vsindex int32) (int32, error) {
+	if vsindex < 0 || int(vsindex) >= len(vs.datas) {
+		return 0, fmt.Errorf("invalid vsindex %d (for length %d)", vsindex, len(vs.datas))
+	}
+	return int32(len(vs.datas[vsindex])), nil
+}
+
+// scalars returns the factors of the regions used by the item variation data `vsindex`,
+// for the normalized coordinates `coords`.
+func (vs *variationStore) scalars(vsindex int32, coords []float32) ([]float32, error) {
+	if _, err := vs.regionCount(vsindex); err != nil {
+		return nil, err
+	}
+	out := make([]float32, len(vs.datas[vsindex]))
+	if len(coords) == 0 { // default instance
+		return out, nil
+	}
+	for i, regionIndex := range vs.datas[vsindex] {
+		v := float32(1)
+		for axis, reg := range vs.regions[regionIndex] {
+			var coord float32
+			if axis < len(coords) {
+				coord = coords[axis]
+			}
+			v *= reg.evaluate(coord)
+		}
+		out[i] = v
+	}
+	return out, nil
+}
+
+// blend implements the blend operator: the stack contains `n` default values,
+// followed by `n*k` deltas (`k` deltas for each value) and `n` itself.
+// The deltas are combined using `scalars` (of length `k`) and the `n` resulting values
+// are left on the stack.
+func blend(stack *ps.ArgStack, scalars []float32) error {
+	if stack.Top < 1 {
+		return errors.New("invalid stack size for 'blend'")
+	}
+	n := stack.Pop()
+	k := int32(len(scalars))
+	// check n against Top/(k+1) so that n*(k+1) can't overflow
+	if n < 0 || n > stack.Top/(k+1) {
+		return errors.New("invalid stack size for 'blend'")
+	}
+	base := stack.Top - n*(k+1)
+	for i := int32(0); i < n; i++ {
+		v := float32(stack.Vals[base+i])
+		for j, scalar := range scalars {
+			v += scalar * float32(stack.Vals[base+n+i*k+int32(j)])
+		}
+		stack.Vals[base+i] = int32(math.Round(float64(v)))
+	}
+	stack.Top = base + n
+	return nil
+}
+
+// skipBlendDeltas implements the blend operator for the default instance,
+// removing the deltas from the stack. The default values
+// are left untouched, since they may be real numbers.
+func (vs *variationStore) skipBlendDeltas(stack *ps.ArgStack, vsindex int32) error {
+	if vs == nil {
+		return errors.New("invalid operator 'blend' in CFF table")
+	}
+	k, err := vs.regionCount(vsindex)
+	if err != nil {
+		return err
+	}
+	if stack.Top < 1 {
+		return errors.New("invalid stack size for 'blend'")
+	}
+	n := stack.Pop()
+	// check n against Top/(k+1) so that n*(k+1) can't overflow
+	if n < 0 || n > stack.Top/(k+1) {
+		return errors.New("invalid stack size for 'blend'")
+	}
+	stack.Top -= n * k
+	return nil
+}
@@ -9,11 +9,21 @@ import (
 
 // LoadGlyph parses the glyph charstring to compute segments and path bounds.
 // It returns an error if the glyph is invalid or if decoding the charstring fails.
+// For CFF2 fonts, the outlines of the default instance are returned.
 func (f *Font) LoadGlyph(glyph fonts.GID) ([]fonts.Segment, ps.PathBounds, error) {
+	return f.LoadVariableGlyph(glyph, nil)
+}
+
+// LoadVariableGlyph is the same as LoadGlyph, but for CFF2 fonts,
+// interpolates the outlines for the normalized variation coordinates `coords`.
+// `coords` is ignored for CFF fonts.
+// Note that the interpolated values are rounded to integers, so that outlines of
+// intermediate instances may be off by a few units.
+func (f *Font) LoadVariableGlyph(glyph fonts.GID, coords []float32) ([]fonts.Segment, ps.PathBounds, error) {
 	var (
 		psi    ps.Machine
 		loader type2CharstringHandler
-		index  byte = 0
+		index  uint16 = 0
 		err    error
 	)
 	if f.fdSelect != nil {
@@ -25,9 +35,24 @@ func (f *Font) LoadGlyph(glyph fonts.GID) ([]fonts.Segment, ps.PathBounds, error
 	if int(glyph) >= len(f.charstrings) {
 		return nil, ps.PathBounds{}, fmt.Errorf("invalid glyph index %d", glyph)
 	}
+	if int(index) >= len(f.localSubrs) {
+		return nil, ps.PathBounds{}, fmt.Errorf("invalid font dict index %d", index)
+	}
+
+	if f.vstore != nil {
+		loader.vstore, loader.coords = f.vstore, coords
+		loader.vsindex = f.vsindexes[index]
+		loader.maxStack = f.maxStack
+	}
 
 	subrs := f.localSubrs[index]
 	err = psi.Run(f.charstrings[glyph], subrs, f.globalSubrs, &loader)
+	if f.vstore != nil { // CFF2 charstrings have no endchar operator
+		loader.cs.ClosePath()
+	}
+	if mat, ok := f.glyphTransform(index); ok {
+		loader.cs.Bounds = transformOutlines(mat, loader.cs.Segments, loader.cs.Bounds)
+	}
 	return loader.cs.Segments, loader.cs.Bounds, err
 }
 
@@ -40,10 +65,21 @@ type type2CharstringHandler struct {
 	// `width` must be initialized to default width
 	nominalWidthX int32
 	width         int32
+
+	// CFF2 only
+	vstore   *variationStore // nil for CFF fonts
+	coords   []float32       // normalized variation coordinates
+	vsindex  int32
+	maxStack int32     // 0 for CFF fonts
+	scalars  []float32 // lazily computed for `vsindex`
 }
 
 func (type2CharstringHandler) Context() ps.PsContext { return ps.Type2Charstring }
 
+// ArgStackSize implements ps.ArgStackSizer, using the
+// maxstack value of CFF2 fonts.
+func (met *type2CharstringHandler) ArgStackSize() int { return int(met.maxStack) }
+
 func (met *type2CharstringHandler) Apply(op ps.PsOperator, state *ps.Machine) error {
 	var err error
 	if !op.IsEscaped {
@@ -60,6 +96,22 @@ func (met *type2CharstringHandler) Apply(op ps.PsOperator, state *ps.Machine) er
 			return ps.LocalSubr(state) // do not clear the arg stack
 		case 29: // callgsubr
 			return ps.GlobalSubr(state) // do not clear the arg stack
+		case 15: // vsindex (CFF2)
+			if met.vstore == nil || state.ArgStack.Top < 1 {
+				return fmt.Errorf("invalid operator %s in charstring", op)
+			}
+			met.vsindex, met.scalars = state.ArgStack.Pop(), nil
+		case 16: // blend (CFF2)
+			if met.vstore == nil {
+				return fmt.Errorf("invalid operator %s in charstring", op)
+			}
+			if met.scalars == nil {
+				met.scalars, err = met.vstore.scalars(met.vsindex, met.coords)
+				if err != nil {
+					return err
+				}
+			}
+			return blend(&state.ArgStack, met.scalars) // do not clear the arg stack
 		case 21: // rmoveto
 			if state.ArgStack.Top > 2 { // width is optional
 				met.width = met.nominalWidthX + state.ArgStack.Vals[0]
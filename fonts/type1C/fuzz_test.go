@@ -0,0 +1,79 @@
+//go:build go1.18
+// +build go1.18
+
+package type1c
+
+import (
+	"bytes"
+	"testing"
+
+	ps "github.com/benoitkugler/textlayout/fonts/psinterpreter"
+)
+
+// Run with
+//	go test -fuzz FuzzCharstring ./fonts/type1C
+
+// maxFuzzSubrs bounds the number of subroutines
+const maxFuzzSubrs = 20
+
+func FuzzCharstring(f *testing.F) {
+	// subroutines are separated by a zero byte (which is a reserved operator)
+	f.Add([]byte{32, 10, 14}, bytes.Join(nestedSubrs(2, 4), []byte{0}))
+	f.Add([]byte{32, 10, 14}, bytes.Join(nestedSubrs(9, 8), []byte{0}))  // deeply nested
+	f.Add([]byte{32, 10, 14}, bytes.Join(nestedSubrs(12, 2), []byte{0})) // too deep
+	f.Add([]byte{32, 10, 14}, []byte{32, 10, 11})                        // recursive
+	f.Add([]byte{139, 139, 21, 150, 6, 150, 7, 14}, []byte{})            // simple path
+
+	f.Fuzz(func(t *testing.T, charstring []byte, subrsData []byte) {
+		subrs := bytes.Split(subrsData, []byte{0})
+		if len(subrs) > maxFuzzSubrs {
+			subrs = subrs[:maxFuzzSubrs]
+		}
+
+		var (
+			psi    ps.Machine
+			loader type2CharstringHandler
+		)
+		_ = psi.Run(charstring, subrs, subrs, &loader)
+	})
+}
+
+func FuzzParseCFF2(f *testing.F) {
+	f.Add([]byte{2, 0, 5, 0, 0, 0xff, 0xff, 0xff, 0xff, 1}) // huge INDEX count
+	f.Add(blendOverflowCFF2())                              // n*(k+1) overflow in 'blend'
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		_, _ = ParseCFF2(bytes.NewReader(data))
+	})
+}
+
+// blendOverflowCFF2 returns a minimal CFF2 table, with one region,
+// whose Private DICT uses a 'blend' with n = 2^30, so that n*(k+1)
+// overflows.
+func blendOverflowCFF2() []byte {
+	longint := func(v uint32) []byte { return []byte{29, byte(v >> 24), byte(v >> 16), byte(v >> 8), byte(v)} }
+
+	const (
+		topDictLength = 3*5 + 4
+		globalSubrs   = 5 + topDictLength
+		charstrings   = globalSubrs + 4
+		vstore        = charstrings + 8
+		fdArray       = vstore + 32
+		private       = fdArray + 18
+	)
+	out := []byte{2, 0, 5, 0, topDictLength}
+	out = append(append(out, longint(charstrings)...), 17)
+	out = append(append(out, longint(vstore)...), 24)
+	out = append(append(out, longint(fdArray)...), 12, 36)
+	out = append(out, 0, 0, 0, 0)                         // empty Global Subrs INDEX
+	out = append(out, 0, 0, 0, 1, 1, 1, 2, 14)            // one 'endchar' charstring
+	out = append(out, 0, 30, 0, 1, 0, 0, 0, 12, 0, 1)     // vstore: length, format, regionsOffset, count
+	out = append(out, 0, 0, 0, 22)                        // item variation data offset
+	out = append(out, 0, 1, 0, 1, 0, 0, 0x40, 0, 0x40, 0) // one axis, one region
+	out = append(out, 0, 0, 0, 0, 0, 1, 0, 0)             // one region index
+	out = append(out, 0, 0, 0, 1, 1, 1, 12)               // FDArray with one Font DICT
+	out = append(append(out, longint(8)...), longint(private)...)
+	out = append(out, 18)
+	out = append(append(out, longint(1<<30)...), 23, 139, 22) // Private DICT
+	return out
+}
@@ -0,0 +1,124 @@
+package type1c
+
+import (
+	"math"
+
+	"github.com/benoitkugler/textlayout/fonts"
+	ps "github.com/benoitkugler/textlayout/fonts/psinterpreter"
+)
+
+// defaultFontMatrix is used when the Top DICT has no FontMatrix entry,
+// and corresponds to 1000 units per em.
+var defaultFontMatrix = [6]float64{0.001, 0, 0, 0.001, 0, 0}
+
+// FontMatrix returns the matrix of the Top DICT (or the default one), mapping the
+// glyph space to the text space, as defined in the CFF specification.
+// Glyph outlines and metrics returned by this package are expressed in the
+// units defined by this matrix, even for CIDFonts whose Font DICTs provide their own matrix.
+func (f *Font) FontMatrix() [6]float64 { return f.fontMatrix }
+
+// Upem reads the FontMatrix to extract the scaling factor (the maximum between x and y coordinates)
+func (f *Font) Upem() uint16 {
+	scale := math.Max(math.Abs(f.fontMatrix[0]), math.Abs(f.fontMatrix[3]))
+	if scale == 0 {
+		return 1000
+	}
+	return uint16(math.Round(1 / scale))
+}
+
+// multiply returns the matrix applying `m1` then `m2`,
+// that is, m1 x m2 with PostScript conventions.
+func multiply(m1, m2 [6]float64) [6]float64 {
+	return [6]float64{
+		m1[0]*m2[0] + m1[1]*m2[2],
+		m1[0]*m2[1] + m1[1]*m2[3],
+		m1[2]*m2[0] + m1[3]*m2[2],
+		m1[2]*m2[1] + m1[3]*m2[3],
+		m1[4]*m2[0] + m1[5]*m2[2] + m2[4],
+		m1[4]*m2[1] + m1[5]*m2[3] + m2[5],
+	}
+}
+
+// invert returns the inverse of `m`, or false if it is not invertible
+func invert(m [6]float64) ([6]float64, bool) {
+	det := m[0]*m[3] - m[1]*m[2]
+	if det == 0 {
+		return [6]float64{}, false
+	}
+	a, b, c, d := m[3]/det, -m[1]/det, -m[2]/det, m[0]/det
+	return [6]float64{a, b, c, d, -(m[4]*a + m[5]*c), -(m[4]*b + m[5]*d)}, true
+}
+
+// fontDictMatrices returns the effective matrices of the Font DICTs
+// of a CIDFont, or nil if none of them defines a FontMatrix.
+// As in FreeType, a Font DICT matrix is composed with the Top DICT one, if any.
+func fontDictMatrices(top *[6]float64, fontDicts []topDictData) [][6]float64 {
+	var hasMatrix bool
+	for _, fd := range fontDicts {
+		if fd.fontMatrix != nil {
+			hasMatrix = true
+			break
+		}
+	}
+	if !hasMatrix {
+		return nil
+	}
+	out := make([][6]float64, len(fontDicts))
+	for i, fd := range fontDicts {
+		switch {
+		case fd.fontMatrix == nil && top == nil:
+			out[i] = defaultFontMatrix
+		case fd.fontMatrix == nil:
+			out[i] = *top
+		case top == nil:
+			out[i] = *fd.fontMatrix
+		default:
+			out[i] = multiply(*fd.fontMatrix, *top)
+		}
+	}
+	return out
+}
+
+// glyphTransform returns the transformation to apply to outlines
+// of glyphs using the Font DICT `index`, so that they are expressed
+// in the units of the FontMatrix. It returns false if no transformation is needed.
+func (f *Font) glyphTransform(index uint16) ([6]float64, bool) {
+	if int(index) >= len(f.fdMatrices) || f.fdMatrices[index] == f.fontMatrix {
+		return [6]float64{}, false
+	}
+	inv, ok := invert(f.fontMatrix)
+	if !ok {
+		return [6]float64{}, false
+	}
+	return multiply(f.fdMatrices[index], inv), true
+}
+
+func transformPoint(m [6]float64, x, y float64) (float64, float64) {
+	return m[0]*x + m[2]*y + m[4], m[1]*x + m[3]*y + m[5]
+}
+
+// transformOutlines applies `m` to the segments and the bounds.
+func transformOutlines(m [6]float64, segments []fonts.Segment, bounds ps.PathBounds) ps.PathBounds {
+	for i, seg := range segments {
+		for j := range seg.Args {
+			x, y := transformPoint(m, float64(seg.Args[j].X), float64(seg.Args[j].Y))
+			segments[i].Args[j] = fonts.SegmentPoint{X: float32(x), Y: float32(y)}
+		}
+	}
+
+	corners := [4][2]int32{
+		{bounds.Min.X, bounds.Min.Y}, {bounds.Min.X, bounds.Max.Y},
+		{bounds.Max.X, bounds.Min.Y}, {bounds.Max.X, bounds.Max.Y},
+	}
+	var out ps.PathBounds
+	for i, corner := range corners {
+		x, y := transformPoint(m, float64(corner[0]), float64(corner[1]))
+		pt := ps.Point{X: int32(math.Round(x)), Y: int32(math.Round(y))}
+		if i == 0 {
+			out.Min, out.Max = pt, pt
+		} else {
+			out.Enlarge(pt)
+		}
+	}
+	return out
+}
@@ -84,6 +84,8 @@ func (u userStrings) getString(sid uint16) (string, error) {
 type cffParser struct {
 	src    []byte // whole input
 	offset int    // current position
+
+	isCFF2 bool // INDEX counts are 32-bit in CFF2
 }
 
 func (p *cffParser) parse() ([]Font, error) {
@@ -126,6 +128,10 @@ func (p *cffParser) parse() ([]Font, error) {
 		if err != nil {
 			return nil, err
 		}
+		out[i].fontMatrix = defaultFontMatrix
+		if topDict.fontMatrix != nil {
+			out[i].fontMatrix = *topDict.fontMatrix
+		}
 	}
 
 	// Parse the Global Subrs [Subroutines] INDEX,
@@ -161,7 +167,7 @@ func (p *cffParser) parse() ([]Font, error) {
 		if !topDict.isCIDFont {
 			// Parse the Private DICT, whose location was found in the Top DICT.
 			var localSubrs [][]byte
-			localSubrs, err = p.parsePrivateDICT(topDict.privateDictOffset, topDict.privateDictLength)
+			_, localSubrs, err = p.parsePrivateDICT(topDict.privateDictOffset, topDict.privateDictLength, nil)
 			if err != nil {
 				return nil, err
 			}
@@ -189,12 +195,13 @@ func (p *cffParser) parse() ([]Font, error) {
 			}
 			multiSubrs := make([][][]byte, len(topDicts))
 			for i, topDict := range topDicts {
-				multiSubrs[i], err = p.parsePrivateDICT(topDict.privateDictOffset, topDict.privateDictLength)
+				_, multiSubrs[i], err = p.parsePrivateDICT(topDict.privateDictOffset, topDict.privateDictLength, nil)
 				if err != nil {
 					return nil, err
 				}
 			}
 			out[i].localSubrs = multiSubrs
+			out[i].fdMatrices = fontDictMatrices(topDict.fontMatrix, topDicts)
 		}
 	}
 
@@ -208,7 +215,7 @@ func (p *cffParser) parseTopDicts() ([]topDictData, error) {
 		return nil, err
 	}
 
-	out := make([]topDictData, len(instructions)) // guarded by uint16 max size
+	out := make([]topDictData, len(instructions)) // guarded by the index length check
 	var psi ps.Machine
 	for i, buf := range instructions {
 		topDict := &out[i]
@@ -408,7 +415,7 @@ func (p *cffParser) parseEncoding(encodingOffset int32, numGlyphs uint16, charse
 
 // fdSelect holds a CFF font's Font Dict Select data.
 type fdSelect interface {
-	fontDictIndex(glyph fonts.GID) (byte, error)
+	fontDictIndex(glyph fonts.GID) (uint16, error)
 	// return the maximum index + 1 (it's the length of an array
 	// which can be safely indexed by the indexes)
 	extent() int
@@ -416,11 +423,11 @@ type fdSelect interface {
 
 type fdSelect0 []byte
 
-func (fds fdSelect0) fontDictIndex(glyph fonts.GID) (byte, error) {
+func (fds fdSelect0) fontDictIndex(glyph fonts.GID) (uint16, error) {
 	if int(glyph) >= len(fds) {
 		return 0, errors.New("invalid glyph index")
 	}
-	return fds[glyph], nil
+	return uint16(fds[glyph]), nil
 }
 
 func (fds fdSelect0) extent() int {
@@ -435,15 +442,16 @@ func (fds fdSelect0) extent() int {
 
 type range3 struct {
 	first fonts.GID
-	fd    byte
+	fd    uint16 // byte for format 3
 }
 
+// fdSelect3 is used for formats 3 and 4 (CFF2 only)
 type fdSelect3 struct {
 	ranges   []range3
 	sentinel fonts.GID // = numGlyphs
 }
 
-func (fds fdSelect3) fontDictIndex(x fonts.GID) (byte, error) {
+func (fds fdSelect3) fontDictIndex(x fonts.GID) (uint16, error) {
 	lo, hi := 0, len(fds.ranges)
 	for lo < hi {
 		i := (lo + hi) / 2
@@ -477,7 +485,7 @@ func (fds fdSelect3) extent() int {
 }
 
 // parseFDSelect parses the Font Dict Select data as per 5176.CFF.pdf section
-// 19 "FDSelect".
+// 19 "FDSelect", and the CFF2 specification for format 4.
 func (p *cffParser) parseFDSelect(offset int32, numGlyphs uint16) (fdSelect, error) {
 	if err := p.seek(offset); err != nil {
 		return nil, err
@@ -508,7 +516,28 @@ func (p *cffParser) parseFDSelect(offset int32, numGlyphs uint16) (fdSelect, err
 		for i := range out.ranges {
 			// 	buf holds the range [xlo, xhi).
 			out.ranges[i].first = fonts.GID(be.Uint16(p.src[p.offset+3*i:]))
-			out.ranges[i].fd = p.src[p.offset+3*i+2]
+			out.ranges[i].fd = uint16(p.src[p.offset+3*i+2])
+		}
+		return out, nil
+	case 4: // CFF2 only
+		if !p.isCFF2 {
+			break
+		}
+		buf, err = p.read(4)
+		if err != nil {
+			return nil, err
+		}
+		numRanges := be.Uint32(buf)
+		if uint64(len(p.src)-p.offset) < 6*uint64(numRanges)+4 {
+			return nil, errors.New("invalid FDSelect data")
+		}
+		out := fdSelect3{
+			sentinel: fonts.GID(numGlyphs),
+			ranges:   make([]range3, numRanges),
+		}
+		for i := range out.ranges {
+			out.ranges[i].first = fonts.GID(be.Uint32(p.src[p.offset+6*i:]))
+			out.ranges[i].fd = be.Uint16(p.src[p.offset+6*i+4:])
 		}
 		return out, nil
 	}
@@ -516,38 +545,37 @@ func (p *cffParser) parseFDSelect(offset int32, numGlyphs uint16) (fdSelect, err
 }
 
 // Parse Private DICT and the Local Subrs [Subroutines] INDEX
-func (p *cffParser) parsePrivateDICT(offset, length int32) ([][]byte, error) {
+// `vstore` is only used for CFF2 fonts, and may be nil.
+func (p *cffParser) parsePrivateDICT(offset, length int32, vstore *variationStore) (privateDict, [][]byte, error) {
+	priv := privateDict{vstore: vstore}
 	if length == 0 {
-		return nil, nil
+		return priv, nil, nil
 	}
 	if err := p.seek(offset); err != nil {
-		return nil, err
+		return priv, nil, err
 	}
 	buf, err := p.read(int(length))
 	if err != nil {
-		return nil, err
+		return priv, nil, err
 	}
-	var (
-		psi  ps.Machine
-		priv privateDict
-	)
+	var psi ps.Machine
 	if err = psi.Run(buf, nil, nil, &priv); err != nil {
-		return nil, err
+		return priv, nil, err
 	}
 
 	if priv.subrsOffset == 0 {
-		return nil, nil
+		return priv, nil, nil
 	}
 
 	// "The local subrs offset is relative to the beginning of the Private DICT data"
 	if err = p.seek(offset + priv.subrsOffset); err != nil {
-		return nil, errors.New("invalid local subroutines offset")
+		return priv, nil, errors.New("invalid local subroutines offset")
 	}
 	subrs, err := p.parseIndex()
 	if err != nil {
-		return nil, err
+		return priv, nil, err
 	}
-	return subrs, nil
+	return priv, subrs, nil
 }
 
 // read returns the n bytes from p.offset and advances p.offset by n.
@@ -591,19 +619,27 @@ func bigEndian(b []byte) uint32 {
 	panic("unreachable")
 }
 
-func (p *cffParser) parseIndexHeader() (count uint16, offSize int32, err error) {
-	buf, err := p.read(2)
-	if err != nil {
-		return 0, 0, err
+func (p *cffParser) parseIndexHeader() (count uint32, offSize int32, err error) {
+	if p.isCFF2 {
+		buf, err := p.read(4)
+		if err != nil {
+			return 0, 0, err
+		}
+		count = be.Uint32(buf)
+	} else {
+		buf, err := p.read(2)
+		if err != nil {
+			return 0, 0, err
+		}
+		count = uint32(be.Uint16(buf))
 	}
-	count = be.Uint16(buf)
 	// 5176.CFF.pdf section 5 "INDEX Data" says that "An empty INDEX is
 	// represented by a count field with a 0 value and no additional fields.
 	// Thus, the total size of an empty INDEX is 2 bytes".
 	if count == 0 {
 		return count, 0, nil
 	}
-	buf, err = p.read(1)
+	buf, err := p.read(1)
 	if err != nil {
 		return 0, 0, err
 	}
@@ -611,6 +647,11 @@ func (p *cffParser) parseIndexHeader() (count uint16, offSize int32, err error)
 	if offSize < 1 || 4 < offSize {
 		return 0, 0, fmt.Errorf("invalid offset size %d", offSize)
 	}
+	// compute in 64 bits so that a (CFF2) count of 0xFFFFFFFF does not wrap;
+	// this also ensures that count is bounded by the length of the input
+	if (uint64(count)+1)*uint64(offSize) > uint64(len(p.src)-p.offset) {
+		return 0, 0, errors.New("invalid CFF index (EOF)")
+	}
 	return count, offSize, nil
 }
 
@@ -673,6 +714,9 @@ type topDictData struct {
 	cidFontName                                        uint16
 	privateDictOffset                                  int32
 	privateDictLength                                  int32
+	vstoreOffset                                       int32       // CFF2 only
+	maxStack                                           int32       // CFF2 only, 0 if not set
+	fontMatrix                                         *[6]float64 // nil if not set
 }
 
 // resolve the strings
@@ -786,6 +830,14 @@ var topDictOperators = [2][]topDictOperator{
 			t.privateDictOffset = s.ArgStack.Vals[s.ArgStack.Top-1]
 			return nil
 		}, +2 /*Private*/},
+		24: {func(t *topDictData, s *ps.Machine) error {
+			t.vstoreOffset = s.ArgStack.Vals[s.ArgStack.Top-1]
+			return nil
+		}, +1 /*vstore (CFF2)*/},
+		25: {func(t *topDictData, s *ps.Machine) error {
+			t.maxStack = s.ArgStack.Vals[s.ArgStack.Top-1]
+			return nil
+		}, +1 /*maxstack (CFF2)*/},
 	},
 	// 2-byte operators. The first byte is the escape byte.
 	{
@@ -813,7 +865,17 @@ var topDictOperators = [2][]topDictOperator{
 			}
 			return nil
 		}, +1 /*CharstringType*/},
-		7:  {topDictNoOp, -1 /*FontMatrix*/},
+		7: {func(t *topDictData, s *ps.Machine) error {
+			if s.ArgStack.Top != 6 { // ignore invalid matrices, as FreeType does
+				return nil
+			}
+			var mat [6]float64
+			for i := range mat {
+				mat[i] = float64(s.ArgStack.Number(int32(i)))
+			}
+			t.fontMatrix = &mat
+			return nil
+		}, -1 /*FontMatrix*/},
 		8:  {topDictNoOp, +1 /*StrokeWidth*/},
 		20: {topDictNoOp, +1 /*SyntheticBase*/},
 		21: {topDictNoOp, +1 /*PostScript*/},
@@ -847,10 +909,23 @@ var topDictOperators = [2][]topDictOperator{
 type privateDict struct {
 	subrsOffset                  int32
 	defaultWidthX, nominalWidthX int32
+
+	// CFF2 only
+	vstore  *variationStore // used to resolve blend operators
+	vsindex int32           // default item variation data for the charstrings
 }
 
 func (privateDict) Context() ps.PsContext { return ps.PrivateDict }
 
+// ArgStackSize implements ps.ArgStackSizer: CFF2 DICTs
+// accept more operands, for the blend operator.
+func (priv privateDict) ArgStackSize() int {
+	if priv.vstore != nil {
+		return ps.MaxCFF2ArgStackSize
+	}
+	return 0
+}
+
 // The Private DICT operators are defined by 5176.CFF.pdf Table 23 "Private
 // DICT Operators".
 func (priv *privateDict) Apply(op ps.PsOperator, state *ps.Machine) error {
@@ -878,6 +953,16 @@ func (priv *privateDict) Apply(op ps.PsOperator, state *ps.Machine) error {
 			}
 			priv.subrsOffset = state.ArgStack.Vals[state.ArgStack.Top-1]
 			return state.ArgStack.PopN(1)
+		case 22: // "vsindex" (CFF2)
+			if state.ArgStack.Top < 1 {
+				return errors.New("invalid stack size for 'vsindex' in private Dict charstring")
+			}
+			priv.vsindex = state.ArgStack.Vals[state.ArgStack.Top-1]
+			return state.ArgStack.PopN(1)
+		case 23: // "blend" (CFF2)
+			// the hinting values are not used, so that the default values are kept,
+			// without applying the variations
+			return priv.vstore.skipBlendDeltas(&state.ArgStack, priv.vsindex)
 		}
 	} else { // 2-byte operators. The first byte is the escape byte.
 		switch op.Operator {
@@ -3,12 +3,15 @@ package type1c
 import (
 	"bytes"
 	"fmt"
+	"math"
 	"math/rand"
 	"path/filepath"
 	"testing"
 
 	testdata "github.com/benoitkugler/textlayout-testdata/type1C"
 	"github.com/benoitkugler/textlayout/fonts"
+	ps "github.com/benoitkugler/textlayout/fonts/psinterpreter"
+	"github.com/benoitkugler/textlayout/fonts/simpleencodings"
 )
 
 func TestParseCFF(t *testing.T) {
@@ -111,3 +114,341 @@ func TestCIDFont(t *testing.T) {
 	}
 	fmt.Println(len(font.localSubrs))
 }
+
+func TestGlyphName(t *testing.T) {
+	b, err := testdata.Files.ReadFile("AAAPKB+SourceSansPro-Bold.cff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	font, err := Parse(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name := font.GlyphName(0); name != ".notdef" {
+		t.Fatalf("expected .notdef, got %s", name)
+	}
+	for gid := 0; gid < font.NumGlyphs(); gid++ {
+		name := font.GlyphName(fonts.GID(gid))
+		if name == "" {
+			t.Fatalf("missing name for glyph %d", gid)
+		}
+		if got, ok := font.GlyphByName(name); !ok || got != fonts.GID(gid) {
+			t.Fatalf("expected glyph %d for %s, got %d", gid, name, got)
+		}
+	}
+	if _, ok := font.GlyphByName("nonexistent"); ok {
+		t.Fatal("unexpected glyph for invalid name")
+	}
+
+	b, err = testdata.Files.ReadFile("AdobeMingStd-Light-Identity-H.cff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	font, err = Parse(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := font.GlyphByName(".notdef"); ok {
+		t.Fatal("unexpected glyph name in CIDFont")
+	}
+}
+
+func TestGlyphNamePredefinedCharset(t *testing.T) {
+	// predefined charsets are longer than the number of glyphs
+	font := Font{charset: charsetISOAdobe[:], charstrings: make([][]byte, 3)}
+	if name := font.GlyphName(2); name != "exclam" {
+		t.Fatalf("expected exclam, got %s", name)
+	}
+	if name := font.GlyphName(5); name != "" {
+		t.Fatalf("unexpected name %s for an out of range glyph", name)
+	}
+	if gid, ok := font.GlyphByName("exclam"); !ok || gid != 2 {
+		t.Fatalf("expected glyph 2, got %d", gid)
+	}
+	if gid, ok := font.GlyphByName("percent"); ok {
+		t.Fatalf("unexpected out of range glyph %d", gid)
+	}
+}
+
+func TestRuneToGID(t *testing.T) {
+	for _, test := range []struct {
+		file       string
+		isStandard bool
+	}{
+		{"AAAPKB+SourceSansPro-Bold.cff", true},
+		{"YPTQCA+CMR17.cff", false},
+	} {
+		b, err := testdata.Files.ReadFile(test.file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		font, err := Parse(bytes.NewReader(b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if isStandard := font.Encoding == &simpleencodings.AdobeStandard; isStandard != test.isStandard {
+			t.Fatalf("%s: unexpected encoding", test.file)
+		}
+		gid, ok := font.RuneToGID('A')
+		if !ok {
+			t.Fatalf("%s: missing glyph for 'A'", test.file)
+		}
+		if name := font.GlyphName(gid); name != "A" {
+			t.Fatalf("%s: expected glyph A, got %s", test.file, name)
+		}
+		if _, ok := font.RuneToGID('中'); ok {
+			t.Fatalf("%s: unexpected glyph for multi-byte code", test.file)
+		}
+	}
+}
+
+func TestGIDForCID(t *testing.T) {
+	b, err := testdata.Files.ReadFile("AdobeMingStd-Light-Identity-H.cff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	font, err := Parse(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		cid int
+		gid fonts.GID
+	}{
+		{0, 0},
+		{19, 19},
+		{19178, 18681},
+	} {
+		if gid, ok := font.GIDForCID(test.cid); !ok || gid != test.gid {
+			t.Fatalf("CID %d: expected GID %d, got %d (%v)", test.cid, test.gid, gid, ok)
+		}
+	}
+	if _, ok := font.GIDForCID(60000); ok {
+		t.Fatal("unexpected glyph for invalid CID")
+	}
+
+	b, err = testdata.Files.ReadFile("YPTQCA+CMR17.cff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	font, err = Parse(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := font.GIDForCID(0); ok {
+		t.Fatal("unexpected CID for a font which is not a CIDFont")
+	}
+}
+
+func TestFontMatrix(t *testing.T) {
+	for _, test := range []struct {
+		file string
+		upem uint16
+	}{
+		{"YPTQCA+CMR17.cff", 1000},
+		{"ttf/Chilanka-Regular.cff", 2048},
+	} {
+		b, err := testdata.Files.ReadFile(test.file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		font, err := Parse(bytes.NewReader(b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		scale := 1 / float64(test.upem)
+		if exp := [6]float64{scale, 0, 0, scale, 0, 0}; font.FontMatrix() != exp {
+			t.Fatalf("%s: expected FontMatrix %v, got %v", test.file, exp, font.FontMatrix())
+		}
+		if font.Upem() != test.upem {
+			t.Fatalf("%s: expected upem %d, got %d", test.file, test.upem, font.Upem())
+		}
+	}
+}
+
+func TestInvalidFontMatrix(t *testing.T) {
+	// 5 operands only : the matrix is ignored
+	var (
+		psi     ps.Machine
+		topDict topDictData
+	)
+	if err := psi.Run([]byte{139, 139, 139, 139, 139, 12, 7}, nil, nil, &topDict); err != nil {
+		t.Fatal(err)
+	}
+	if topDict.fontMatrix != nil {
+		t.Fatalf("unexpected FontMatrix %v", *topDict.fontMatrix)
+	}
+}
+
+func TestFontMatrixOperands(t *testing.T) {
+	// reals and integers, some of them out of the float32 integer range
+	var (
+		psi     ps.Machine
+		topDict topDictData
+	)
+	instructions := []byte{
+		30, 0x0a, 0x00, 0x1f, // 0.001
+		139,                        // 0
+		29, 0xff, 0x67, 0x69, 0x80, // -10000000
+		30, 0x0a, 0x00, 0x1f, // 0.001
+		29, 0x00, 0x98, 0x96, 0x80, // 10000000
+		30, 0xe1, 0xa5, 0xff, // -1.5
+		12, 7,
+	}
+	if err := psi.Run(instructions, nil, nil, &topDict); err != nil {
+		t.Fatal(err)
+	}
+	scale := float64(float32(0.001))
+	exp := [6]float64{scale, 0, -10000000, scale, 10000000, -1.5}
+	if topDict.fontMatrix == nil || *topDict.fontMatrix != exp {
+		t.Fatalf("expected FontMatrix %v, got %v", exp, topDict.fontMatrix)
+	}
+}
+
+func TestFontDictMatrix(t *testing.T) {
+	b, err := testdata.Files.ReadFile("AdobeMingStd-Light-Identity-H.cff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	font, err := Parse(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	const glyph = 10
+	segments, bounds, err := font.LoadGlyph(glyph)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// scale the glyphs of every Font DICT by 2, with an offset of 10 units
+	fdMatrix := [6]float64{0.002, 0, 0, 0.002, 0.01, 0}
+	fontDicts := make([]topDictData, len(font.localSubrs))
+	for i := range fontDicts {
+		fontDicts[i].fontMatrix = &fdMatrix
+	}
+	font.fdMatrices = fontDictMatrices(nil, fontDicts)
+	scaledSegments, scaledBounds, err := font.LoadGlyph(glyph)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(segments) != len(scaledSegments) {
+		t.Fatalf("unexpected number of segments %d", len(scaledSegments))
+	}
+	for i, seg := range segments {
+		for j, pt := range seg.Args {
+			got := scaledSegments[i].Args[j]
+			if math.Abs(float64(got.X-(2*pt.X+10))) > 1e-3 || math.Abs(float64(got.Y-2*pt.Y)) > 1e-3 {
+				t.Fatalf("expected %v, got %v", fonts.SegmentPoint{X: 2*pt.X + 10, Y: 2 * pt.Y}, got)
+			}
+		}
+	}
+	if exp := (ps.PathBounds{
+		Min: ps.Point{X: 2*bounds.Min.X + 10, Y: 2 * bounds.Min.Y},
+		Max: ps.Point{X: 2*bounds.Max.X + 10, Y: 2 * bounds.Max.Y},
+	}); scaledBounds != exp {
+		t.Fatalf("expected %v, got %v", exp, scaledBounds)
+	}
+
+	// the Font DICT matrix is composed with the Top DICT one
+	top := [6]float64{1000, 0, 0, 1000, 0, 0}
+	if mats := fontDictMatrices(&top, fontDicts); mats[0] != [6]float64{2, 0, 0, 2, 10, 0} {
+		t.Fatalf("unexpected composed matrix %v", mats[0])
+	}
+}
+
+// nestedSubrs returns subroutines where each subroutine calls
+// the next one `fanout` times, resulting in an exponential
+// number of operations, while staying under the call stack limit.
+func nestedSubrs(depth, fanout int) [][]byte {
+	subrs := make([][]byte, depth+1)
+	for i := 0; i < depth; i++ {
+		// 33+i encodes the biased index i+1
+		subrs[i] = append(bytes.Repeat([]byte{byte(33 + i), 10}, fanout), 11)
+	}
+	subrs[depth] = []byte{11} // return
+	return subrs
+}
+
+func TestCharstringOperatorsLimit(t *testing.T) {
+	charstring := []byte{32, 10, 14} // callsubr 0, endchar
+
+	var (
+		psi    ps.Machine
+		loader type2CharstringHandler
+	)
+	err := psi.Run(charstring, nestedSubrs(2, 4), nil, &loader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = psi.Run(charstring, nestedSubrs(9, 8), nil, &loader)
+	if err == nil {
+		t.Fatal("expected error for too many operators")
+	}
+}
+
+func TestCharstringArgStackLimit(t *testing.T) {
+	// 60 operands then rlineto
+	charstring := append(bytes.Repeat([]byte{139}, 60), 5)
+
+	var (
+		psi    ps.Machine
+		loader type2CharstringHandler
+	)
+	if err := psi.Run(charstring, nil, nil, &loader); err == nil {
+		t.Fatal("expected error for too many operands in CFF charstring")
+	}
+
+	// CFF2 charstrings use the maxstack limit
+	loader = type2CharstringHandler{maxStack: 193}
+	if err := psi.Run(charstring, nil, nil, &loader); err != nil {
+		t.Fatal(err)
+	}
+	loader = type2CharstringHandler{maxStack: 50}
+	if err := psi.Run(charstring, nil, nil, &loader); err == nil {
+		t.Fatal("expected error for too many operands in CFF2 charstring")
+	}
+}
+
+func TestFDSelect4(t *testing.T) {
+	// 2 ranges: [0, 2) -> 1, [2, 5) -> 300
+	src := []byte{4, 0, 0, 0, 2, 0, 0, 0, 0, 0, 1, 0, 0, 0, 2, 1, 44, 0, 0, 0, 5}
+	p := cffParser{src: src, isCFF2: true}
+	fds, err := p.parseFDSelect(0, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for gid, exp := range [5]uint16{1, 1, 300, 300, 300} {
+		got, err := fds.fontDictIndex(fonts.GID(gid))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != exp {
+			t.Fatalf("expected %d, got %d", exp, got)
+		}
+	}
+	if fds.extent() != 301 {
+		t.Fatalf("unexpected extent %d", fds.extent())
+	}
+
+	// format 4 is not valid in CFF
+	p = cffParser{src: src}
+	if _, err = p.parseFDSelect(0, 5); err == nil {
+		t.Fatal("expected error for FDSelect format 4 in CFF")
+	}
+}
+
+func TestVariationRegionInvalid(t *testing.T) {
+	for _, reg := range []variationRegion{
+		{0.5, 0.2, 1},  // start > peak
+		{0, 0.8, 0.5},  // peak > end
+		{-0.5, 0.5, 1}, // crossing zero
+	} {
+		if f := reg.evaluate(0.6); f != 1 {
+			t.Fatalf("expected neutral factor for %v, got %f", reg, f)
+		}
+	}
+	if f := (variationRegion{0, 0.5, 1}).evaluate(0.25); f != 0.5 {
+		t.Fatalf("unexpected factor %f", f)
+	}
+}
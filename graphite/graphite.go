@@ -146,10 +146,28 @@ func (g glyph) getMetric(metric uint8) int32 {
 // FontOptions allows to specify a scale to get position
 // in user units rather than in font units.
 type FontOptions struct {
+	// Tracer, if not nil, is called after each pass run by Shape,
+	// and may be used to debug the rules of a font.
+	// It is not called for skipped passes.
+	Tracer func(PassTrace)
+
 	scale float32 // scales from design units to ppm
 	// isHinted bool
 }
 
+// PassTrace describes the execution of one pass of the
+// Silf table, and is emitted to FontOptions.Tracer.
+type PassTrace struct {
+	// Before and After are the glyphs of the segment,
+	// in the order of the slots, before and after the pass.
+	Before, After []GID
+	// Rules are the indexes of the rules fired during the pass,
+	// in the order of their application.
+	Rules []uint16
+	// Index is the index of the pass in the Silf subtable.
+	Index uint8
+}
+
 // NewFontOptions builds options from the given points per em.
 func NewFontOptions(ppem uint16, face *GraphiteFace) *FontOptions {
 	return &FontOptions{scale: float32(ppem) / float32(face.Upem())}
@@ -263,6 +281,14 @@ func (f *GraphiteFace) getGlyph(gid GID) *glyph {
 	return nil
 }
 
+// GlyphAttribute returns the value of the attribute `attr` of the glyph `gid`,
+// as defined in the Glat table, or 0 if the glyph or the attribute is not found.
+// The meaning of the attributes is specific to each font, and is usually
+// defined by the GDL compiler.
+func (f *GraphiteFace) GlyphAttribute(gid GID, attr uint16) int16 {
+	return f.getGlyphAttr(gid, attr)
+}
+
 func (f *GraphiteFace) getGlyphAttr(gid GID, attr uint16) int16 {
 	if glyph := f.getGlyph(gid); glyph != nil {
 		return glyph.attrs.get(attr)
@@ -343,6 +369,10 @@ func (face *GraphiteFace) Shape(font *FontOptions, text []rune, script Tag, feat
 	}
 	seg.feats = features
 
+	if font != nil {
+		seg.tracer = font.Tracer
+	}
+
 	seg.processRunes(text)
 
 	face.runGraphite(&seg, seg.silf)
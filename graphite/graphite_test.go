@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"math/rand"
+	"reflect"
 	"testing"
 
 	testdata "github.com/benoitkugler/textlayout-testdata/graphite"
@@ -57,3 +58,36 @@ func TestCrash(t *testing.T) {
 		parseTableGlat(input, []uint32{1, 45, 78, 896, 4566})
 	}
 }
+
+func TestTracer(t *testing.T) {
+	face := loadGraphite(t, "Padauk.ttf")
+	text := []rune{0x1000, 0x103C, 0x102D, 0x102F}
+
+	var traces []PassTrace
+	font := NewFontOptions(12, face)
+	font.Tracer = func(pass PassTrace) { traces = append(traces, pass) }
+	seg := face.Shape(font, text, 0, nil, 0)
+
+	if len(traces) == 0 {
+		t.Fatal("expected at least one pass trace")
+	}
+	fired := false
+	for i, pass := range traces {
+		if i > 0 && !reflect.DeepEqual(pass.Before, traces[i-1].After) {
+			t.Fatalf("pass %d: inconsistent input %v, expected %v", pass.Index, pass.Before, traces[i-1].After)
+		}
+		fired = fired || len(pass.Rules) != 0
+	}
+	if !fired {
+		t.Fatal("expected at least one rule fired")
+	}
+	if len(traces[0].Before) != len(text) {
+		t.Fatalf("expected one glyph per rune before the first pass, got %v", traces[0].Before)
+	}
+
+	// the output is not affected by the tracer
+	exp := face.Shape(NewFontOptions(12, face), text, 0, nil, 0)
+	if got, want := seg.glyphs(), exp.glyphs(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
@@ -215,6 +215,9 @@ func (pa *pass) findAndDoRule(slot *Slot, m *machine, fsm *finiteStateMachine) (
 			if debugMode >= 2 {
 				tr.dumpRuleOutput(fsm, r, slot)
 			}
+			if seg := fsm.slots.segment; seg.tracer != nil {
+				seg.trace.Rules = append(seg.trace.Rules, r)
+			}
 
 			if err != nil {
 				return slot, fmt.Errorf("applying rule: %s", err)
@@ -912,10 +915,17 @@ func (s *passes) runGraphite(seg *Segment, firstPass, lastPass uint8, doBidi boo
 		var err error
 		if i >= 32 || (seg.passBits&(1<<i)) == 0 || s.passes[i].collisionLoops != 0 {
 			var ok bool
+			if seg.tracer != nil {
+				seg.trace = PassTrace{Index: i, Before: seg.glyphs()}
+			}
 			ok, err = s.passes[i].runGraphite(m, fsm, reverse)
 			if !ok {
 				return false
 			}
+			if seg.tracer != nil {
+				seg.trace.After = seg.glyphs()
+				seg.tracer(seg.trace)
+			}
 		}
 		// only subsitution passes can change segment length, cached subsegments are short for their text
 		if err != nil || (len(seg.charinfo) != 0 && len(seg.charinfo) > maxSize) {
@@ -43,6 +43,9 @@ type Segment struct {
 	// for performance reasons.
 	NumGlyphs int
 
+	tracer func(PassTrace) // optional
+	trace  PassTrace       // current pass, only used with tracer
+
 	passBits uint32 // if bit set then skip pass
 	flags    uint8  // General purpose flags
 	dir      int8   // text direction
@@ -269,6 +272,15 @@ func (seg *Segment) getSlotBidiClass(s *Slot) int8 {
 	return res
 }
 
+// glyphs returns the glyphs of the slots, used for tracing
+func (seg *Segment) glyphs() []GID {
+	out := make([]GID, 0, seg.NumGlyphs)
+	for s := seg.First; s != nil; s = s.Next {
+		out = append(out, s.glyphID)
+	}
+	return out
+}
+
 // check the bounds and return nil if needed
 func (seg *Segment) getCharInfo(index int) *charInfo {
 	if index < len(seg.charinfo) {
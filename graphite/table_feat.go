@@ -118,8 +118,15 @@ func parseTableFeat(data []byte) (tableFeat, error) {
 		out[i].label = truetype.NameID(label_)
 
 		// convert from offset to index
-		index := (int(offset) - headerSize - len(featSlice)) / 4
+		settingsStart := int(offset) - headerSize - len(featSlice)
+		if numSettings != 0 && (settingsStart < 0 || settingsStart%4 != 0) {
+			return nil, fmt.Errorf("invalid Feat table: invalid settings offset %d for feature %d", offset, i)
+		}
+		index := settingsStart / 4
 		end := index + int(numSettings)
+		if available := (len(data) - headerSize - len(featSlice)) / 4; end > available {
+			return nil, fmt.Errorf("invalid Feat table: settings for feature %d out of bounds (%d > %d)", i, end, available)
+		}
 		if numSettings != 0 && end > maxSettingsLength {
 			maxSettingsLength = end
 		}
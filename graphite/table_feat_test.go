@@ -237,6 +237,28 @@ func TestParseTableFeat(t *testing.T) {
 	if _, err := parseTableFeat(badInput); err == nil {
 		t.Fatalf("expected error on bad input")
 	}
+
+	// offset pointing inside the feature definitions
+	badStart := testDataB
+	badStart.defs[1].settingsOffset = featHeaderSize
+	if _, err := parseTableFeat(asBinary(badStart)); err == nil {
+		t.Fatalf("expected error on offset before settings")
+	}
+
+	// overflowing offset
+	badEnd := testDataB
+	badEnd.defs[0].settingsOffset = 0xFFFFFFF0
+	if _, err := parseTableFeat(asBinary(badEnd)); err == nil {
+		t.Fatalf("expected error on overflowing offset")
+	}
+
+	// truncated inputs
+	input := asBinary(testDataE)
+	for i := range input {
+		if _, err := parseTableFeat(input[:i]); err == nil {
+			t.Fatalf("expected error on truncated input (length %d)", i)
+		}
+	}
 }
 
 func dumpFeatures(ft *GraphiteFace) []byte {
@@ -217,3 +217,36 @@ func TestGlat(t *testing.T) {
 		}
 	}
 }
+
+func TestGlyphAttribute(t *testing.T) {
+	for _, filename := range []string{
+		"Annapurnarc2.ttf",   // Glat version 1
+		"Awami_test.ttf",     // Glat version 3, with octaboxes
+		"Scheherazadegr.ttf", // Glat version 1
+	} {
+		face := loadGraphite(t, filename)
+		expected := readExpectedGlat(filename)
+		for gid, m := range expected {
+			for k, v := range m.attributes {
+				if got := face.GlyphAttribute(GID(gid), k); got != v {
+					t.Fatalf("%s: glyph %d, attribute %d: expected %d, got %d", filename, gid, k, v, got)
+				}
+			}
+		}
+		if got := face.GlyphAttribute(GID(len(expected)+10), 0); got != 0 {
+			t.Fatalf("%s: expected 0 for an invalid glyph, got %d", filename, got)
+		}
+	}
+
+	// Glat version 2 uses two bytes for the key and the count
+	data := []byte{0, 3, 0, 2, 0, 10, 0xff, 0xfe, 1, 0, 0, 1, 0, 7}
+	attrs, err := parseOneGlyphAttr(data, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key, exp := range map[uint16]int16{3: 10, 4: -2, 5: 0, 256: 7, 257: 0} {
+		if got := attrs.attributes.get(key); got != exp {
+			t.Fatalf("attribute %d: expected %d, got %d", key, exp, got)
+		}
+	}
+}
@@ -106,8 +106,12 @@ func parseTableSill(data []byte) (tableSill, error) {
 		return nil, fmt.Errorf("invalid Sill table: %s", err)
 	}
 
+	const settingSize = 8
 	out := make(tableSill, numLangs)
 	for i, entry := range entries {
+		if end := int(entry.Offset) + int(entry.NumSettings)*settingSize; end > len(data) {
+			return nil, fmt.Errorf("invalid Sill table: settings for language %d out of bounds (%d > %d)", i, end, len(data))
+		}
 		out[i].langcode = Tag(binary.BigEndian.Uint32(entry.Langcode[:]))
 		out[i].settings = make([]languageSetting, entry.NumSettings)
 		r.SetPos(int(entry.Offset))
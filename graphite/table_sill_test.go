@@ -0,0 +1,76 @@
+package graphite
+
+import "testing"
+
+type sillHeader struct {
+	major, minor uint16
+	numLangs     uint16
+	_            [3]uint16
+}
+
+type sillEntry struct {
+	langcode    Tag
+	numSettings uint16
+	offset      uint16
+}
+
+type sillSetting struct {
+	featureID Tag
+	value     int16
+	_         uint16
+}
+
+const (
+	sillHeaderSize = 12
+	sillEntrySize  = 8
+)
+
+type sillTableTest struct {
+	header   sillHeader
+	entries  [2]sillEntry
+	settings [3]sillSetting
+}
+
+var testSill = sillTableTest{
+	sillHeader{1, 0, 2, [3]uint16{}},
+	[2]sillEntry{
+		{0x656e0000, 1, sillHeaderSize + 2*sillEntrySize},
+		{0x66720000, 2, sillHeaderSize + 2*sillEntrySize + 8},
+	},
+	[3]sillSetting{{0x41424344, 1, 0}, {0x41424344, 2, 0}, {0x41424345, 3, 0}},
+}
+
+func TestParseTableSill(t *testing.T) {
+	input := asBinary(testSill)
+	sill, err := parseTableSill(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sill) != 2 || len(sill[0].settings) != 1 || len(sill[1].settings) != 2 {
+		t.Fatalf("unexpected Sill table %v", sill)
+	}
+	if set := sill[1].settings[1]; set.FeatureId != 0x41424345 || set.Value != 3 {
+		t.Fatalf("unexpected setting %v", set)
+	}
+
+	// truncated inputs
+	for i := range input {
+		if _, err := parseTableSill(input[:i]); err == nil {
+			t.Fatalf("expected error on truncated input (length %d)", i)
+		}
+	}
+
+	// overflowing offset
+	bad := testSill
+	bad.entries[1].offset = 0xFFF0
+	if _, err := parseTableSill(asBinary(bad)); err == nil {
+		t.Fatalf("expected error on overflowing offset")
+	}
+
+	// overflowing count
+	bad = testSill
+	bad.entries[0].numSettings = 4
+	if _, err := parseTableSill(asBinary(bad)); err == nil {
+		t.Fatalf("expected error on overflowing settings count")
+	}
+}
@@ -1,6 +1,8 @@
 package harfbuzz
 
 import (
+	"fmt"
+	"io"
 	"math"
 	"sort"
 
@@ -85,12 +87,30 @@ type Buffer struct {
 	// Glyph that replaces characters not found in the font during shaping.
 	// The not-found glyph defaults to zero, sometimes knows as the
 	// ".notdef" glyph.
+	// See also `NotdefHandling`.
 	NotFound fonts.GID
 
+	// NotdefHandling controls how the characters not found in the font
+	// are handled.
+	NotdefHandling NotdefHandling
+	// NotdefReplacement is used with `NotdefReplace`, for instance
+	// []rune("?") or []rune{0xFFFD}. Replacement runes which are not in the font
+	// use the `NotFound` glyph, and an empty replacement removes the glyphs.
+	NotdefReplacement []rune
+
 	// Information about how the text in the buffer should be treated.
 	Flags ShappingOptions
 	// Precise the cluster handling behavior.
 	ClusterLevel ClusterLevel
+	// Normalization, if not zero, overrides the normalization mode
+	// preferred by the shaper (for OpenType fonts).
+	Normalization NormalizationMode
+
+	// MaxOps, if positive, is the maximum number of operations (lookup applications,
+	// nested lookup calls, etc...) performed by one `Shape` call with an Opentype font.
+	// The default (zero) uses a limit proportional to the length of the buffer.
+	// See also `BudgetExceeded`.
+	MaxOps int
 
 	// some pathological cases can be constructed
 	// (for example with GSUB tables), where the size of the buffer
@@ -105,6 +125,12 @@ type Buffer struct {
 	scratchFlags bufferScratchFlags /* Have space-fallback, etc. */
 
 	haveOutput bool
+
+	// set when maxOps or maxLen are exceeded
+	budgetExceeded bool
+
+	messageFunc func(msg string) bool // optional, see SetMessageFunc
+	debugLogger io.Writer             // optional, see SetDebugLogger
 }
 
 // NewBuffer allocate a storage with default options.
@@ -116,6 +142,81 @@ func NewBuffer() *Buffer {
 	}
 }
 
+// BudgetExceeded returns true if the last call to `Shape` stopped
+// applying lookups early, because the operations budget (see `MaxOps`)
+// or the maximum length of the buffer was exceeded.
+// The buffer then contains a partially shaped result.
+// This should not happen with decent font files.
+func (b *Buffer) BudgetExceeded() bool { return b.budgetExceeded }
+
+// SafeToBreakAt returns true if the text may be broken between the glyphs
+// `glyphIndex` and `glyphIndex+1` of the shaped buffer, that is if shaping the two sides
+// separately would give the same glyphs, so that the line breaking does not require
+// to shape the text again.
+// It is false inside a cluster, and when the cluster following the break
+// (in logical order) has the `GlyphUnsafeToBreak` flag.
+// The edges of the buffer (glyphIndex < 0 or glyphIndex >= len(b.Info)-1) are always safe.
+func (b *Buffer) SafeToBreakAt(glyphIndex int) bool {
+	if glyphIndex < 0 || glyphIndex >= len(b.Info)-1 {
+		return true
+	}
+	before, after := b.Info[glyphIndex], b.Info[glyphIndex+1]
+	if before.Cluster == after.Cluster {
+		return false
+	}
+	next := after // the first glyph of the logically next cluster
+	if b.Props.Direction.isBackward() {
+		next = before
+	}
+	return next.Mask&GlyphUnsafeToBreak == 0
+}
+
+// SetMessageFunc sets a callback, called by `Shape` at key points of the shaping
+// with Opentype fonts, which may be used to debug the shaping.
+// The messages are "start table GSUB", "start lookup <index>", "end lookup <index>",
+// "start pause <stage>", "end pause <stage>", "end table GSUB", and the same for GPOS.
+// When called, the current state of the shaping is stored in `Info` and `Pos`.
+// If `f` returns false for a "start" message, the corresponding lookup
+// or pause function is skipped.
+// Passing nil removes the callback. It is not reset by `Clear`.
+func (b *Buffer) SetMessageFunc(f func(msg string) bool) { b.messageFunc = f }
+
+// SetDebugLogger enables the logging of the main shaping steps
+// (choice of the shaper, lookups applied, reordering, etc...) to `w`,
+// which is useful to investigate unexpected shaping results.
+// Passing nil disables the logging, which is the default.
+// It is not reset by `Clear`.
+func (b *Buffer) SetDebugLogger(w io.Writer) { b.debugLogger = w }
+
+// debugging returns true if a debug logger is set,
+// and should be used to avoid formatting messages otherwise
+func (b *Buffer) debugging() bool { return b.debugLogger != nil }
+
+func (b *Buffer) debugf(format string, args ...interface{}) {
+	if b.debugLogger != nil {
+		fmt.Fprintf(b.debugLogger, format, args...)
+	}
+}
+
+func (b *Buffer) debugln(args ...interface{}) {
+	if b.debugLogger != nil {
+		fmt.Fprintln(b.debugLogger, args...)
+	}
+}
+
+// messaging returns true if a message callback is set,
+// and should be used to avoid formatting messages otherwise
+func (b *Buffer) messaging() bool { return b.messageFunc != nil }
+
+// message calls the message callback, if any,
+// returning true if the operation should go on
+func (b *Buffer) message(format string, args ...interface{}) bool {
+	if b.messageFunc == nil {
+		return true
+	}
+	return b.messageFunc(fmt.Sprintf(format, args...))
+}
+
 // AddRune appends a character with the Unicode value of `codepoint` to `b`, and
 // gives it the initial cluster value of `cluster`. Clusters can be any thing
 // the client wants, they are usually used to refer to the index of the
@@ -220,11 +321,14 @@ func (b *Buffer) Clear() {
 	b.Flags = 0
 	b.Invisible = 0
 	b.NotFound = 0
+	b.NotdefHandling = NotdefKeep
+	b.NotdefReplacement = nil
 
 	b.Props = SegmentProperties{}
 	b.scratchFlags = 0
 
 	b.haveOutput = false
+	b.budgetExceeded = false
 
 	b.idx = 0
 	b.Info = b.Info[:0]
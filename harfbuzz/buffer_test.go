@@ -1,6 +1,8 @@
 package harfbuzz
 
 import (
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/benoitkugler/textlayout/fonts"
@@ -340,3 +342,173 @@ func bufferDiff(buffer, reference *Buffer, dottedcircleGlyph fonts.GID, position
 
 	return result
 }
+
+func TestNotFoundHandling(t *testing.T) {
+	font := NewFont(openFontFileTT("DejaVuSerif.ttf"))
+	input := []rune{'a', 0x4E2D, 'b'} // CJK ideograph not supported by the font
+
+	shape := func(handling NotdefHandling, notFound fonts.GID, replacement string) *Buffer {
+		buf := NewBuffer()
+		buf.AddRunes(input, 0, -1)
+		buf.GuessSegmentProperties()
+		buf.NotdefHandling = handling
+		buf.NotFound = notFound
+		buf.NotdefReplacement = []rune(replacement)
+		buf.Shape(font, nil)
+		return buf
+	}
+
+	glyphA, _ := font.face.NominalGlyph('a')
+	glyphB, _ := font.face.NominalGlyph('b')
+	glyphQ, _ := font.face.NominalGlyph('?')
+
+	// keep : .notdef is used
+	buf := shape(NotdefKeep, 0, "")
+	assertEqualInt(t, 3, len(buf.Info))
+	assert(t, buf.Info[1].Glyph == 0)
+	assertEqualInt(t, 1, buf.Info[1].Cluster)
+
+	// keep, with a custom glyph
+	buf = shape(NotdefKeep, glyphB, "")
+	assertEqualInt(t, 3, len(buf.Info))
+	assert(t, buf.Info[1].Glyph == glyphB)
+
+	// remove
+	buf = shape(NotdefRemove, 0, "")
+	assertEqualInt(t, 2, len(buf.Info))
+	assertEqualInt(t, 2, len(buf.Pos))
+	assert(t, buf.Info[0].Glyph == glyphA && buf.Info[1].Glyph == glyphB)
+	assertEqualInt(t, 0, buf.Info[0].Cluster)
+	assertEqualInt(t, 2, buf.Info[1].Cluster)
+
+	// remove only the missing runes, even if their glyph is used by the font
+	buf = shape(NotdefRemove, glyphB, "")
+	assertEqualInt(t, 2, len(buf.Info))
+	assert(t, buf.Info[0].Glyph == glyphA && buf.Info[1].Glyph == glyphB)
+
+	// replace by a string
+	buf = shape(NotdefReplace, 0, "??")
+	assertEqualInt(t, 4, len(buf.Info))
+	assertEqualInt(t, 4, len(buf.Pos))
+	assert(t, buf.Info[0].Glyph == glyphA && buf.Info[3].Glyph == glyphB)
+	for _, i := range []int{1, 2} {
+		assert(t, buf.Info[i].Glyph == glyphQ)
+		assertEqualInt(t, 1, buf.Info[i].Cluster)
+		assertEqualInt32(t, buf.Pos[i].XAdvance, font.GlyphHAdvance(glyphQ))
+	}
+}
+
+func TestClusterLevel(t *testing.T) {
+	font := NewFont(openFontFileTT("DejaVuSerif.ttf"))
+
+	fi := []rune{'f', 'i'}
+	fiMarks := []rune{'f', 'i', 0x0301, 0x0323}     // the marks are reordered
+	xMarks := []rune{'a', 'x', 0x0323, 0x0301, 'b'} // no precomposed form
+
+	for _, test := range []struct {
+		level    ClusterLevel
+		input    []rune
+		expected string
+	}{
+		// ligatures always use the cluster of the first component
+		{MonotoneGraphemes, fi, "[fi=0]"},
+		{MonotoneCharacters, fi, "[fi=0]"},
+		{Characters, fi, "[fi=0]"},
+
+		// marks are merged with their base only at grapheme level
+		{MonotoneGraphemes, xMarks, "[a=0|x=1|dotbelowcomb=1|acutecomb=1|b=4]"},
+		{MonotoneCharacters, xMarks, "[a=0|x=1|dotbelowcomb=2|acutecomb=3|b=4]"},
+		{Characters, xMarks, "[a=0|x=1|dotbelowcomb=2|acutecomb=3|b=4]"},
+
+		// reordering merges clusters, except at character level
+		{MonotoneGraphemes, fiMarks, "[f=0|uni1ECB.dotless=1|acutecomb=1]"},
+		{MonotoneCharacters, fiMarks, "[f=0|uni1ECB.dotless=1|acutecomb=1]"},
+		{Characters, fiMarks, "[f=0|uni1ECB.dotless=1|acutecomb=2]"},
+	} {
+		buf := NewBuffer()
+		buf.ClusterLevel = test.level
+		buf.AddRunes(test.input, 0, -1)
+		buf.GuessSegmentProperties()
+		buf.Shape(font, nil)
+
+		if got := buf.serialize(font, formatOptions{hidePositions: true}); got != test.expected {
+			t.Errorf("for %s and %v, expected %s, got %s", test.level, test.input, test.expected, got)
+		}
+	}
+}
+
+func TestMessageFunc(t *testing.T) {
+	font := NewFont(openFontFileTT("DejaVuSerif.ttf"))
+
+	shape := func(messageFunc func(string) bool) *Buffer {
+		buf := NewBuffer()
+		buf.SetMessageFunc(messageFunc)
+		buf.AddRunes([]rune("fi"), 0, -1)
+		buf.GuessSegmentProperties()
+		buf.Shape(font, nil)
+		return buf
+	}
+
+	var messages []string
+	buf := shape(func(msg string) bool {
+		messages = append(messages, msg)
+		return true
+	})
+	assertEqualInt(t, 1, len(buf.Info)) // ligature
+
+	var tables []string
+	lookups := 0
+	for i, msg := range messages {
+		switch {
+		case strings.HasSuffix(msg, "table GSUB"), strings.HasSuffix(msg, "table GPOS"):
+			tables = append(tables, msg)
+		case strings.HasPrefix(msg, "start lookup"):
+			lookups++
+			// each lookup is closed before the next message
+			if exp := strings.Replace(msg, "start", "end", 1); i+1 >= len(messages) || messages[i+1] != exp {
+				t.Fatalf("expected %s after %s", exp, msg)
+			}
+		}
+	}
+	if exp := []string{"start table GSUB", "end table GSUB", "start table GPOS", "end table GPOS"}; !reflect.DeepEqual(tables, exp) {
+		t.Fatalf("expected %v, got %v", exp, tables)
+	}
+	if lookups == 0 {
+		t.Fatal("expected lookup messages")
+	}
+
+	// skipping the lookups disables the ligature
+	buf = shape(func(msg string) bool { return !strings.HasPrefix(msg, "start lookup") })
+	assertEqualInt(t, 2, len(buf.Info))
+}
+
+func TestSafeToBreakAt(t *testing.T) {
+	font := NewFont(openFontFileTT("DejaVuSerif.ttf"))
+
+	for _, test := range []struct {
+		text     string
+		expected []bool // for each glyph, except the last one
+	}{
+		// kerning pairs are unsafe, word boundaries are safe
+		{"AVA To", []bool{false, false, true, true, false}},
+		// the mark is in the cluster of its base
+		{"xi\u0323\u0301", []bool{true, false}},
+		// the mark is attached to the 'fi' ligature, in the same cluster
+		{"fi\u0332", []bool{false}},
+		// joining letters (rendered with .notdef), in right to left order
+		{"سلام عليكم", []bool{false, false, false, false, true, true, true, false, false}},
+	} {
+		buf := NewBuffer()
+		buf.AddRunes([]rune(test.text), 0, -1)
+		buf.GuessSegmentProperties()
+		buf.Shape(font, nil)
+
+		assertEqualInt(t, len(test.expected)+1, len(buf.Info))
+		for i, exp := range test.expected {
+			if got := buf.SafeToBreakAt(i); got != exp {
+				t.Errorf("%q: expected %v after glyph %d, got %v", test.text, exp, i, got)
+			}
+		}
+		assert(t, buf.SafeToBreakAt(-1) && buf.SafeToBreakAt(len(buf.Info)-1))
+	}
+}
@@ -27,7 +27,9 @@ func (shaperFallback) shape(font *Font, buffer *Buffer, _ []Feature) {
 			pos[i].XAdvance = 0
 			pos[i].YAdvance = 0
 		} else {
-			info[i].Glyph, _ = font.face.NominalGlyph(info[i].codepoint)
+			var ok bool
+			info[i].Glyph, ok = font.face.NominalGlyph(info[i].codepoint)
+			info[i].notFound = !ok
 			pos[i].XAdvance, pos[i].YAdvance = font.GlyphAdvanceForDirection(info[i].Glyph, direction)
 			pos[i].XOffset, pos[i].YOffset = font.subtractGlyphOriginForDirection(info[i].Glyph, direction,
 				pos[i].XOffset, pos[i].YOffset)
@@ -143,6 +143,18 @@ func (f *Font) nominalGlyph(r rune, notFound fonts.GID) (fonts.GID, bool) {
 	return g, ok
 }
 
+// VariationGlyph retrieves the glyph ID for the Unicode code point `base`
+// followed by the Variation Selector `selector`, using the Unicode Variation
+// Sequences of the font (cmap format 14). It returns false if the sequence is not
+// supported, or if the face does not implement `FaceOpentype`.
+func (f *Font) VariationGlyph(base, selector rune) (fonts.GID, bool) {
+	face, ok := f.face.(FaceOpentype)
+	if !ok {
+		return 0, false
+	}
+	return face.VariationGlyph(base, selector)
+}
+
 // ---- Convert from font-space to user-space ----
 
 func (f *Font) emScaleX(v int16) Position    { return Position(v) * f.XScale / f.faceUpem }
@@ -275,6 +287,39 @@ func (f *Font) hasGlyph(ch rune) bool {
 	return ok
 }
 
+// CanRender returns true if all the runes of `text` are
+// mapped to a glyph by the font, using a simple cmap lookup.
+// Default ignorable characters, which are hidden during shaping,
+// are not required to be supported.
+//
+// This check is fast, but does not account for the substitutions performed
+// during shaping. See `ShapesWithoutNotdef` for a stricter alternative.
+func (f *Font) CanRender(text []rune) bool {
+	for _, r := range text {
+		if _, ok := f.face.NominalGlyph(r); !ok && !uni.isDefaultIgnorable(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// ShapesWithoutNotdef shapes `text` using `props`, and returns true if
+// the result does not contain the .notdef glyph (GID 0).
+// Unset properties are guessed from the text (see `Buffer.GuessSegmentProperties`).
+func (f *Font) ShapesWithoutNotdef(text []rune, props SegmentProperties) bool {
+	buffer := NewBuffer()
+	buffer.AddRunes(text, 0, -1)
+	buffer.Props = props
+	buffer.GuessSegmentProperties()
+	buffer.Shape(f, nil)
+	for _, info := range buffer.Info {
+		if info.Glyph == 0 {
+			return false
+		}
+	}
+	return true
+}
+
 func (f *Font) subtractGlyphHOrigin(glyph fonts.GID, x, y Position) (Position, Position) {
 	originX, originY := f.getGlyphHOriginWithFallback(glyph)
 	return x - originX, y - originY
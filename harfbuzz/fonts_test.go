@@ -268,3 +268,74 @@ func TestLigCarets(t *testing.T) {
 		t.Fatalf("for glyph %d, expected %v, got %v", 1023, expected, carets)
 	}
 }
+
+func TestCanRender(t *testing.T) {
+	font := NewFont(openFontFileTT("DejaVuSerif.ttf"))
+
+	ascii := []rune("The quick brown fox, 123 !")
+	cjk := []rune("中文")
+
+	assert(t, font.CanRender(ascii))
+	assert(t, font.CanRender(append([]rune{0x200D}, ascii...))) // default ignorables are hidden
+	assert(t, !font.CanRender(cjk))
+	assert(t, !font.CanRender(append(ascii, cjk...)))
+
+	assert(t, font.ShapesWithoutNotdef(ascii, SegmentProperties{}))
+	assert(t, !font.ShapesWithoutNotdef(cjk, SegmentProperties{}))
+	assert(t, !font.ShapesWithoutNotdef(append(ascii, cjk...), SegmentProperties{}))
+}
+
+func TestVariationGlyph(t *testing.T) {
+	font := NewFont(openFontFileTT("ToyCMAP14.otf"))
+
+	const base, selector = 0x82A6, 0xE0101 // CJK ideograph with a registered variant
+	nominal, ok := font.face.NominalGlyph(base)
+	assert(t, ok)
+	variant, ok := font.VariationGlyph(base, selector)
+	assert(t, ok)
+	assert(t, variant != nominal)
+
+	_, ok = font.VariationGlyph(base, 0xFE0F)
+	assert(t, !ok)
+
+	buf := NewBuffer()
+	buf.AddRunes([]rune{base, selector}, 0, -1)
+	buf.GuessSegmentProperties()
+	buf.Shape(font, nil)
+	assertEqualInt(t, 1, len(buf.Info))
+	assertEqualInt(t, int(variant), int(buf.Info[0].Glyph))
+
+	buf = NewBuffer()
+	buf.AddRunes([]rune{base}, 0, -1)
+	buf.GuessSegmentProperties()
+	buf.Shape(font, nil)
+	assertEqualInt(t, int(nominal), int(buf.Info[0].Glyph))
+}
+
+func TestShapeHvarHeavyWeight(t *testing.T) {
+	face := openFontFileTT("Commissioner-VF.ttf")
+	font := NewFont(face)
+
+	shape := func() *Buffer {
+		buf := NewBuffer()
+		buf.AddRunes([]rune("Win"), 0, -1)
+		buf.GuessSegmentProperties()
+		buf.Shape(font, nil)
+		return buf
+	}
+
+	regular := shape()
+	font.SetVarCoordsDesign([]float32{900, 0, 0, 0}) // Black
+	heavy := shape()
+
+	for i, pos := range heavy.Pos {
+		gid := heavy.Info[i].Glyph
+		defaultAdvance := int32(face.Hmtx[gid].Advance)
+		assertEqualInt32(t, regular.Pos[i].XAdvance, defaultAdvance)
+		// the advance includes the 'HVAR' delta, not only the default 'hmtx' value
+		if pos.XAdvance <= defaultAdvance {
+			t.Fatalf("glyph %d: expected wider advance than %d, got %d", gid, defaultAdvance, pos.XAdvance)
+		}
+		assertEqualInt32(t, pos.XAdvance, int32(face.HorizontalAdvance(gid)+0.5))
+	}
+}
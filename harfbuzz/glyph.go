@@ -153,6 +153,8 @@ type GlyphInfo struct {
 	unicode unicodeProp
 
 	complexCategory, complexAux uint8 // storage interpreted by complex shapers
+
+	notFound bool // the input character is not in the font, see NotdefHandling
 }
 
 // String returns a simple description of the glyph of the form Glyph=Cluster(mask)
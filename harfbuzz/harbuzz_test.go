@@ -46,6 +46,17 @@ func openFontFileTT(filename string) *tt.Font {
 	return font
 }
 
+// opens the font at `index` in a collection from truetype testdata.
+func openFontCollectionTT(filename string, index int) *tt.Font {
+	f, err := tttestdata.Files.ReadFile(filename)
+	check(err)
+
+	fonts, err := tt.Load(bytes.NewReader(f))
+	check(err)
+
+	return fonts[index].(*tt.Font)
+}
+
 // opens truetype fonts from harfbuzz testdata.
 func openFontFile(filename string) *tt.Font {
 	f, err := testdata.Files.ReadFile(filename)
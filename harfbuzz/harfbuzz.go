@@ -23,10 +23,10 @@ import (
 // pending the change for Unicode 14 that are not merged yet, starting at
 // 195c05df9925c7c4a4982a286ef9c416b2cde3af
 
-// debugMode is only used in test:
-//   - 0 : nothing
-//   - 1 : only the main steps are printed
-//   - 2 : details informations are printed
+// debugMode enables, at compile time, additional diagnostics:
+//   - 0 : only the main steps are logged, when a logger is set (see Buffer.SetDebugLogger)
+//   - 1 : the creation of the shape plans is also printed on stdout
+//   - 2 : details informations are also logged
 const debugMode = 0
 
 // Direction is the text direction.
@@ -147,6 +147,24 @@ const (
 	DoNotinsertDottedCircle
 )
 
+// NotdefHandling controls how the characters not found in the font
+// are displayed. It defaults to `NotdefKeep`.
+type NotdefHandling uint8
+
+const (
+	// Characters not found are displayed with the `Buffer.NotFound` glyph
+	// (usually .notdef), which may be used to supply a replacement glyph.
+	NotdefKeep NotdefHandling = iota
+	// Characters not found are removed from the shaping result.
+	// Their clusters are merged with the adjacent glyphs.
+	NotdefRemove
+	// Characters not found are replaced by the glyphs of the runes of
+	// `Buffer.NotdefReplacement`, with the same cluster.
+	// The replacement runes are not shaped: only their nominal glyphs and
+	// advances are used.
+	NotdefReplace
+)
+
 // ClusterLevel allows selecting more fine-grained Cluster handling.
 // It defaults to `MonotoneGraphemes`.
 type ClusterLevel uint8
@@ -1,8 +1,6 @@
 package harfbuzz
 
 import (
-	"fmt"
-
 	"github.com/benoitkugler/textlayout/fonts"
 	tt "github.com/benoitkugler/textlayout/fonts/truetype"
 )
@@ -828,7 +826,7 @@ func (s stateTableDriver) drive(c driverContext) {
 		}
 
 		if debugMode >= 2 {
-			fmt.Printf("\t\tState machine - state %d, class %d at index %d\n", state, class, s.buffer.idx)
+			s.buffer.debugf("\t\tState machine - state %d, class %d at index %d\n", state, class, s.buffer.idx)
 		}
 
 		entry := s.machine.GetEntry(state, class)
@@ -892,7 +890,7 @@ func (s stateTableDriver) drive(c driverContext) {
 		state = nextState
 
 		if debugMode >= 2 {
-			fmt.Printf("\t\tState machine - new state %d\n", state)
+			s.buffer.debugf("\t\tState machine - new state %d\n", state)
 		}
 
 		if s.buffer.idx == len(s.buffer.Info) {
@@ -986,7 +984,7 @@ func (c *aatApplyContext) applyMorx(chain tt.MorxChain, flags GlyphMask) {
 		}
 
 		if debugMode >= 2 {
-			fmt.Printf("MORX - start chainsubtable %d\n", i)
+			c.buffer.debugf("MORX - start chainsubtable %d\n", i)
 		}
 
 		if reverse {
@@ -1000,8 +998,8 @@ func (c *aatApplyContext) applyMorx(chain tt.MorxChain, flags GlyphMask) {
 		}
 
 		if debugMode >= 2 {
-			fmt.Printf("MORX - end chainsubtable %d\n", i)
-			fmt.Println(c.buffer.Info)
+			c.buffer.debugf("MORX - end chainsubtable %d\n", i)
+			c.buffer.debugln(c.buffer.Info)
 		}
 
 	}
@@ -1009,7 +1007,7 @@ func (c *aatApplyContext) applyMorx(chain tt.MorxChain, flags GlyphMask) {
 
 func (c *aatApplyContext) applyMorxSubtable(subtable tt.MortxSubtable) bool {
 	if debugMode >= 2 {
-		fmt.Printf("\tMORX subtable %T\n", subtable.Data)
+		c.buffer.debugf("\tMORX subtable %T\n", subtable.Data)
 	}
 	switch data := subtable.Data.(type) {
 	case tt.MorxRearrangementSubtable:
@@ -1218,7 +1216,7 @@ func (dc *driverContextLigature) transition(driver stateTableDriver, entry tt.AA
 	buffer := driver.buffer
 
 	if debugMode >= 2 {
-		fmt.Printf("\tLigature - Ligature transition at %d\n", buffer.idx)
+		buffer.debugf("\tLigature - Ligature transition at %d\n", buffer.idx)
 	}
 
 	if entry.Flags&tt.MLSetComponent != 0 {
@@ -1231,7 +1229,7 @@ func (dc *driverContextLigature) transition(driver stateTableDriver, entry tt.AA
 		dc.matchLength++
 
 		if debugMode >= 2 {
-			fmt.Printf("\tLigature - Set component at %d\n", len(buffer.outInfo))
+			buffer.debugf("\tLigature - Set component at %d\n", len(buffer.outInfo))
 		}
 
 	}
@@ -1239,7 +1237,7 @@ func (dc *driverContextLigature) transition(driver stateTableDriver, entry tt.AA
 	if dc.isActionable(driver, entry) {
 
 		if debugMode >= 2 {
-			fmt.Printf("\tLigature - Perform action with %d\n", dc.matchLength)
+			buffer.debugf("\tLigature - Perform action with %d\n", dc.matchLength)
 		}
 
 		end := len(buffer.outInfo)
@@ -1262,14 +1260,14 @@ func (dc *driverContextLigature) transition(driver stateTableDriver, entry tt.AA
 			if cursor == 0 {
 				/* Stack underflow.  Clear the stack. */
 				if debugMode >= 2 {
-					fmt.Println("\tLigature - Stack underflow")
+					buffer.debugln("\tLigature - Stack underflow")
 				}
 				dc.matchLength = 0
 				break
 			}
 
 			if debugMode >= 2 {
-				fmt.Printf("\tLigature - Moving to stack position %d\n", cursor-1)
+				buffer.debugf("\tLigature - Moving to stack position %d\n", cursor-1)
 			}
 
 			cursor--
@@ -1293,7 +1291,7 @@ func (dc *driverContextLigature) transition(driver stateTableDriver, entry tt.AA
 			ligatureIdx += int(componentData)
 
 			if debugMode >= 2 {
-				fmt.Printf("\tLigature - Action store %d last %d\n", action&tt.MLActionStore, action&tt.MLActionLast)
+				buffer.debugf("\tLigature - Action store %d last %d\n", action&tt.MLActionStore, action&tt.MLActionLast)
 			}
 
 			if action&(tt.MLActionStore|tt.MLActionLast) != 0 {
@@ -1303,7 +1301,7 @@ func (dc *driverContextLigature) transition(driver stateTableDriver, entry tt.AA
 				lig := dc.table.Ligatures[ligatureIdx]
 
 				if debugMode >= 2 {
-					fmt.Printf("\tLigature - Produced ligature %d\n", lig)
+					buffer.debugf("\tLigature - Produced ligature %d\n", lig)
 				}
 
 				buffer.replaceGlyphIndex(lig)
@@ -1313,7 +1311,7 @@ func (dc *driverContextLigature) transition(driver stateTableDriver, entry tt.AA
 				for dc.matchLength-1 > cursor {
 
 					if debugMode >= 2 {
-						fmt.Println("\tLigature - Skipping ligature component")
+						buffer.debugln("\tLigature - Skipping ligature component")
 					}
 
 					dc.matchLength--
@@ -1505,7 +1503,7 @@ func (c *aatApplyContext) applyKernx(kerx tt.TableKernx) {
 		reverse = st.IsBackwards() != c.buffer.Props.Direction.isBackward()
 
 		if debugMode >= 2 {
-			fmt.Printf("AAT kerx : start subtable %d\n", i)
+			c.buffer.debugf("AAT kerx : start subtable %d\n", i)
 		}
 
 		if !seenCrossStream && st.IsCrossStream() {
@@ -1537,8 +1535,8 @@ func (c *aatApplyContext) applyKernx(kerx tt.TableKernx) {
 		}
 
 		if debugMode >= 2 {
-			fmt.Printf("AAT kerx : end subtable %d\n", i)
-			fmt.Println(c.buffer.Pos)
+			c.buffer.debugf("AAT kerx : end subtable %d\n", i)
+			c.buffer.debugln(c.buffer.Pos)
 		}
 
 	}
@@ -1546,7 +1544,7 @@ func (c *aatApplyContext) applyKernx(kerx tt.TableKernx) {
 
 func (c *aatApplyContext) applyKerxSubtable(st tt.KernSubtable) bool {
 	if debugMode >= 2 {
-		fmt.Printf("\tKERNX table %T\n", st.Data)
+		c.buffer.debugf("\tKERNX table %T\n", st.Data)
 	}
 	switch data := st.Data.(type) {
 	case tt.Kern0:
@@ -1,7 +1,6 @@
 package harfbuzz
 
 import (
-	"fmt"
 	"sort"
 
 	"github.com/benoitkugler/textlayout/fonts"
@@ -458,11 +457,11 @@ func (cs *complexShaperArabic) postprocessGlyphs(plan *otShapePlan, buffer *Buff
 			}
 			i++ // Don't touch i again.
 
-			if debugMode >= 1 {
-				fmt.Printf("ARABIC - step %d: stretch at (%d,%d,%d)\n", step+1, context, start, end)
-				fmt.Printf("ARABIC - rest of word:    count=%d width %d\n", start-context, wTotal)
-				fmt.Printf("ARABIC - fixed tiles:     count=%d width=%d\n", nFixed, wFixed)
-				fmt.Printf("ARABIC - repeating tiles: count=%d width=%d\n", nRepeating, wRepeating)
+			if buffer.debugging() {
+				buffer.debugf("ARABIC - step %d: stretch at (%d,%d,%d)\n", step+1, context, start, end)
+				buffer.debugf("ARABIC - rest of word:    count=%d width %d\n", start-context, wTotal)
+				buffer.debugf("ARABIC - fixed tiles:     count=%d width=%d\n", nFixed, wFixed)
+				buffer.debugf("ARABIC - repeating tiles: count=%d width=%d\n", nRepeating, wRepeating)
 			}
 
 			// number of additional times to repeat each repeating tile.
@@ -486,8 +485,8 @@ func (cs *complexShaperArabic) postprocessGlyphs(plan *otShapePlan, buffer *Buff
 
 			if step == MEASURE {
 				extraGlyphsNeeded += nCopies * nRepeating
-				if debugMode >= 1 {
-					fmt.Printf("ARABIC - will add extra %d copies of repeating tiles\n", nCopies)
+				if buffer.debugging() {
+					buffer.debugf("ARABIC - will add extra %d copies of repeating tiles\n", nCopies)
 				}
 			} else {
 				buffer.unsafeToBreak(context, end)
@@ -500,8 +499,8 @@ func (cs *complexShaperArabic) postprocessGlyphs(plan *otShapePlan, buffer *Buff
 						repeat += nCopies
 					}
 
-					if debugMode >= 1 {
-						fmt.Printf("ARABIC - appending %d copies of glyph %d; j=%d\n", repeat, info[k-1].codepoint, j)
+					if buffer.debugging() {
+						buffer.debugf("ARABIC - appending %d copies of glyph %d; j=%d\n", repeat, info[k-1].codepoint, j)
 					}
 					for n := 0; n < repeat; n++ {
 						xOffset -= width
@@ -551,20 +550,20 @@ func infoIsMcm(info *GlyphInfo) bool {
 func (cs *complexShaperArabic) reorderMarks(_ *otShapePlan, buffer *Buffer, start, end int) {
 	info := buffer.Info
 
-	if debugMode >= 1 {
-		fmt.Printf("ARABIC - Reordering marks from %d to %d\n", start, end)
+	if buffer.debugging() {
+		buffer.debugf("ARABIC - Reordering marks from %d to %d\n", start, end)
 	}
 
 	i := start
 	for cc := uint8(220); cc <= 230; cc += 10 {
-		if debugMode >= 1 {
-			fmt.Printf("ARABIC - Looking for %d's starting at %d\n", cc, i)
+		if buffer.debugging() {
+			buffer.debugf("ARABIC - Looking for %d's starting at %d\n", cc, i)
 		}
 		for i < end && info[i].getModifiedCombiningClass() < cc {
 			i++
 		}
-		if debugMode >= 1 {
-			fmt.Printf("ARABIC - Looking for %d's stopped at %d\n", cc, i)
+		if buffer.debugging() {
+			buffer.debugf("ARABIC - Looking for %d's stopped at %d\n", cc, i)
 		}
 
 		if i == end {
@@ -584,10 +583,10 @@ func (cs *complexShaperArabic) reorderMarks(_ *otShapePlan, buffer *Buffer, star
 			continue
 		}
 
-		if debugMode >= 1 {
-			fmt.Printf("ARABIC - Found %d's from %d to %d", cc, i, j)
+		if buffer.debugging() {
+			buffer.debugf("ARABIC - Found %d's from %d to %d", cc, i, j)
 			// shift it!
-			fmt.Printf("ARABIC - Shifting %d's: %d %d", cc, i, j)
+			buffer.debugf("ARABIC - Shifting %d's: %d %d", cc, i, j)
 		}
 
 		var temp [shapeComplexMaxCombiningMarks]GlyphInfo
@@ -1,9 +1,108 @@
 package harfbuzz
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/benoitkugler/textlayout/fonts"
+)
 
 func TestNumArabicLookup(t *testing.T) {
 	if len(arabicFallbackFeatures) > arabicFallbackMaxLookups {
 		t.Error()
 	}
 }
+
+func TestArabicJoinControls(t *testing.T) {
+	const (
+		beh  = 'ب'
+		zwnj = 0x200C
+		zwj  = 0x200D
+	)
+	joiningActions := func(text []rune) []uint8 {
+		buf := NewBuffer()
+		buf.AddRunes(text, 0, -1)
+		buf.GuessSegmentProperties()
+		buf.setUnicodeProps()
+		arabicJoining(buf)
+		out := make([]uint8, len(buf.Info))
+		for i, info := range buf.Info {
+			out[i] = info.complexAux
+		}
+		return out
+	}
+
+	for _, test := range []struct {
+		text     []rune
+		expected map[int]uint8 // index of letters -> action
+	}{
+		{[]rune{beh, beh}, map[int]uint8{0: arabInit, 1: arabFina}},
+		// ZWNJ prevents joining
+		{[]rune{beh, zwnj, beh}, map[int]uint8{0: arabIsol, 2: arabIsol}},
+		{[]rune{beh, beh, zwnj, beh}, map[int]uint8{0: arabInit, 1: arabFina, 3: arabIsol}},
+		// ZWJ forces joining
+		{[]rune{zwj, beh, zwj}, map[int]uint8{1: arabMedi}},
+		{[]rune{zwj, beh}, map[int]uint8{1: arabFina}},
+		{[]rune{beh, zwj}, map[int]uint8{0: arabInit}},
+		{[]rune{beh, zwj, beh}, map[int]uint8{0: arabInit, 2: arabFina}},
+	} {
+		actions := joiningActions(test.text)
+		for i, exp := range test.expected {
+			if actions[i] != exp {
+				t.Errorf("for %q, expected action %d at %d, got %d", string(test.text), exp, i, actions[i])
+			}
+		}
+	}
+
+	// check the glyphs selected by a font
+	font := NewFont(openFontFile("perf_reference/fonts/Amiri-Regular.ttf"))
+	shape := func(text []rune) []fonts.GID { // in logical order
+		buf := NewBuffer()
+		buf.AddRunes(text, 0, -1)
+		buf.GuessSegmentProperties()
+		buf.Shape(font, nil)
+		out := make([]fonts.GID, len(buf.Info))
+		for i, info := range buf.Info {
+			out[len(out)-1-i] = info.Glyph
+		}
+		return out
+	}
+	isol := shape([]rune{beh})[0]
+	if glyphs := shape([]rune{beh, zwnj, beh}); glyphs[0] != isol || glyphs[2] != isol {
+		t.Errorf("expected isolated forms, got %v", glyphs)
+	}
+	if glyphs := shape([]rune{zwj, beh, zwj}); glyphs[1] == isol {
+		t.Errorf("expected a joined form, got %v", glyphs)
+	}
+}
+
+func TestArabicFallbackShaping(t *testing.T) {
+	// this font has presentation forms in its cmap, but no GSUB table
+	face := openFontFile("harfbuzz_reference/in-house/fonts/df768b9c257e0c9c35786c47cae15c46571d56be.ttf")
+	if lt := face.LayoutTables(); len(lt.GSUB.Features) != 0 {
+		t.Fatalf("expected no GSUB features, got %d", len(lt.GSUB.Features))
+	}
+	font := NewFont(face)
+
+	for _, test := range []struct {
+		text     string
+		expected []fonts.GID // in visual order
+	}{
+		{"س", []fonts.GID{3}},           // no isolated form: nominal glyph
+		{"سلا", []fonts.GID{11, 4}},     // uni06440627.fina (lam-alef ligature), uni0633.init
+		{"متی", []fonts.GID{22, 20, 8}}, // uni06CC.fina, uni062A.medi, uni0645.init
+		{"ام", []fonts.GID{7, 1}},       // alef does not join on the left
+	} {
+		buf := NewBuffer()
+		buf.AddRunes([]rune(test.text), 0, -1)
+		buf.GuessSegmentProperties()
+		buf.Shape(font, nil)
+		if len(buf.Info) != len(test.expected) {
+			t.Fatalf("%s: expected %d glyphs, got %d", test.text, len(test.expected), len(buf.Info))
+		}
+		for i, info := range buf.Info {
+			if info.Glyph != test.expected[i] {
+				t.Fatalf("%s: expected glyphs %v, got %v", test.text, test.expected, buf.Info)
+			}
+		}
+	}
+}
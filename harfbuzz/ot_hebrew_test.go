@@ -0,0 +1,48 @@
+package harfbuzz
+
+import (
+	"testing"
+
+	tt "github.com/benoitkugler/textlayout/fonts/truetype"
+)
+
+// noGPOSFace hides the GPOS table, so that the
+// Hebrew shaper falls back to presentation forms.
+type noGPOSFace struct {
+	*tt.Font
+}
+
+func (f noGPOSFace) LayoutTables() tt.LayoutTables {
+	lt := f.Font.LayoutTables()
+	lt.GPOS = tt.TableGPOS{}
+	return lt
+}
+
+func TestHebrewPresentationForms(t *testing.T) {
+	face := openFontFileTT("FreeSerif.ttf")
+	shinWithShinDot, ok := face.NominalGlyph(0xFB2A)
+	assert(t, ok)
+
+	shape := func(font *Font) *Buffer {
+		buf := NewBuffer()
+		buf.AddRunes([]rune{0x05E9, 0x05C1}, 0, -1) // SHIN, SHIN DOT
+		buf.GuessSegmentProperties()
+		buf.Shape(font, nil)
+		return buf
+	}
+
+	// the font has GPOS mark positioning : the dot is kept
+	// as a separate mark glyph
+	buf := shape(NewFont(face))
+	assertEqualInt(t, 2, len(buf.Info))
+	for _, info := range buf.Info {
+		assert(t, info.Glyph != shinWithShinDot)
+		assertEqualInt(t, 0, info.Cluster)
+	}
+
+	// without GPOS, the precomposed presentation form is used
+	buf = shape(NewFont(noGPOSFace{face}))
+	assertEqualInt(t, 1, len(buf.Info))
+	assert(t, buf.Info[0].Glyph == shinWithShinDot)
+	assertEqualInt(t, 0, buf.Info[0].Cluster)
+}
@@ -1,7 +1,6 @@
 package harfbuzz
 
 import (
-	"fmt"
 	"sort"
 
 	"github.com/benoitkugler/textlayout/fonts"
@@ -833,7 +832,7 @@ func (indicPlan *indicShapePlan) initialReorderingConsonantSyllable(font *Font,
 				if info[j].complexCategory != otH && j > i {
 					/* Move Halant to after last consonant. */
 					if debugMode >= 2 {
-						fmt.Printf("INDIC - halant: switching glyph %d to %d (and shifting between)", i, j)
+						buffer.debugf("INDIC - halant: switching glyph %d to %d (and shifting between)", i, j)
 					}
 					t := info[i]
 					copy(info[i:j], info[i+1:])
@@ -901,7 +900,7 @@ func (indicPlan *indicShapePlan) initialReorderingConsonantSyllable(font *Font,
 		/* Sit tight, rock 'n roll! */
 
 		if debugMode >= 2 {
-			fmt.Printf("INDIC - post-base: sorting between glyph %d and %d\n", start, end)
+			buffer.debugf("INDIC - post-base: sorting between glyph %d and %d\n", start, end)
 		}
 
 		subSlice := info[start:end]
@@ -1103,8 +1102,8 @@ func (indicPlan *indicShapePlan) initialReorderingSyllableIndic(font *Font, buff
 }
 
 func (cs *complexShaperIndic) initialReorderingIndic(_ *otShapePlan, font *Font, buffer *Buffer) {
-	if debugMode >= 1 {
-		fmt.Println("INDIC - start reordering indic initial")
+	if buffer.debugging() {
+		buffer.debugln("INDIC - start reordering indic initial")
 	}
 
 	cs.plan.updateConsonantPositionsIndic(font, buffer)
@@ -1116,8 +1115,8 @@ func (cs *complexShaperIndic) initialReorderingIndic(_ *otShapePlan, font *Font,
 		cs.plan.initialReorderingSyllableIndic(font, buffer, start, end)
 	}
 
-	if debugMode >= 1 {
-		fmt.Println("INDIC - end reordering indic initial")
+	if buffer.debugging() {
+		buffer.debugln("INDIC - end reordering indic initial")
 	}
 }
 
@@ -1291,7 +1290,7 @@ func (indicPlan *indicShapePlan) finalReorderingSyllableIndic(plan *otShapePlan,
 					}
 
 					if debugMode >= 2 {
-						fmt.Printf("INDIC - matras: switching glyph %d to %d (and shifting between)", oldPos, newPos)
+						buffer.debugf("INDIC - matras: switching glyph %d to %d (and shifting between)", oldPos, newPos)
 					}
 
 					tmp := info[oldPos]
@@ -1458,7 +1457,7 @@ func (indicPlan *indicShapePlan) finalReorderingSyllableIndic(plan *otShapePlan,
 		{
 
 			if debugMode >= 2 {
-				fmt.Printf("INDIC - reph: switching glyph %d to %d (and shifting between)", start, newRephPos)
+				buffer.debugf("INDIC - reph: switching glyph %d to %d (and shifting between)", start, newRephPos)
 			}
 
 			/* Move */
@@ -1523,7 +1522,7 @@ func (indicPlan *indicShapePlan) finalReorderingSyllableIndic(plan *otShapePlan,
 						buffer.mergeClusters(newPos, oldPos+1)
 
 						if debugMode >= 2 {
-							fmt.Printf("INDIC - pre-base: switching glyph %d to %d (and shifting between)", oldPos, newPos)
+							buffer.debugf("INDIC - pre-base: switching glyph %d to %d (and shifting between)", oldPos, newPos)
 						}
 
 						tmp := info[oldPos]
@@ -1570,8 +1569,8 @@ func (indicPlan *indicShapePlan) finalReorderingIndic(plan *otShapePlan, font *F
 		return
 	}
 
-	if debugMode >= 1 {
-		fmt.Println("INDIC - start reordering indic final")
+	if buffer.debugging() {
+		buffer.debugln("INDIC - start reordering indic final")
 	}
 
 	iter, count := buffer.syllableIterator()
@@ -1579,8 +1578,8 @@ func (indicPlan *indicShapePlan) finalReorderingIndic(plan *otShapePlan, font *F
 		indicPlan.finalReorderingSyllableIndic(plan, buffer, start, end)
 	}
 
-	if debugMode >= 1 {
-		fmt.Println("INDIC - end reordering indic final")
+	if buffer.debugging() {
+		buffer.debugln("INDIC - end reordering indic final")
 	}
 }
 
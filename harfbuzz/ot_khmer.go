@@ -1,8 +1,6 @@
 package harfbuzz
 
 import (
-	"fmt"
-
 	"github.com/benoitkugler/textlayout/fonts"
 	tt "github.com/benoitkugler/textlayout/fonts/truetype"
 )
@@ -280,8 +278,8 @@ func (cs *complexShaperKhmer) reorderSyllableKhmer(buffer *Buffer, start, end in
 }
 
 func (cs *complexShaperKhmer) reorderKhmer(_ *otShapePlan, font *Font, buffer *Buffer) {
-	if debugMode >= 1 {
-		fmt.Println("KHMER - start reordering khmer")
+	if buffer.debugging() {
+		buffer.debugln("KHMER - start reordering khmer")
 	}
 
 	syllabicInsertDottedCircles(font, buffer, khmerBrokenCluster, otDOTTEDCIRCLE, otRepha, -1)
@@ -290,8 +288,8 @@ func (cs *complexShaperKhmer) reorderKhmer(_ *otShapePlan, font *Font, buffer *B
 		cs.reorderSyllableKhmer(buffer, start, end)
 	}
 
-	if debugMode >= 1 {
-		fmt.Println("KHMER - end reordering khmer")
+	if buffer.debugging() {
+		buffer.debugln("KHMER - end reordering khmer")
 	}
 }
 
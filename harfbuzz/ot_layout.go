@@ -187,6 +187,18 @@ func SelectLanguage(table *tt.TableLayout, scriptIndex int, languageTags []tt.Ta
 	return DefaultLanguageIndex, false
 }
 
+// languageTag returns the tag of the language system selected by `SelectLanguage`,
+// `dflt` for the default language system, or 0 if no script is selected.
+func languageTag(table *tt.TableLayout, scriptIndex, languageIndex int) tt.Tag {
+	if scriptIndex == NoScriptIndex {
+		return 0
+	}
+	if languageIndex == DefaultLanguageIndex {
+		return tagDefaultLanguage
+	}
+	return table.Scripts[scriptIndex].Languages[languageIndex].Tag
+}
+
 func findFeature(g *tt.TableLayout, featureTag tt.Tag) uint16 {
 	if index, ok := g.FindFeatureIndex(featureTag); ok {
 		return index
@@ -213,17 +225,22 @@ func FindFeatureForLang(table *tt.TableLayout, scriptIndex, languageIndex int, f
 }
 
 // Fetches the tag of a requested feature index in the given layout table,
-// underneath the specified script and language. Returns -1 if no feature is requested.
+// underneath the specified script and language (which may be the default language system).
+// Returns `NoFeatureIndex` if no feature is requested.
 func getRequiredFeature(g *tt.TableLayout, scriptIndex, languageIndex int) (uint16, tt.Tag) {
-	if scriptIndex == NoScriptIndex || languageIndex == DefaultLanguageIndex {
+	if scriptIndex == NoScriptIndex {
 		return NoFeatureIndex, 0
 	}
 
-	l := g.Scripts[scriptIndex].Languages[languageIndex]
-	if l.RequiredFeatureIndex == 0xFFFF {
+	script := g.Scripts[scriptIndex]
+	if languageIndex == DefaultLanguageIndex && script.DefaultLanguage == nil {
 		return NoFeatureIndex, 0
 	}
+	l := script.GetLangSys(uint16(languageIndex))
 	index := l.RequiredFeatureIndex
+	if index == NoFeatureIndex || int(index) >= len(g.Features) {
+		return NoFeatureIndex, 0
+	}
 	return index, g.Features[index].Tag
 }
 
@@ -1,8 +1,6 @@
 package harfbuzz
 
 import (
-	"fmt"
-
 	"github.com/benoitkugler/textlayout/fonts"
 	tt "github.com/benoitkugler/textlayout/fonts/truetype"
 )
@@ -78,7 +76,7 @@ func positionFinishOffsetsGPOS(buffer *Buffer) {
 	if buffer.scratchFlags&bsfHasGPOSAttachment != 0 {
 
 		if debugMode >= 2 {
-			fmt.Println("POSITION - handling attachments")
+			buffer.debugln("POSITION - handling attachments")
 		}
 
 		for i := range pos {
@@ -135,7 +133,7 @@ func (table gposSubtable) apply(c *otApplyContext) bool {
 	}
 
 	if debugMode >= 2 {
-		fmt.Printf("\tAPPLY - type %T at index %d\n", table.Data, c.buffer.idx)
+		buffer.debugf("\tAPPLY - type %T at index %d\n", table.Data, c.buffer.idx)
 	}
 
 	switch data := table.Data.(type) {
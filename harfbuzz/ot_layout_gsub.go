@@ -1,7 +1,6 @@
 package harfbuzz
 
 import (
-	"fmt"
 	"math/bits"
 
 	"github.com/benoitkugler/textlayout/fonts"
@@ -110,7 +109,7 @@ func (table gsubSubtable) apply(c *otApplyContext) bool {
 	}
 
 	if debugMode >= 2 {
-		fmt.Printf("\tAPPLY - type %T at index %d\n", table.Data, c.buffer.idx)
+		c.buffer.debugf("\tAPPLY - type %T at index %d\n", table.Data, c.buffer.idx)
 	}
 
 	switch data := table.Data.(type) {
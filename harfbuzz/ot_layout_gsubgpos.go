@@ -1,7 +1,6 @@
 package harfbuzz
 
 import (
-	"fmt"
 	"math"
 
 	"github.com/benoitkugler/textlayout/fonts"
@@ -469,7 +468,7 @@ func (c *otApplyContext) applyChainRuleSet(ruleSet []tt.ChainedSequenceRule, mat
 	for i, rule := range ruleSet {
 
 		if debugMode >= 2 {
-			fmt.Println("APPLY - chain rule number", i)
+			c.buffer.debugln("APPLY - chain rule number", i)
 		}
 
 		b := c.chainContextApplyLookup(rule.Backtrack, rule.Input, rule.Lookahead, rule.Lookups, match)
@@ -818,13 +817,14 @@ func (c *otApplyContext) ligateInput(count int, matchPositions [maxContextLength
 }
 
 func (c *otApplyContext) recurse(subLookupIndex uint16) bool {
-	if c.nestingLevelLeft == 0 || c.recurseFunc == nil || c.buffer.maxOps <= 0 {
-		if c.buffer.maxOps <= 0 {
-			c.buffer.maxOps--
-			return false
-		}
+	if c.nestingLevelLeft == 0 || c.recurseFunc == nil {
+		return false
+	}
+	if c.buffer.maxOps <= 0 {
 		c.buffer.maxOps--
+		return false
 	}
+	c.buffer.maxOps--
 
 	c.nestingLevelLeft--
 	ret := c.recurseFunc(c, subLookupIndex)
@@ -873,7 +873,7 @@ func (c *otApplyContext) applyLookup(count int, matchPositions *[maxContextLengt
 		origLen := buffer.backtrackLen() + buffer.lookaheadLen()
 
 		if debugMode >= 2 {
-			fmt.Printf("\t\tAPPLY nested lookup %d\n", lk.LookupIndex)
+			buffer.debugf("\t\tAPPLY nested lookup %d\n", lk.LookupIndex)
 		}
 
 		if !c.recurse(lk.LookupIndex) {
@@ -0,0 +1,376 @@
+package harfbuzz
+
+import (
+	"strings"
+	"testing"
+
+	tt "github.com/benoitkugler/textlayout/fonts/truetype"
+	"github.com/benoitkugler/textlayout/language"
+)
+
+// recursiveGSUBFace replaces the GSUB table by a single 'ccmp' feature,
+// made of two contextual lookups calling each other `fanout` times on the same glyph.
+type recursiveGSUBFace struct {
+	*tt.Font
+	glyph  tt.GID
+	fanout int
+}
+
+func (f recursiveGSUBFace) LayoutTables() tt.LayoutTables {
+	lt := f.Font.LayoutTables()
+
+	cov := tt.CoverageList{f.glyph}
+	contextLookup := func(other uint16) tt.LookupGSUB {
+		context := tt.LookupContext3{Coverages: []tt.Coverage{cov}}
+		for i := 0; i < f.fanout; i++ {
+			context.SequenceLookups = append(context.SequenceLookups, tt.SequenceLookup{InputIndex: 0, LookupIndex: other})
+		}
+		return tt.LookupGSUB{
+			Type:      tt.GSUBContext,
+			Subtables: []tt.GSUBSubtable{{Coverage: cov, Data: tt.GSUBContext3(context)}},
+		}
+	}
+
+	lt.GSUB = tt.TableGSUB{
+		Lookups: []tt.LookupGSUB{contextLookup(1), contextLookup(0)},
+		TableLayout: tt.TableLayout{
+			Scripts: []tt.Script{{
+				Tag:             tt.NewTag('D', 'F', 'L', 'T'),
+				DefaultLanguage: &tt.LangSys{Features: []uint16{0}, RequiredFeatureIndex: 0xFFFF},
+			}},
+			Features: []tt.FeatureRecord{{
+				Tag:     tt.NewTag('c', 'c', 'm', 'p'),
+				Feature: tt.Feature{LookupIndices: []uint16{0}},
+			}},
+		},
+	}
+	return lt
+}
+
+func TestRecurseNestingLimit(t *testing.T) {
+	font := NewFont(openFontFileTT("DejaVuSerif.ttf"))
+	buffer := NewBuffer()
+	buffer.AddRunes([]rune("a"), 0, -1)
+
+	c := newOtApplyContext(0, font, buffer)
+	var depth, maxDepth, calls int
+	c.recurseFunc = func(c *otApplyContext, lookupIndex uint16) bool {
+		calls++
+		depth++
+		if depth > maxDepth {
+			maxDepth = depth
+		}
+		c.recurse(lookupIndex) // infinite recursion, without limit
+		depth--
+		return true
+	}
+	c.recurse(0)
+	assertEqualInt(t, maxNestingLevel, maxDepth)
+	assertEqualInt(t, maxNestingLevel, calls)
+
+	// the operations budget also stops the recursion
+	buffer.maxOps = 3
+	calls = 0
+	c.recurse(0)
+	assertEqualInt(t, 3, calls)
+	assert(t, buffer.maxOps < 0)
+}
+
+func TestRecursiveLookup(t *testing.T) {
+	ft := openFontFileTT("DejaVuSerif.ttf")
+	glyph, ok := ft.NominalGlyph('a')
+	assert(t, ok)
+
+	for _, fanout := range []int{1, 4, 16} {
+		font := NewFont(recursiveGSUBFace{Font: ft, glyph: glyph, fanout: fanout})
+
+		buffer := NewBuffer()
+		buffer.AddRunes([]rune("aaaaaaaaaa"), 0, -1)
+		buffer.GuessSegmentProperties()
+		buffer.Shape(font, nil) // must terminate
+
+		assertEqualInt(t, 10, len(buffer.Info))
+		for _, info := range buffer.Info {
+			assertEqualInt(t, int(glyph), int(info.Glyph))
+		}
+	}
+}
+
+func TestOperationsBudget(t *testing.T) {
+	ft := openFontFileTT("DejaVuSerif.ttf")
+	glyph, ok := ft.NominalGlyph('a')
+	assert(t, ok)
+
+	shape := func(fanout, maxOps int) *Buffer {
+		font := NewFont(recursiveGSUBFace{Font: ft, glyph: glyph, fanout: fanout})
+		buffer := NewBuffer()
+		buffer.MaxOps = maxOps
+		buffer.AddRunes([]rune(strings.Repeat("a", 200)), 0, -1)
+		buffer.GuessSegmentProperties()
+		buffer.Shape(font, nil)
+		assertEqualInt(t, 200, len(buffer.Info)) // partially shaped, but complete
+		return buffer
+	}
+
+	assert(t, !shape(1, 0).BudgetExceeded())
+	assert(t, shape(1, 100).BudgetExceeded())
+	assert(t, shape(16, 0).BudgetExceeded())
+
+	// the flag is reset by the next call
+	buffer := shape(16, 0)
+	buffer.Clear()
+	assert(t, !buffer.BudgetExceeded())
+}
+
+// deviceGPOSFace replaces the GPOS table by a single 'kern' feature,
+// adjusting the advance and the placement of `glyph` with device tables.
+type deviceGPOSFace struct {
+	*tt.Font
+	glyph tt.GID
+}
+
+func (f deviceGPOSFace) LayoutTables() tt.LayoutTables {
+	lt := f.Font.LayoutTables()
+
+	cov := tt.CoverageList{f.glyph}
+	device := tt.DeviceHinting{StartSize: 10, EndSize: 12, Values: []int8{1, 2, -3}}
+	lt.GPOS = tt.TableGPOS{
+		Lookups: []tt.LookupGPOS{{
+			Type: tt.GPOSSingle,
+			Subtables: []tt.GPOSSubtable{{Coverage: cov, Data: tt.GPOSSingle1{
+				Format: tt.XAdvance | tt.XAdvDevice | tt.YPlaDevice,
+				Value:  tt.GPOSValueRecord{XAdvance: 100, XAdvDevice: device, YPlaDevice: device},
+			}}},
+		}},
+		TableLayout: tt.TableLayout{
+			Scripts: []tt.Script{{
+				Tag:             tt.NewTag('D', 'F', 'L', 'T'),
+				DefaultLanguage: &tt.LangSys{Features: []uint16{0}, RequiredFeatureIndex: 0xFFFF},
+			}},
+			Features: []tt.FeatureRecord{{
+				Tag:     tt.NewTag('k', 'e', 'r', 'n'),
+				Feature: tt.Feature{LookupIndices: []uint16{0}},
+			}},
+		},
+	}
+	return lt
+}
+
+func TestGPOSValueRecordDevice(t *testing.T) {
+	ft := openFontFileTT("DejaVuSerif.ttf")
+	glyph, ok := ft.NominalGlyph('a')
+	assert(t, ok)
+
+	// device tables are ignored when ppem is zero
+	shape := func(ppem uint16, useDevice bool) GlyphPosition {
+		font := NewFont(deviceGPOSFace{Font: ft, glyph: glyph})
+		if useDevice {
+			font.XPpem, font.YPpem = ppem, ppem
+		}
+		// 64 units per pixel
+		font.XScale, font.YScale = 64*int32(ppem), 64*int32(ppem)
+		buffer := NewBuffer()
+		buffer.AddRunes([]rune("a"), 0, -1)
+		buffer.GuessSegmentProperties()
+		buffer.Shape(font, nil)
+		assertEqualInt(t, 1, len(buffer.Pos))
+		return buffer.Pos[0]
+	}
+
+	for _, test := range []struct {
+		ppem   uint16
+		pixels int32 // adjustment from the device table
+	}{
+		{9, 0},
+		{10, 1},
+		{11, 2},
+		{12, -3},
+		{13, 0},
+	} {
+		base, pos := shape(test.ppem, false), shape(test.ppem, true)
+		assertEqualInt32(t, pos.XAdvance, base.XAdvance+64*test.pixels)
+		assertEqualInt32(t, pos.YOffset, base.YOffset+64*test.pixels)
+	}
+}
+
+func TestGPOSValueRecordVariation(t *testing.T) {
+	// the kerning between glyphs 2 and 3 is -22 at the default
+	// weight (200), and varies with the weight
+	ft := openFontFile("fonts/SourceSansVariable-Roman.modcomp.ttf")
+
+	kerning := func(weight float32) Position {
+		font := NewFont(ft)
+		font.SetVarCoordsDesign([]float32{weight})
+		buffer := NewBuffer()
+		buffer.AddRunes([]rune{0xC7, 0x106}, 0, -1)
+		buffer.GuessSegmentProperties()
+		buffer.Shape(font, nil)
+		assertEqualInt(t, 2, len(buffer.Pos))
+		return buffer.Pos[0].XAdvance - font.GlyphHAdvance(buffer.Info[0].Glyph)
+	}
+
+	k200, k400, k900 := kerning(200), kerning(400), kerning(900)
+	assertEqualInt32(t, k200, -22)
+	assert(t, k900 < k400 && k400 < k200)
+}
+
+func TestGPOSAnchorVariation(t *testing.T) {
+	// the anchors of the base and the mark use variation
+	// device tables (anchor format 3)
+	ft := openFontFileTT("SelawikVar.ttf")
+
+	markPosition := func(weight float32) Position {
+		font := NewFont(ft)
+		font.SetVarCoordsDesign([]float32{weight})
+		buffer := NewBuffer()
+		buffer.AddRunes([]rune("x\u0301"), 0, -1)
+		buffer.GuessSegmentProperties()
+		buffer.Shape(font, nil)
+		assertEqualInt(t, 2, len(buffer.Pos))
+		// relative to the origin of the base
+		return buffer.Pos[0].XAdvance + buffer.Pos[1].XOffset
+	}
+
+	light, regular, bold := markPosition(300), markPosition(400), markPosition(700)
+	assert(t, light != regular && regular != bold && light != bold)
+}
+
+// requiredGSUBFace replaces the GSUB table by a single 'ss01' feature,
+// substituting `from` by `to`, which is only referenced as the required
+// feature of the language system `langSys`.
+type requiredGSUBFace struct {
+	*tt.Font
+	from, to tt.GID
+	langSys  tt.LangSys
+}
+
+func (f *requiredGSUBFace) LayoutTables() tt.LayoutTables {
+	lt := f.Font.LayoutTables()
+
+	cov := tt.CoverageList{f.from}
+	lt.GSUB = tt.TableGSUB{
+		Lookups: []tt.LookupGSUB{{
+			Type:      tt.GSUBSingle,
+			Subtables: []tt.GSUBSubtable{{Coverage: cov, Data: tt.GSUBSingle1(int16(f.to) - int16(f.from))}},
+		}},
+		TableLayout: tt.TableLayout{
+			Scripts: []tt.Script{{
+				Tag:             tt.NewTag('l', 'a', 't', 'n'),
+				DefaultLanguage: &tt.LangSys{RequiredFeatureIndex: 0xFFFF},
+				Languages:       []tt.LangSys{f.langSys},
+			}},
+			Features: []tt.FeatureRecord{{
+				Tag:     tt.NewTag('s', 's', '0', '1'),
+				Feature: tt.Feature{LookupIndices: []uint16{0}},
+			}},
+		},
+	}
+	if f.langSys.Tag == tagDefaultLanguage {
+		lt.GSUB.Scripts[0].DefaultLanguage = &f.langSys
+		lt.GSUB.Scripts[0].Languages = nil
+	}
+	return lt
+}
+
+func TestRequiredFeature(t *testing.T) {
+	ft := openFontFileTT("DejaVuSerif.ttf")
+	from, _ := ft.NominalGlyph('a')
+	to, _ := ft.NominalGlyph('b')
+
+	shape := func(langSys tt.LangSys, lang language.Language) tt.GID {
+		font := NewFont(&requiredGSUBFace{Font: ft, from: from, to: to, langSys: langSys})
+		buffer := NewBuffer()
+		buffer.AddRunes([]rune{'a'}, 0, -1)
+		buffer.Props.Language = lang
+		buffer.GuessSegmentProperties()
+		buffer.Shape(font, nil)
+		return buffer.Info[0].Glyph
+	}
+
+	for _, test := range []struct {
+		langSys  tt.LangSys
+		lang     language.Language
+		expected tt.GID
+	}{
+		// required feature of the default language system
+		{tt.LangSys{Tag: tagDefaultLanguage, RequiredFeatureIndex: 0}, "en", to},
+		// required feature of a specific language system
+		{tt.LangSys{Tag: tt.NewTag('T', 'R', 'K', ' '), RequiredFeatureIndex: 0}, "tr", to},
+		{tt.LangSys{Tag: tt.NewTag('T', 'R', 'K', ' '), RequiredFeatureIndex: 0}, "en", from},
+		// no required feature
+		{tt.LangSys{Tag: tagDefaultLanguage, RequiredFeatureIndex: 0xFFFF}, "en", from},
+	} {
+		if got := shape(test.langSys, test.lang); got != test.expected {
+			t.Errorf("for %v (language %s), expected %d, got %d", test.langSys, test.lang, test.expected, got)
+		}
+	}
+}
+
+// gsubFace replaces the GSUB table of a font.
+type gsubFace struct {
+	*tt.Font
+	gsub tt.TableGSUB
+}
+
+func (f *gsubFace) LayoutTables() tt.LayoutTables {
+	lt := f.Font.LayoutTables()
+	lt.GSUB = f.gsub
+	return lt
+}
+
+func singleSubstLookup(from, to tt.GID) tt.LookupGSUB {
+	return tt.LookupGSUB{
+		Type: tt.GSUBSingle,
+		Subtables: []tt.GSUBSubtable{{
+			Coverage: tt.CoverageList{from},
+			Data:     tt.GSUBSingle1(int16(to) - int16(from)),
+		}},
+	}
+}
+
+func TestLoclBeforeCcmp(t *testing.T) {
+	ft := openFontFileTT("DejaVuSerif.ttf")
+	a, _ := ft.NominalGlyph('a')
+	b, _ := ft.NominalGlyph('b')
+	c, _ := ft.NominalGlyph('c')
+
+	// 'locl' maps a to b, and 'ccmp' b to c, so that the result
+	// depends on the order in which the two features are applied
+	shape := func(loclFirst bool) tt.GID {
+		locl, ccmp := singleSubstLookup(a, b), singleSubstLookup(b, c)
+		loclIndex, ccmpIndex := uint16(0), uint16(1)
+		lookups := []tt.LookupGSUB{locl, ccmp}
+		if !loclFirst {
+			loclIndex, ccmpIndex = 1, 0
+			lookups = []tt.LookupGSUB{ccmp, locl}
+		}
+		gsub := tt.TableGSUB{
+			Lookups: lookups,
+			TableLayout: tt.TableLayout{
+				Scripts: []tt.Script{{
+					Tag:             tt.NewTag('l', 'a', 't', 'n'),
+					DefaultLanguage: &tt.LangSys{Features: []uint16{0, 1}, RequiredFeatureIndex: 0xFFFF},
+				}},
+				Features: []tt.FeatureRecord{
+					{Tag: tt.NewTag('c', 'c', 'm', 'p'), Feature: tt.Feature{LookupIndices: []uint16{ccmpIndex}}},
+					{Tag: tt.NewTag('l', 'o', 'c', 'l'), Feature: tt.Feature{LookupIndices: []uint16{loclIndex}}},
+				},
+			},
+		}
+		font := NewFont(&gsubFace{Font: ft, gsub: gsub})
+		buffer := NewBuffer()
+		buffer.AddRunes([]rune{'a'}, 0, -1)
+		buffer.GuessSegmentProperties()
+		buffer.Shape(font, nil)
+		return buffer.Info[0].Glyph
+	}
+
+	// both features are applied in the same stage, so that the
+	// lookups are applied in the order defined by the font
+	if got := shape(true); got != c {
+		t.Errorf("expected %d, got %d", c, got)
+	}
+	if got := shape(false); got != b {
+		t.Errorf("expected %d, got %d", b, got)
+	}
+}
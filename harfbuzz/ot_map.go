@@ -57,15 +57,16 @@ type stageInfo struct {
 }
 
 type otMapBuilder struct {
-	tables        *tt.LayoutTables
-	props         SegmentProperties
-	stages        [2][]stageInfo
-	featureInfos  []featureInfo
-	scriptIndex   [2]int
-	languageIndex [2]int
-	currentStage  [2]int
-	chosenScript  [2]tt.Tag
-	foundScript   [2]bool
+	tables         *tt.LayoutTables
+	props          SegmentProperties
+	stages         [2][]stageInfo
+	featureInfos   []featureInfo
+	scriptIndex    [2]int
+	languageIndex  [2]int
+	currentStage   [2]int
+	chosenScript   [2]tt.Tag
+	chosenLanguage [2]tt.Tag
+	foundScript    [2]bool
 }
 
 //  void hb_ot_map_t::collect_lookups (uint tableIndex, hb_set_t *lookups_out) const
@@ -86,9 +87,11 @@ func newOtMapBuilder(tables *tt.LayoutTables, props SegmentProperties) otMapBuil
 
 	out.scriptIndex[0], out.chosenScript[0], out.foundScript[0] = SelectScript(&tables.GSUB.TableLayout, scriptTags)
 	out.languageIndex[0], _ = SelectLanguage(&tables.GSUB.TableLayout, out.scriptIndex[0], languageTags)
+	out.chosenLanguage[0] = languageTag(&tables.GSUB.TableLayout, out.scriptIndex[0], out.languageIndex[0])
 
 	out.scriptIndex[1], out.chosenScript[1], out.foundScript[1] = SelectScript(&tables.GPOS.TableLayout, scriptTags)
 	out.languageIndex[1], _ = SelectLanguage(&tables.GPOS.TableLayout, out.scriptIndex[1], languageTags)
+	out.chosenLanguage[1] = languageTag(&tables.GPOS.TableLayout, out.scriptIndex[1], out.languageIndex[1])
 
 	return out
 }
@@ -146,6 +149,7 @@ func (mb *otMapBuilder) compile(m *otMap, key otShapePlanKey) {
 	tables := [2]*tt.TableLayout{&gsub.TableLayout, &gpos.TableLayout}
 
 	m.chosenScript = mb.chosenScript
+	m.chosenLanguage = mb.chosenLanguage
 	m.foundScript = mb.foundScript
 	requiredFeatureIndex[0], requiredFeatureTag[0] = getRequiredFeature(tables[0], mb.scriptIndex[0], mb.languageIndex[0])
 	requiredFeatureIndex[1], requiredFeatureTag[1] = getRequiredFeature(tables[1], mb.scriptIndex[1], mb.languageIndex[1])
@@ -363,12 +367,13 @@ type stageMap struct {
 }
 
 type otMap struct {
-	lookups      [2][]lookupMap
-	stages       [2][]stageMap
-	features     []featureMap // sorted
-	chosenScript [2]tt.Tag
-	globalMask   GlyphMask
-	foundScript  [2]bool
+	lookups        [2][]lookupMap
+	stages         [2][]stageMap
+	features       []featureMap // sorted
+	chosenScript   [2]tt.Tag
+	chosenLanguage [2]tt.Tag
+	globalMask     GlyphMask
+	foundScript    [2]bool
 }
 
 //   friend struct hb_ot_map_builder_t;
@@ -439,29 +444,41 @@ func (m *otMap) addLookups(table *tt.TableLayout, tableIndex int, featureIndex u
 
 // apply the GSUB table
 func (m *otMap) substitute(plan *otShapePlan, font *Font, buffer *Buffer) {
-	if debugMode >= 1 {
-		fmt.Println("SUBSTITUTE - start table GSUB")
+	if buffer.debugging() {
+		buffer.debugln("SUBSTITUTE - start table GSUB")
 	}
 
+	if buffer.messaging() {
+		buffer.message("start table GSUB")
+	}
 	proxy := otProxy{otProxyMeta: proxyGSUB, accels: font.gsubAccels}
 	m.apply(proxy, plan, font, buffer)
+	if buffer.messaging() {
+		buffer.message("end table GSUB")
+	}
 
-	if debugMode >= 1 {
-		fmt.Println("SUBSTITUTE - end table GSUB")
+	if buffer.debugging() {
+		buffer.debugln("SUBSTITUTE - end table GSUB")
 	}
 }
 
 // apply the GPOS table
 func (m *otMap) position(plan *otShapePlan, font *Font, buffer *Buffer) {
-	if debugMode >= 1 {
-		fmt.Println("POSITION - start table GPOS")
+	if buffer.debugging() {
+		buffer.debugln("POSITION - start table GPOS")
 	}
 
+	if buffer.messaging() {
+		buffer.message("start table GPOS")
+	}
 	proxy := otProxy{otProxyMeta: proxyGPOS, accels: font.gposAccels}
 	m.apply(proxy, plan, font, buffer)
+	if buffer.messaging() {
+		buffer.message("end table GPOS")
+	}
 
-	if debugMode >= 1 {
-		fmt.Println("POSITION - end table GPOS")
+	if buffer.debugging() {
+		buffer.debugln("POSITION - end table GPOS")
 	}
 }
 
@@ -474,14 +491,14 @@ func (m *otMap) apply(proxy otProxy, plan *otShapePlan, font *Font, buffer *Buff
 	for stageI, stage := range m.stages[tableIndex] {
 
 		if debugMode >= 2 {
-			fmt.Printf("\tAPPLY - stage %d\n", stageI)
+			buffer.debugf("\tAPPLY - stage %d\n", stageI)
 		}
 
 		for ; i < stage.lastLookup; i++ {
 			lookupIndex := m.lookups[tableIndex][i].index
 
-			if debugMode >= 1 {
-				fmt.Printf("\t\tLookup %d start\n", lookupIndex)
+			if buffer.debugging() {
+				buffer.debugf("\t\tLookup %d start\n", lookupIndex)
 			}
 
 			c.lookupIndex = lookupIndex
@@ -491,24 +508,37 @@ func (m *otMap) apply(proxy otProxy, plan *otShapePlan, font *Font, buffer *Buff
 			c.random = m.lookups[tableIndex][i].random
 
 			// pathological cases
-			if len(c.buffer.Info) > c.buffer.maxLen {
+			if len(c.buffer.Info) > c.buffer.maxLen || c.buffer.maxOps <= 0 {
+				c.buffer.budgetExceeded = true
 				return
 			}
+			if buffer.messaging() && !buffer.message("start lookup %d", lookupIndex) {
+				continue
+			}
 			c.applyString(proxy.otProxyMeta, &proxy.accels[lookupIndex])
+			if buffer.messaging() {
+				buffer.message("end lookup %d", lookupIndex)
+			}
 
-			if debugMode >= 1 {
-				fmt.Println("\t\tLookup end")
-				fmt.Println(c.buffer.Info)
+			if buffer.debugging() {
+				buffer.debugln("\t\tLookup end")
+				buffer.debugln(c.buffer.Info)
 			}
 
 		}
 
 		if stage.pauseFunc != nil {
-			if debugMode >= 1 {
-				fmt.Println("\t\tExecuting pause function")
+			if buffer.debugging() {
+				buffer.debugln("\t\tExecuting pause function")
 			}
 
+			if buffer.messaging() && !buffer.message("start pause %d", stageI) {
+				continue
+			}
 			stage.pauseFunc(plan, font, buffer)
+			if buffer.messaging() {
+				buffer.message("end pause %d", stageI)
+			}
 		}
 	}
 }
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	tt "github.com/benoitkugler/textlayout/fonts/truetype"
+	"github.com/benoitkugler/textlayout/language"
 )
 
 func TestOTFeature(t *testing.T) {
@@ -60,3 +61,76 @@ func TestOTFeature(t *testing.T) {
 	// g_assert_cmpuint (9, ==, text_size);
 	// g_assert_cmpstr (text, ==, "FontForge");
 }
+
+func TestDefaultFeaturesStages(t *testing.T) {
+	gsubTags := []tt.Tag{
+		tt.NewTag('c', 'a', 'l', 't'),
+		tt.NewTag('c', 'c', 'm', 'p'),
+		tt.NewTag('c', 'l', 'i', 'g'),
+		tt.NewTag('l', 'i', 'g', 'a'),
+		tt.NewTag('l', 'o', 'c', 'l'),
+		tt.NewTag('r', 'l', 'i', 'g'),
+		tt.NewTag('r', 'v', 'r', 'n'),
+	}
+	gposTags := []tt.Tag{
+		tt.NewTag('k', 'e', 'r', 'n'),
+		tt.NewTag('m', 'a', 'r', 'k'),
+		tt.NewTag('m', 'k', 'm', 'k'),
+	}
+
+	// register one (empty) lookup per feature
+	layout := func(tags []tt.Tag) tt.TableLayout {
+		out := tt.TableLayout{Scripts: []tt.Script{{
+			Tag:             tt.NewTag('D', 'F', 'L', 'T'),
+			DefaultLanguage: &tt.LangSys{RequiredFeatureIndex: 0xFFFF},
+		}}}
+		for i, tag := range tags {
+			out.Features = append(out.Features, tt.FeatureRecord{Tag: tag, Feature: tt.Feature{LookupIndices: []uint16{uint16(i)}}})
+			out.Scripts[0].DefaultLanguage.Features = append(out.Scripts[0].DefaultLanguage.Features, uint16(i))
+		}
+		return out
+	}
+	var tables tt.LayoutTables
+	tables.GSUB.TableLayout = layout(gsubTags)
+	tables.GSUB.Lookups = make([]tt.LookupGSUB, len(gsubTags))
+	tables.GPOS.TableLayout = layout(gposTags)
+	tables.GPOS.Lookups = make([]tt.LookupGPOS, len(gposTags))
+
+	sp := newShaperOpentype(&tables, nil)
+	sp.compile(SegmentProperties{Direction: LeftToRight, Script: language.Latin}, nil)
+	m := &sp.plan.map_
+
+	for _, tag := range gsubTags {
+		if m.getFeatureIndex(0, tag) == NoFeatureIndex {
+			t.Fatalf("feature %s not enabled", tag)
+		}
+	}
+	for _, tag := range gposTags {
+		if m.getFeatureIndex(1, tag) == NoFeatureIndex {
+			t.Fatalf("feature %s not enabled", tag)
+		}
+	}
+
+	// 'rvrn' is applied alone, before any other GSUB feature
+	rvrn := tt.NewTag('r', 'v', 'r', 'n')
+	if stage := m.getFeatureStage(0, rvrn); stage != 0 {
+		t.Errorf("expected stage 0 for %s, got %d", rvrn, stage)
+	}
+	// then, the default features are applied together, in lookup order
+	for _, tag := range gsubTags {
+		if tag == rvrn {
+			continue
+		}
+		if stage := m.getFeatureStage(0, tag); stage != 1 {
+			t.Errorf("expected stage 1 for %s, got %d", tag, stage)
+		}
+	}
+	for _, tag := range gposTags {
+		if stage := m.getFeatureStage(1, tag); stage != 0 {
+			t.Errorf("expected stage 0 for %s, got %d", tag, stage)
+		}
+	}
+	if lookups := m.getStageLookups(0, 1); len(lookups) != len(gsubTags)-1 {
+		t.Errorf("expected %d lookups, got %d", len(gsubTags)-1, len(lookups))
+	}
+}
@@ -1,10 +1,6 @@
 package harfbuzz
 
-import (
-	"fmt"
-
-	tt "github.com/benoitkugler/textlayout/fonts/truetype"
-)
+import tt "github.com/benoitkugler/textlayout/fonts/truetype"
 
 // ported from harfbuzz/src/hb-ot-shape-complex-myanmar.cc, .hh Copyright © 2011,2012,2013  Google, Inc.  Behdad Esfahbod
 
@@ -187,8 +183,8 @@ func reorderSyllableMyanmar(buffer *Buffer, start, end int) {
 }
 
 func reorderMyanmar(_ *otShapePlan, font *Font, buffer *Buffer) {
-	if debugMode >= 1 {
-		fmt.Println("MYANMAR - start reordering myanmar")
+	if buffer.debugging() {
+		buffer.debugln("MYANMAR - start reordering myanmar")
 	}
 
 	syllabicInsertDottedCircles(font, buffer, myanmarBrokenCluster, otGB, -1, -1)
@@ -198,8 +194,8 @@ func reorderMyanmar(_ *otShapePlan, font *Font, buffer *Buffer) {
 		reorderSyllableMyanmar(buffer, start, end)
 	}
 
-	if debugMode >= 1 {
-		fmt.Println("MYANMAR - end reordering myanmar")
+	if buffer.debugging() {
+		buffer.debugln("MYANMAR - end reordering myanmar")
 	}
 }
 
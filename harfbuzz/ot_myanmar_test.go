@@ -1,6 +1,10 @@
 package harfbuzz
 
-import "testing"
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
 
 func TestMyanmarProperties(t *testing.T) {
 	expecteds := map[rune][2]uint8{
@@ -18,3 +22,31 @@ func TestMyanmarProperties(t *testing.T) {
 		}
 	}
 }
+
+func TestMyanmarDebugLogger(t *testing.T) {
+	font := NewFont(openFontFile("harfbuzz_reference/in-house/fonts/af3086380b743099c54a3b11b96766039ea62fcd.ttf"))
+
+	var logs bytes.Buffer
+	buf := NewBuffer()
+	buf.SetDebugLogger(&logs)
+	buf.AddRunes([]rune{0x101D, 0x1031}, 0, -1)
+	buf.GuessSegmentProperties()
+	buf.Shape(font, nil)
+
+	out := logs.String()
+	for _, message := range []string{"MYANMAR - start reordering myanmar", "MYANMAR - end reordering myanmar"} {
+		if !strings.Contains(out, message) {
+			t.Fatalf("missing %q in debug logs:\n%s", message, out)
+		}
+	}
+
+	// logging is disabled by default, and only concerns the buffer
+	logs.Reset()
+	other := NewBuffer()
+	other.AddRunes([]rune{0x101D, 0x1031}, 0, -1)
+	other.GuessSegmentProperties()
+	other.Shape(font, nil)
+	if logs.Len() != 0 {
+		t.Fatalf("unexpected logs %s", logs.String())
+	}
+}
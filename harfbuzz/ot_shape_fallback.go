@@ -1,7 +1,5 @@
 package harfbuzz
 
-import "fmt"
-
 // ported from harfbuzz/src/hb-ot-shape-fallback.cc Copyright © 2011,2012 Google, Inc. Behdad Esfahbod
 
 const (
@@ -351,8 +349,8 @@ func fallbackMarkPosition(plan *otShapePlan, font *Font, buffer *Buffer,
 
 // adjusts width of various spaces.
 func fallbackSpaces(font *Font, buffer *Buffer) {
-	if debugMode >= 1 {
-		fmt.Println("POSITION - applying fallback spaces")
+	if buffer.debugging() {
+		buffer.debugln("POSITION - applying fallback spaces")
 	}
 	info := buffer.Info
 	pos := buffer.Pos
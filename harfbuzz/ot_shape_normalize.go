@@ -1,10 +1,6 @@
 package harfbuzz
 
-import (
-	"fmt"
-
-	"github.com/benoitkugler/textlayout/fonts"
-)
+import "github.com/benoitkugler/textlayout/fonts"
 
 // ported from harfbuzz/src/hb-ot-shape-normalize.cc Copyright © 2011,2012  Google, Inc. Behdad Esfahbod
 
@@ -63,6 +59,38 @@ const (
 	nmDefault = nmAuto
 )
 
+// NormalizationMode selects how the text is normalized, before
+// applying the font lookups.
+type NormalizationMode uint8
+
+const (
+	// Use the mode preferred by the shaper, which depends on the script.
+	NormalizationDefault NormalizationMode = iota
+	// Only decompose characters not supported by the font.
+	NormalizationNone
+	// Decompose all characters.
+	NormalizationDecomposed
+	// Compose marks with their base, when supported by the font.
+	NormalizationComposedDiacritics
+	// Same as NormalizationComposedDiacritics, but always decompose first.
+	NormalizationComposedDiacriticsNoShortCircuit
+)
+
+func (m NormalizationMode) mode() normalizationMode {
+	switch m {
+	case NormalizationNone:
+		return nmNone
+	case NormalizationDecomposed:
+		return nmDecomposed
+	case NormalizationComposedDiacritics:
+		return nmComposedDiacritics
+	case NormalizationComposedDiacriticsNoShortCircuit:
+		return nmComposedDiacriticsNoShortCircuit
+	default:
+		return nmDefault
+	}
+}
+
 type otNormalizeContext struct {
 	plan   *otShapePlan
 	buffer *Buffer
@@ -171,19 +199,21 @@ func (c *otNormalizeContext) decomposeCurrentCharacter(shortest bool) {
 		}
 	}
 
+	// default ignorables are handled by hideDefaultIgnorables
+	buffer.cur(0).notFound = !buffer.cur(0).isDefaultIgnorable()
 	nextChar(buffer, glyph)
 }
 
 func (c *otNormalizeContext) handleVariationSelectorCluster(end int) {
 	buffer := c.buffer
-	if debugMode >= 1 {
-		fmt.Printf("NORMALIZE - variation selector cluster at index %d\n", buffer.idx)
+	if buffer.debugging() {
+		buffer.debugf("NORMALIZE - variation selector cluster at index %d\n", buffer.idx)
 	}
 	font := c.font
 	for buffer.idx < end-1 {
 		if uni.isVariationSelector(buffer.cur(+1).codepoint) {
 			var ok bool
-			buffer.cur(0).Glyph, ok = font.face.(FaceOpentype).VariationGlyph(buffer.cur(0).codepoint, buffer.cur(+1).codepoint)
+			buffer.cur(0).Glyph, ok = font.VariationGlyph(buffer.cur(0).codepoint, buffer.cur(+1).codepoint)
 			if ok {
 				r := buffer.cur(0).codepoint
 				buffer.replaceGlyphs(2, []rune{r}, nil)
@@ -212,8 +242,8 @@ func (c *otNormalizeContext) handleVariationSelectorCluster(end int) {
 
 func (c *otNormalizeContext) decomposeMultiCharCluster(end int, shortCircuit bool) {
 	buffer := c.buffer
-	if debugMode >= 1 {
-		fmt.Printf("NORMALIZE - decompose multi char cluster at index %d\n", buffer.idx)
+	if buffer.debugging() {
+		buffer.debugf("NORMALIZE - decompose multi char cluster at index %d\n", buffer.idx)
 	}
 
 	for i := buffer.idx; i < end; i++ {
@@ -244,6 +274,9 @@ func otShapeNormalize(plan *otShapePlan, buffer *Buffer, font *Font) {
 	}
 
 	mode := plan.shaper.normalizationPreference()
+	if buffer.Normalization != NormalizationDefault {
+		mode = buffer.Normalization.mode()
+	}
 	if mode == nmAuto {
 		if plan.hasGposMark {
 			// https://github.com/harfbuzz/harfbuzz/issues/653#issuecomment-423905920
@@ -327,8 +360,8 @@ func otShapeNormalize(plan *otShapePlan, buffer *Buffer, font *Font) {
 	/* Second round, reorder (inplace) */
 
 	if !allSimple {
-		if debugMode >= 1 {
-			fmt.Println("NORMALIZE - start reorder")
+		if buffer.debugging() {
+			buffer.debugln("NORMALIZE - start reorder")
 		}
 		count = len(buffer.Info)
 		for i := 0; i < count; i++ {
@@ -355,8 +388,8 @@ func otShapeNormalize(plan *otShapePlan, buffer *Buffer, font *Font) {
 
 			i = end
 		}
-		if debugMode >= 1 {
-			fmt.Println("NORMALIZE - end reorder")
+		if buffer.debugging() {
+			buffer.debugln("NORMALIZE - end reorder")
 		}
 	}
 
@@ -378,8 +411,8 @@ func otShapeNormalize(plan *otShapePlan, buffer *Buffer, font *Font) {
 		(mode == nmComposedDiacritics ||
 			mode == nmComposedDiacriticsNoShortCircuit) {
 
-		if debugMode >= 1 {
-			fmt.Println("NORMALIZE - recompose")
+		if buffer.debugging() {
+			buffer.debugln("NORMALIZE - recompose")
 		}
 
 		/* As noted in the comment earlier, we don't try to combine
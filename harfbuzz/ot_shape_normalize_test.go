@@ -0,0 +1,77 @@
+package harfbuzz
+
+import (
+	"testing"
+
+	tt "github.com/benoitkugler/textlayout/fonts/truetype"
+)
+
+func shapeNormalize(face *tt.Font, text []rune) *Buffer {
+	buf := NewBuffer()
+	buf.AddRunes(text, 0, len(text))
+	buf.GuessSegmentProperties()
+	buf.Shape(NewFont(face), nil)
+	return buf
+}
+
+func TestNormalizeDecompose(t *testing.T) {
+	// Castoro has no glyph for U+01F0 (j with caron), but has j and the combining caron
+	face := openFontFileTT("Castoro-Regular.ttf")
+	if _, ok := face.NominalGlyph(0x01F0); ok {
+		t.Fatal("unexpected precomposed glyph")
+	}
+	j, _ := face.NominalGlyph('j')
+	caron, _ := face.NominalGlyph(0x030C)
+
+	buf := shapeNormalize(face, []rune{0x01F0})
+	if len(buf.Info) != 2 || buf.Info[0].Glyph != j || buf.Info[1].Glyph != caron {
+		t.Fatalf("expected decomposed glyphs %d %d, got %v", j, caron, buf.Info)
+	}
+	if buf.Info[0].Cluster != 0 || buf.Info[1].Cluster != 0 {
+		t.Fatal("expected one cluster")
+	}
+	// the mark is positioned by the font
+	if buf.Pos[1].XAdvance != 0 {
+		t.Fatalf("expected a zero advance mark, got %d", buf.Pos[1].XAdvance)
+	}
+}
+
+func TestNormalizeCompose(t *testing.T) {
+	// the decomposed sequence is composed if the font supports it
+	face := openFontFileTT("DejaVuSerif.ttf")
+	composed, ok := face.NominalGlyph(0x01F0)
+	if !ok {
+		t.Fatal("missing precomposed glyph")
+	}
+	buf := shapeNormalize(face, []rune{'j', 0x030C})
+	if len(buf.Info) != 1 || buf.Info[0].Glyph != composed {
+		t.Fatalf("expected composed glyph %d, got %v", composed, buf.Info)
+	}
+}
+
+func TestNormalizationMode(t *testing.T) {
+	face := openFontFileTT("DejaVuSerif.ttf")
+	e, _ := face.NominalGlyph('e')
+	acute, _ := face.NominalGlyph(0x0301)
+	eAcute, _ := face.NominalGlyph('é')
+
+	shape := func(mode NormalizationMode) *Buffer {
+		buf := NewBuffer()
+		buf.AddRunes([]rune("é"), 0, -1)
+		buf.GuessSegmentProperties()
+		buf.Normalization = mode
+		buf.Shape(NewFont(face), nil)
+		return buf
+	}
+
+	if buf := shape(NormalizationDefault); len(buf.Info) != 1 || buf.Info[0].Glyph != eAcute {
+		t.Fatalf("expected precomposed glyph %d, got %v", eAcute, buf.Info)
+	}
+	buf := shape(NormalizationDecomposed)
+	if len(buf.Info) != 2 || buf.Info[0].Glyph != e || buf.Info[1].Glyph != acute {
+		t.Fatalf("expected decomposed glyphs %d %d, got %v", e, acute, buf.Info)
+	}
+	if buf.Info[0].Cluster != 0 || buf.Info[1].Cluster != 0 {
+		t.Fatal("expected one cluster")
+	}
+}
@@ -1,8 +1,6 @@
 package harfbuzz
 
 import (
-	"fmt"
-
 	"github.com/benoitkugler/textlayout/fonts"
 	tt "github.com/benoitkugler/textlayout/fonts/truetype"
 )
@@ -59,6 +57,46 @@ func newOtShapePlanner(tables *tt.LayoutTables, props SegmentProperties) *otShap
 	return &out
 }
 
+var (
+	tagVert = tt.NewTag('v', 'e', 'r', 't')
+	tagVrt2 = tt.NewTag('v', 'r', 't', '2')
+)
+
+// verticalFeature returns 'vrt2' if the font has it, or 'vert'
+func (planner *otShapePlanner) verticalFeature() tt.Tag {
+	if _, ok := planner.tables.GSUB.FindFeatureIndex(tagVrt2); ok {
+		return tagVrt2
+	}
+	return tagVert
+}
+
+// verticalUserFeatures returns `features`, where each user feature disabling 'vert'
+// is followed by the same feature for 'vrt2', since 'vrt2' replaces 'vert' when
+// the font has it : this way, 'vert=0' still disables vertical forms.
+func verticalUserFeatures(dir Direction, features []Feature) []Feature {
+	if !dir.isVertical() {
+		return features
+	}
+	var out []Feature // lazily allocated
+	for i, f := range features {
+		disableVert := f.Tag == tagVert && f.Value == 0
+		if disableVert && out == nil {
+			out = append(make([]Feature, 0, len(features)+1), features[:i]...)
+		}
+		if out != nil {
+			out = append(out, f)
+		}
+		if disableVert {
+			f.Tag = tagVrt2
+			out = append(out, f)
+		}
+	}
+	if out == nil {
+		return features
+	}
+	return out
+}
+
 func (planner *otShapePlanner) compile(plan *otShapePlan, key otShapePlanKey) {
 	plan.props = planner.props
 	plan.shaper = planner.shaper
@@ -73,7 +111,8 @@ func (planner *otShapePlanner) compile(plan *otShapePlan, key otShapePlanKey) {
 	plan.hasFrac = plan.fracMask != 0 || (plan.numrMask != 0 && plan.dnomMask != 0)
 
 	plan.rtlmMask = plan.map_.getMask1(tt.NewTag('r', 't', 'l', 'm'))
-	plan.hasVert = plan.map_.getMask1(tt.NewTag('v', 'e', 'r', 't')) != 0
+	plan.hasVert = plan.map_.getMask1(tt.NewTag('v', 'e', 'r', 't')) != 0 ||
+		plan.map_.getMask1(tt.NewTag('v', 'r', 't', '2')) != 0
 
 	kernTag := tt.NewTag('v', 'k', 'r', 'n')
 	if planner.props.Direction.isHorizontal() {
@@ -230,6 +269,7 @@ var (
 
 func (planner *otShapePlanner) collectFeatures(userFeatures []Feature) {
 	map_ := &planner.map_
+	userFeatures = verticalUserFeatures(planner.props.Direction, userFeatures)
 
 	map_.enableFeature(tt.NewTag('r', 'v', 'r', 'n'))
 	map_.addGSUBPause(nil)
@@ -277,7 +317,9 @@ func (planner *otShapePlanner) collectFeatures(userFeatures []Feature) {
 		 * matter which script/langsys it is listed (or not) under.
 		 * See various bugs referenced from:
 		 * https://github.com/harfbuzz/harfbuzz/issues/63 */
-		map_.enableFeatureExt(tt.NewTag('v', 'e', 'r', 't'), ffGlobalSearch, 1)
+		// 'vrt2' is designed to replace 'vert' (it includes its substitutions),
+		// so that it is preferred when the font has it.
+		map_.enableFeatureExt(planner.verticalFeature(), ffGlobalSearch, 1)
 	}
 
 	for _, f := range userFeatures {
@@ -485,7 +527,7 @@ func (c *otContext) setupMasks() {
 
 	c.plan.shaper.setupMasks(c.plan, buffer, c.font)
 
-	for _, feature := range c.userFeatures {
+	for _, feature := range verticalUserFeatures(c.plan.props.Direction, c.userFeatures) {
 		if !(feature.Start == FeatureGlobalStart && feature.End == FeatureGlobalEnd) {
 			mask, shift := map_.getMask(feature.Tag)
 			buffer.setMasks(feature.Value<<shift, mask, feature.Start, feature.End)
@@ -516,11 +558,9 @@ func hideDefaultIgnorables(buffer *Buffer, font *Font) {
 
 	info := buffer.Info
 
-	var (
-		invisible = buffer.Invisible
-		ok        bool
-	)
-	if invisible == 0 {
+	invisible := buffer.Invisible
+	ok := invisible != 0
+	if !ok {
 		invisible, ok = font.face.NominalGlyph(' ')
 	}
 	if buffer.Flags&RemoveDefaultIgnorables == 0 && ok {
@@ -589,12 +629,12 @@ func (c *otContext) substituteAfterPosition() {
 		aatLayoutRemoveDeletedGlyphsInplace(c.buffer)
 	}
 
-	if debugMode >= 1 {
-		fmt.Printf("POSTPROCESS glyphs start (%T)\n", c.plan.shaper)
+	if c.buffer.debugging() {
+		c.buffer.debugf("POSTPROCESS glyphs start (%T)\n", c.plan.shaper)
 	}
 	c.plan.shaper.postprocessGlyphs(c.plan, c.buffer, c.font)
-	if debugMode >= 1 {
-		fmt.Println("POSTPROCESS glyphs end ")
+	if c.buffer.debugging() {
+		c.buffer.debugln("POSTPROCESS glyphs end ")
 	}
 }
 
@@ -698,7 +738,7 @@ func (c *otContext) position() {
 	c.positionDefault()
 
 	if debugMode >= 2 {
-		fmt.Println("AFTER DEFAULT POSITION", c.buffer.Pos)
+		c.buffer.debugln("AFTER DEFAULT POSITION", c.buffer.Pos)
 	}
 
 	c.positionComplex()
@@ -772,6 +812,9 @@ func (sp *shaperOpentype) shape(font *Font, buffer *Buffer, features []Feature)
 	const maxOpsFactor = 1024
 	const maxOpsMin = 16384
 	c.buffer.maxOps = max(len(c.buffer.Info)*maxOpsFactor, maxOpsMin)
+	if c.buffer.MaxOps > 0 {
+		c.buffer.maxOps = c.buffer.MaxOps
+	}
 	c.buffer.maxLen = max(len(c.buffer.Info)*maxLenFactor, maxLenMin)
 
 	// save the original direction, we use it later.
@@ -783,30 +826,30 @@ func (sp *shaperOpentype) shape(font *Font, buffer *Buffer, features []Feature)
 
 	c.buffer.formClusters()
 
-	if debugMode >= 1 {
-		fmt.Println("FORMING CLUSTER :", c.buffer.Info)
+	if buffer.debugging() {
+		buffer.debugln("FORMING CLUSTER :", c.buffer.Info)
 	}
 
 	c.buffer.ensureNativeDirection()
 
-	if debugMode >= 1 {
-		fmt.Printf("PREPROCESS text start (complex shaper %T)\n", c.plan.shaper)
+	if buffer.debugging() {
+		buffer.debugf("PREPROCESS text start (complex shaper %T)\n", c.plan.shaper)
 	}
 	c.plan.shaper.preprocessText(c.plan, c.buffer, c.font)
-	if debugMode >= 1 {
-		fmt.Println("PREPROCESS text end:", c.buffer.Info)
+	if buffer.debugging() {
+		buffer.debugln("PREPROCESS text end:", c.buffer.Info)
 	}
 
 	c.substituteBeforePosition() // apply GSUB
 
 	if debugMode >= 2 {
-		fmt.Println("AFTER SUBSTITUTE", c.buffer.Info)
+		buffer.debugln("AFTER SUBSTITUTE", c.buffer.Info)
 	}
 
 	c.position()
 
 	if debugMode >= 2 {
-		fmt.Println("AFTER POSITION", c.buffer.Pos)
+		buffer.debugln("AFTER POSITION", c.buffer.Pos)
 	}
 
 	c.substituteAfterPosition()
@@ -815,5 +858,8 @@ func (sp *shaperOpentype) shape(font *Font, buffer *Buffer, features []Feature)
 
 	c.buffer.Props.Direction = c.targetDirection
 
+	if c.buffer.maxOps <= 0 {
+		c.buffer.budgetExceeded = true
+	}
 	c.buffer.maxOps = maxOpsDefault
 }
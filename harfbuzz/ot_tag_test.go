@@ -473,3 +473,61 @@ func TestOtTagFromLanguage(t *testing.T) {
 		t.Fatalf("exected [lana], got %v", scs)
 	}
 }
+
+func TestOtTagLanguageSystem(t *testing.T) {
+	// DejaVuSerif has localized forms for Serbian
+	font := NewFont(openFontFileTT("DejaVuSerif.ttf"))
+	tables := font.GetOTLayoutTables()
+
+	selected := func(lang string) int {
+		props := SegmentProperties{Direction: LeftToRight, Script: language.Cyrillic, Language: language.NewLanguage(lang)}
+		mb := newOtMapBuilder(tables, props)
+		return mb.languageIndex[0]
+	}
+	serbian, russian := selected("sr"), selected("ru")
+	if serbian == DefaultLanguageIndex {
+		t.Fatal("expected a language system for Serbian")
+	}
+	if serbian == russian {
+		t.Fatalf("expected different language systems, got %d", serbian)
+	}
+
+	// the locl feature is applied to the Cyrillic small letter be
+	shape := func(lang string) tt.GID {
+		buf := NewBuffer()
+		buf.AddRunes([]rune{0x0431}, 0, 1)
+		buf.Props = SegmentProperties{Direction: LeftToRight, Script: language.Cyrillic, Language: language.NewLanguage(lang)}
+		buf.Shape(font, nil)
+		return buf.Info[0].Glyph
+	}
+	if shape("sr") == shape("ru") {
+		t.Fatal("expected a localized form for Serbian")
+	}
+}
+
+func TestOtTagScriptCandidates(t *testing.T) {
+	for _, test := range []struct {
+		script   language.Script
+		expected []string
+	}{
+		{language.Devanagari, []string{"dev3", "dev2", "deva"}},
+		{language.Arabic, []string{"arab"}},
+		{language.Latin, []string{"latn"}},
+	} {
+		tags, _ := NewOTTagsFromScriptAndLanguage(test.script, "")
+		if len(tags) != len(test.expected) {
+			t.Fatalf("for script %s, expected %v, got %v", test.script, test.expected, tags)
+		}
+		for i, tag := range tags {
+			assertEqualTag(t, tag, tt.MustNewTag(test.expected[i]))
+		}
+	}
+
+	// the default script is used when the font does not support the requested one
+	font := NewFont(openFontFileTT("DejaVuSerif.ttf"))
+	tags, _ := NewOTTagsFromScriptAndLanguage(language.Devanagari, "")
+	_, chosen, found := SelectScript(&font.GetOTLayoutTables().GSUB.TableLayout, tags)
+	if found || chosen != tagDefaultScript {
+		t.Fatalf("expected DFLT fallback, got %s", chosen)
+	}
+}
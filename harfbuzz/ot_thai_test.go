@@ -0,0 +1,42 @@
+package harfbuzz
+
+import (
+	"testing"
+
+	"github.com/benoitkugler/textlayout/fonts"
+)
+
+func TestThaiSaraAm(t *testing.T) {
+	face := openFontFileTT("FreeSerif.ttf")
+	font := NewFont(face)
+
+	nominal := func(r rune) fonts.GID {
+		g, ok := face.NominalGlyph(r)
+		if !ok {
+			t.Fatalf("missing glyph for %U", r)
+		}
+		return g
+	}
+
+	// DO DEK, MAI CHATTAWA, SARA AM : SARA AM is decomposed into
+	// NIKHAHIT + SARA AA, and NIKHAHIT is moved before the tone mark
+	input := []rune{0x0E14, 0x0E4B, 0x0E33}
+	expected := []fonts.GID{nominal(0x0E14), nominal(0x0E4D), nominal(0x0E4B), nominal(0x0E32)}
+
+	buf := NewBuffer()
+	buf.AddRunes(input, 0, -1)
+	buf.GuessSegmentProperties()
+	buf.Shape(font, nil)
+
+	if len(buf.Info) != len(expected) {
+		t.Fatalf("expected %d glyphs, got %d", len(expected), len(buf.Info))
+	}
+	for i, info := range buf.Info {
+		if info.Glyph != expected[i] {
+			t.Errorf("glyph %d: expected %d, got %d", i, expected[i], info.Glyph)
+		}
+		if info.Cluster != 0 {
+			t.Errorf("glyph %d: expected cluster 0, got %d", i, info.Cluster)
+		}
+	}
+}
@@ -1,8 +1,6 @@
 package harfbuzz
 
 import (
-	"fmt"
-
 	tt "github.com/benoitkugler/textlayout/fonts/truetype"
 	ucd "github.com/benoitkugler/textlayout/unicodedata"
 )
@@ -344,8 +342,8 @@ func reorderSyllableUse(buffer *Buffer, start, end int) {
 }
 
 func reorderUse(_ *otShapePlan, font *Font, buffer *Buffer) {
-	if debugMode >= 1 {
-		fmt.Println("USE - start reordering USE")
+	if buffer.debugging() {
+		buffer.debugln("USE - start reordering USE")
 	}
 	syllabicInsertDottedCircles(font, buffer, useBrokenCluster,
 		useSyllableMachine_ex_B, useSyllableMachine_ex_R, -1)
@@ -354,8 +352,8 @@ func reorderUse(_ *otShapePlan, font *Font, buffer *Buffer) {
 	for start, end := iter.next(); start < count; start, end = iter.next() {
 		reorderSyllableUse(buffer, start, end)
 	}
-	if debugMode >= 1 {
-		fmt.Println("USE - end reordering USE")
+	if buffer.debugging() {
+		buffer.debugln("USE - end reordering USE")
 	}
 }
 
@@ -3,6 +3,9 @@ package harfbuzz
 import (
 	"fmt"
 	"sync"
+
+	"github.com/benoitkugler/textlayout/fonts"
+	tt "github.com/benoitkugler/textlayout/fonts/truetype"
 )
 
 // ported from harfbuzz/src/hb-shape.cc, harfbuzz/src/hb-shape-plan.cc Copyright © 2009, 2012 Behdad Esfahbod
@@ -26,8 +29,72 @@ import (
 // It also depends on the properties of the segment of text : the `Props`
 // field of the buffer must be set before calling `Shape`.
 func (b *Buffer) Shape(font *Font, features []Feature) {
-	shapePlan := newShapePlanCached(font, b.Props, features, font.varCoords())
-	shapePlan.execute(font, b, features)
+	b.budgetExceeded = false
+	plan := newShapePlanCached(font, b.Props, features, font.varCoords())
+	plan.execute(font, b, features)
+
+	switch b.NotdefHandling {
+	case NotdefRemove:
+		b.removeNotFound()
+	case NotdefReplace:
+		b.replaceNotFound(font)
+	}
+}
+
+// removeNotFound deletes the glyphs of the characters
+// not found in the font, merging their clusters.
+func (b *Buffer) removeNotFound() {
+	otLayoutDeleteGlyphsInplace(b, func(info *GlyphInfo) bool { return info.notFound })
+}
+
+// replaceNotFound replaces the glyphs of the characters
+// not found in the font by the glyphs of `NotdefReplacement`.
+func (b *Buffer) replaceNotFound(font *Font) {
+	if len(b.NotdefReplacement) == 0 {
+		b.removeNotFound()
+		return
+	}
+
+	hasNotFound := false
+	for _, info := range b.Info {
+		hasNotFound = hasNotFound || info.notFound
+	}
+	if !hasNotFound {
+		return
+	}
+
+	// glyphs are in visual order
+	replacement := make([]fonts.GID, len(b.NotdefReplacement))
+	for i, r := range b.NotdefReplacement {
+		replacement[i], _ = font.nominalGlyph(r, b.NotFound)
+	}
+	direction := b.Props.Direction
+	if direction.isBackward() {
+		for i, j := 0, len(replacement)-1; i < j; i, j = i+1, j-1 {
+			replacement[i], replacement[j] = replacement[j], replacement[i]
+		}
+	}
+
+	var (
+		info = make([]GlyphInfo, 0, len(b.Info))
+		pos  = make([]GlyphPosition, 0, len(b.Pos))
+	)
+	for i, glyph := range b.Info {
+		if !glyph.notFound {
+			info = append(info, glyph)
+			pos = append(pos, b.Pos[i])
+			continue
+		}
+		for _, gid := range replacement {
+			glyph.Glyph = gid
+			var p GlyphPosition
+			p.XAdvance, p.YAdvance = font.GlyphAdvanceForDirection(gid, direction)
+			p.XOffset, p.YOffset = font.subtractGlyphOriginForDirection(gid, direction, 0, 0)
+			info = append(info, glyph)
+			pos = append(pos, p)
+		}
+	}
+	b.Info, b.Pos = info, pos
 }
 
 type shaperKind uint8
@@ -51,7 +118,7 @@ type shaper interface {
 	shape(*Font, *Buffer, []Feature)
 }
 
-// Shape plans are an internal mechanism. Each plan contains state
+// ShapePlan stores the choices made when shaping: each plan contains state
 // describing how HarfBuzz will shape a particular text segment, based on
 // the combination of segment properties and the capabilities in the
 // font face in use.
@@ -62,13 +129,14 @@ type shaper interface {
 // etc.).
 //
 // Most client programs will not need to deal with shape plans directly.
-type shapePlan struct {
+// See NewShapePlan to inspect the plan used by Shape.
+type ShapePlan struct {
 	shaper       shaper
 	props        SegmentProperties
 	userFeatures []Feature
 }
 
-func (plan *shapePlan) init(copy bool, font *Font, props SegmentProperties,
+func (plan *ShapePlan) init(copy bool, font *Font, props SegmentProperties,
 	userFeatures []Feature, coords []float32) {
 	plan.props = props
 	if !copy {
@@ -96,7 +164,7 @@ func (plan *shapePlan) init(copy bool, font *Font, props SegmentProperties,
 	}
 }
 
-func (plan shapePlan) userFeaturesMatch(other shapePlan) bool {
+func (plan ShapePlan) userFeaturesMatch(other ShapePlan) bool {
 	if len(plan.userFeatures) != len(other.userFeatures) {
 		return false
 	}
@@ -110,21 +178,33 @@ func (plan shapePlan) userFeaturesMatch(other shapePlan) bool {
 	return true
 }
 
-func (plan shapePlan) equal(other shapePlan) bool {
+func (plan ShapePlan) equal(other ShapePlan) bool {
 	return plan.props == other.props &&
-		plan.userFeaturesMatch(other) && plan.shaper.kind() == other.shaper.kind()
+		plan.userFeaturesMatch(other) && plan.shaper.kind() == other.shaper.kind() &&
+		plan.variationsMatch(other)
+}
+
+// variationsMatch returns true if the plans select the same
+// feature variations, which depend on the font coordinates
+func (plan ShapePlan) variationsMatch(other ShapePlan) bool {
+	ot1, ok1 := plan.shaper.(*shaperOpentype)
+	ot2, ok2 := other.shaper.(*shaperOpentype)
+	if !ok1 || !ok2 {
+		return true
+	}
+	return ot1.key == ot2.key
 }
 
 // Constructs a shaping plan for a combination of @face, @userFeatures, @props,
 // plus the variation-space coordinates @coords.
 // See newShapePlanCached for caching support.
 func newShapePlan(font *Font, props SegmentProperties,
-	userFeatures []Feature, coords []float32) *shapePlan {
+	userFeatures []Feature, coords []float32) *ShapePlan {
 	if debugMode >= 1 {
 		fmt.Printf("NEW SHAPE PLAN: face:%p features:%v coords:%v\n", &font.face, userFeatures, coords)
 	}
 
-	var sp shapePlan
+	var sp ShapePlan
 
 	sp.init(true, font, props, userFeatures, coords)
 
@@ -138,29 +218,72 @@ func newShapePlan(font *Font, props SegmentProperties,
 
 // Executes the given shaping plan on the specified `buffer`, using
 // the given `font` and `features`.
-func (sp *shapePlan) execute(font *Font, buffer *Buffer, features []Feature) {
-	if debugMode >= 1 {
-		fmt.Printf("EXECUTE shape plan %p features:%v shaper:%T\n", sp, features, sp.shaper)
+func (sp *ShapePlan) execute(font *Font, buffer *Buffer, features []Feature) {
+	if buffer.debugging() {
+		buffer.debugf("EXECUTE shape plan %p features:%v shaper:%T\n", sp, features, sp.shaper)
 	}
 
 	sp.shaper.shape(font, buffer, features)
 }
 
+// NewShapePlan returns the plan used by `Buffer.Shape` to shape text
+// with `props` and `features`, using `font`.
+// It may be used to diagnose how a font is shaped.
+func NewShapePlan(font *Font, props SegmentProperties, features []Feature) *ShapePlan {
+	return newShapePlanCached(font, props, features, font.varCoords())
+}
+
+// ChosenScript returns the OpenType script tag selected from the
+// GSUB table of the font (or from its GPOS table, if GSUB has no suitable script).
+// It is 'DFLT' (or 'dflt', 'latn') if the font does not support the requested script,
+// and 0 if the font has no layout tables.
+func (sp *ShapePlan) ChosenScript() tt.Tag {
+	if ot, ok := sp.shaper.(*shaperOpentype); ok {
+		return chosenTag(ot.plan.map_.chosenScript)
+	}
+	return 0
+}
+
+// ChosenLanguage returns the OpenType language system tag selected from
+// the GSUB table of the font (or from its GPOS table, if GSUB has no suitable script).
+// It is 'dflt' for the default language system, and 0 if no script is selected.
+func (sp *ShapePlan) ChosenLanguage() tt.Tag {
+	if ot, ok := sp.shaper.(*shaperOpentype); ok {
+		m := ot.plan.map_
+		if m.chosenScript[0] == NoScriptIndex {
+			return m.chosenLanguage[1]
+		}
+		return m.chosenLanguage[0]
+	}
+	return 0
+}
+
+// chosenTag returns the GSUB tag, or the GPOS tag if GSUB has none
+func chosenTag(tags [2]tt.Tag) tt.Tag {
+	if tags[0] == NoScriptIndex {
+		if tags[1] == NoScriptIndex {
+			return 0
+		}
+		return tags[1]
+	}
+	return tags[0]
+}
+
 /*
  * Caching
  */
 
 var (
-	planCache     = map[Face][]*shapePlan{}
+	planCache     = map[Face][]*ShapePlan{}
 	planCacheLock sync.Mutex
 )
 
 // creates (or returns) a cached shaping plan suitable for reuse, for a combination
 // of `face`, `userFeatures`, `props`, plus the variation-space coordinates `coords`.
 func newShapePlanCached(font *Font, props SegmentProperties,
-	userFeatures []Feature, coords []float32) *shapePlan {
+	userFeatures []Feature, coords []float32) *ShapePlan {
 
-	var key shapePlan
+	var key ShapePlan
 	key.init(false, font, props, userFeatures, coords)
 
 	planCacheLock.Lock()
@@ -418,10 +418,10 @@ func (so *shapeOptions) verifyBufferSafeToBreak(buffer, textBuffer *Buffer, font
 			return fmt.Errorf("unexpected %d >= %d", textStart, textEnd)
 		}
 
-		if debugMode >= 1 {
-			fmt.Println()
-			fmt.Printf("VERIFY SAFE TO BREAK : start %d end %d text start %d end %d\n", start, end, textStart, textEnd)
-			fmt.Println()
+		if buffer.debugging() {
+			buffer.debugln()
+			buffer.debugf("VERIFY SAFE TO BREAK : start %d end %d text start %d end %d\n", start, end, textStart, textEnd)
+			buffer.debugln()
 		}
 
 		fragment.Clear()
@@ -903,3 +903,204 @@ func TestExample(t *testing.T) {
 		fmt.Println(pos.XAdvance, pos.XOffset, ext.Width, ext.XBearing)
 	}
 }
+
+func TestShapePlanChosenTags(t *testing.T) {
+	// this font only has the old Indic 'deva' script
+	font := NewFont(openFontFile("harfbuzz_reference/in-house/fonts/57a9d9f83020155cbb1d2be1f43d82388cbecc88.ttf"))
+	props := SegmentProperties{Direction: LeftToRight, Script: language.Devanagari, Language: language.NewLanguage("hi")}
+	plan := NewShapePlan(font, props, nil)
+	if tag := plan.ChosenScript(); tag != tt.NewTag('d', 'e', 'v', 'a') {
+		t.Fatalf("expected deva script, got %s", tag)
+	}
+	if tag := plan.ChosenLanguage(); tag != tt.NewTag('d', 'f', 'l', 't') {
+		t.Fatalf("expected default language, got %s", tag)
+	}
+
+	font = NewFont(openFontFileTT("DejaVuSerif.ttf"))
+	props = SegmentProperties{Direction: LeftToRight, Script: language.Cyrillic, Language: language.NewLanguage("sr")}
+	plan = NewShapePlan(font, props, nil)
+	if tag := plan.ChosenScript(); tag != tt.NewTag('c', 'y', 'r', 'l') {
+		t.Fatalf("expected cyrl script, got %s", tag)
+	}
+	if tag := plan.ChosenLanguage(); tag != tt.NewTag('S', 'R', 'B', ' ') {
+		t.Fatalf("expected SRB language, got %s", tag)
+	}
+}
+
+func TestShapeFeatureVariations(t *testing.T) {
+	// Commissioner-VF substitutes the dollar sign at heavy weights,
+	// using a FeatureVariations record of its GSUB table
+	face := openFontFileTT("Commissioner-VF.ttf")
+	font := NewFont(face)
+	shapeDollar := func(weight float32) fonts.GID {
+		tt.SetVariations(face, []tt.Variation{{Tag: tt.MustNewTag("wght"), Value: weight}})
+		buf := NewBuffer()
+		buf.AddRunes([]rune{'$'}, 0, 1)
+		buf.GuessSegmentProperties()
+		buf.Shape(font, nil)
+		return buf.Info[0].Glyph
+	}
+
+	nominal, _ := face.NominalGlyph('$')
+	if gid := shapeDollar(100); gid != nominal {
+		t.Fatalf("expected nominal glyph %d at light weight, got %d", nominal, gid)
+	}
+	if gid := shapeDollar(900); gid == nominal {
+		t.Fatal("expected a substituted glyph at heavy weight")
+	}
+}
+
+func TestShapeVertical(t *testing.T) {
+	font := NewFont(openFontCollectionTT("NotoSansCJK-Bold.ttc", 0))
+
+	text := []rune("中文A")
+	buf := NewBuffer()
+	buf.AddRunes(text, 0, -1)
+	buf.GuessSegmentProperties()
+	buf.Props.Direction = TopToBottom
+	buf.Shape(font, nil)
+
+	assertEqualInt(t, len(text), len(buf.Info))
+	for i, info := range buf.Info {
+		pos := buf.Pos[i]
+		// the pen moves down
+		assertEqualInt32(t, pos.XAdvance, 0)
+		assertEqualInt32(t, pos.YAdvance, font.getGlyphVAdvance(info.Glyph))
+		assert(t, pos.YAdvance < 0)
+
+		// the glyph is centered horizontally, below the vertical origin
+		assertEqualInt32(t, pos.XOffset, -font.GlyphHAdvance(info.Glyph)/2)
+		_, originY := font.getGlyphVOriginWithFallback(info.Glyph)
+		assertEqualInt32(t, pos.YOffset, -originY)
+		assert(t, pos.YOffset < 0)
+	}
+}
+
+func TestShapeVerticalForms(t *testing.T) {
+	// NotoSansCJK has both 'vert' and 'vrt2' features,
+	// which differ for the fullwidth colon (U+FF1A)
+	face := openFontCollectionTT("NotoSansCJK-Bold.ttc", 0)
+	font := NewFont(face)
+	shape := func(r rune, dir Direction, features []Feature) fonts.GID {
+		buf := NewBuffer()
+		buf.AddRunes([]rune{r}, 0, -1)
+		buf.GuessSegmentProperties()
+		buf.Props.Direction = dir
+		buf.Shape(font, features)
+		return buf.Info[0].Glyph
+	}
+	vertOnly := []Feature{
+		{Tag: tt.NewTag('v', 'r', 't', '2'), Value: 0, Start: FeatureGlobalStart, End: FeatureGlobalEnd},
+		{Tag: tt.NewTag('v', 'e', 'r', 't'), Value: 1, Start: FeatureGlobalStart, End: FeatureGlobalEnd},
+	}
+
+	for _, r := range []rune{0x3001, 0xFF08} { // ideographic comma, fullwidth parenthesis
+		horizontal := shape(r, LeftToRight, nil)
+		if vertical := shape(r, TopToBottom, nil); vertical == horizontal {
+			t.Fatalf("expected a vertical form for %U", r)
+		}
+		if vertical := shape(r, TopToBottom, vertOnly); vertical == horizontal {
+			t.Fatalf("expected a vertical form for %U with 'vert'", r)
+		}
+	}
+
+	if vrt2, vert := shape(0xFF1A, TopToBottom, nil), shape(0xFF1A, TopToBottom, vertOnly); vrt2 == vert {
+		t.Fatal("expected 'vrt2' to be preferred")
+	}
+
+	// disabling 'vert' also disables 'vrt2'
+	noVert := []Feature{{Tag: tt.NewTag('v', 'e', 'r', 't'), Value: 0, Start: FeatureGlobalStart, End: FeatureGlobalEnd}}
+	for _, r := range []rune{0x3001, 0xFF08, 0xFF1A} {
+		if horizontal, vertical := shape(r, LeftToRight, nil), shape(r, TopToBottom, noVert); vertical != horizontal {
+			t.Fatalf("expected no vertical form for %U with 'vert=0'", r)
+		}
+	}
+	noVert[0].Start, noVert[0].End = 0, 1 // also for partial ranges
+	if horizontal, vertical := shape(0x3001, LeftToRight, nil), shape(0x3001, TopToBottom, noVert); vertical != horizontal {
+		t.Fatal("expected no vertical form with 'vert=0' on a range")
+	}
+}
+
+func TestShapeFractions(t *testing.T) {
+	face := openFontFileTT("Roboto-BoldItalic.ttf")
+	font := NewFont(face)
+	shape := func(text string) []fonts.GID {
+		buf := NewBuffer()
+		buf.AddRunes([]rune(text), 0, -1)
+		buf.GuessSegmentProperties()
+		buf.Shape(font, nil)
+		out := make([]fonts.GID, len(buf.Info))
+		for i, info := range buf.Info {
+			out[i] = info.Glyph
+		}
+		return out
+	}
+
+	one, _ := face.NominalGlyph('1')
+	two, _ := face.NominalGlyph('2')
+
+	// the fraction slash triggers 'numr', 'frac' and 'dnom'
+	glyphs := shape("1\u20442")
+	assertEqualInt(t, len(glyphs), 3)
+	if glyphs[0] == one || glyphs[2] == two {
+		t.Fatalf("expected numerator and denominator forms, got %v", glyphs)
+	}
+	if glyphs[0] == glyphs[2] {
+		t.Fatalf("expected distinct numerator and denominator forms, got %v", glyphs)
+	}
+	// digits outside the fraction are not affected
+	three, _ := face.NominalGlyph('3')
+	if glyphs := shape("3 1\u20442 3"); glyphs[0] != three || glyphs[6] != three {
+		t.Fatalf("unexpected glyphs %v", glyphs)
+	}
+
+	// the ASCII solidus is too ambiguous (dates, paths...) to trigger fractions
+	glyphs = shape("1/2")
+	if glyphs[0] != one || glyphs[2] != two {
+		t.Fatalf("expected nominal digits, got %v", glyphs)
+	}
+}
+
+func TestShapeDefaultIgnorables(t *testing.T) {
+	face := openFontFileTT("Roboto-BoldItalic.ttf")
+	font := NewFont(face)
+	shape := func(text string, invisible fonts.GID, flags ShappingOptions) *Buffer {
+		buf := NewBuffer()
+		buf.Invisible = invisible
+		buf.Flags = flags
+		buf.AddRunes([]rune(text), 0, -1)
+		buf.GuessSegmentProperties()
+		buf.Shape(font, nil)
+		return buf
+	}
+
+	space, _ := face.NominalGlyph(' ')
+	for _, text := range []string{"ab\u00ADc", "ab\u200Dc", "ab\u200Cc", "ab\u2060c"} {
+		// the ignorable is replaced by the space glyph, with no advance
+		buf := shape(text, 0, 0)
+		assertEqualInt(t, len(buf.Info), 4)
+		if buf.Info[2].Glyph != space {
+			t.Fatalf("%q: expected invisible glyph %d, got %d", text, space, buf.Info[2].Glyph)
+		}
+		if pos := buf.Pos[2]; pos.XAdvance != 0 || pos.YAdvance != 0 {
+			t.Fatalf("%q: expected zero advance, got %v", text, pos)
+		}
+
+		// a custom invisible glyph is used if provided
+		buf = shape(text, 3, 0)
+		assertEqualInt(t, len(buf.Info), 4)
+		if buf.Info[2].Glyph != 3 || buf.Pos[2].XAdvance != 0 {
+			t.Fatalf("%q: expected custom invisible glyph, got %d", text, buf.Info[2].Glyph)
+		}
+
+		buf = shape(text, 0, RemoveDefaultIgnorables)
+		assertEqualInt(t, len(buf.Info), 3)
+	}
+
+	// with PreserveDefaultIgnorables, the glyph of the font is used
+	hyphen, _ := face.NominalGlyph(0xAD)
+	buf := shape("ab\u00ADc", 0, PreserveDefaultIgnorables)
+	if buf.Info[2].Glyph != hyphen {
+		t.Fatalf("expected soft hyphen glyph %d, got %d", hyphen, buf.Info[2].Glyph)
+	}
+}
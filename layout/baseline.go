@@ -0,0 +1,130 @@
+package layout
+
+import (
+	"unicode"
+
+	"github.com/benoitkugler/textlayout/fonts/truetype"
+	"github.com/benoitkugler/textlayout/harfbuzz"
+)
+
+// RunOptions describes how a run is placed on its line.
+// The glyph positions of the run are not modified : instead, the run
+// should be rendered at FontScale times the nominal size, with its
+// origin moved up by BaselineShift.
+type RunOptions struct {
+	// BaselineShift is expressed in font units, at the nominal size.
+	// Positive values move the run up.
+	BaselineShift float32
+
+	// FontScale is the size of the run, relative to the nominal size.
+	// Zero is the same as 1.
+	FontScale float32
+}
+
+// Scale returns the effective font scale.
+func (opts RunOptions) Scale() float32 {
+	if opts.FontScale == 0 {
+		return 1
+	}
+	return opts.FontScale
+}
+
+// ScriptPosition selects superscript or subscript text.
+type ScriptPosition uint8
+
+const (
+	Baseline ScriptPosition = iota
+	Superscript
+	Subscript
+)
+
+// fallback values, used when the OS/2 table is missing, in em
+const (
+	defaultScriptScale      = 2. / 3
+	defaultSuperscriptShift = 1. / 3
+	defaultSubscriptShift   = -1. / 5
+)
+
+var (
+	tagSups = truetype.MustNewTag("sups")
+	tagSubs = truetype.MustNewTag("subs")
+)
+
+// ShapeScriptPosition shapes `text` with `font`, as superscript or subscript text.
+// When the font provides the 'sups' or 'subs' feature for every glyph of the text,
+// it is used and the returned options are neutral. Otherwise, superscript and subscript
+// are synthesized : the glyphs are shaped normally, and the returned options scale and shift
+// the run, using the metrics of the OS/2 table when present.
+func ShapeScriptPosition(text []rune, font *truetype.Font, position ScriptPosition) ([]PositionedGlyph, RunOptions) {
+	plain := shapeWithFeatures(text, font, nil)
+	if position == Baseline {
+		return plain, RunOptions{}
+	}
+
+	tag := tagSups
+	if position == Subscript {
+		tag = tagSubs
+	}
+	if hasFeature(font.LayoutTables().GSUB.TableLayout, tag) {
+		feature := harfbuzz.Feature{Tag: tag, Value: 1, Start: 0, End: harfbuzz.FeatureGlobalEnd}
+		glyphs := shapeWithFeatures(text, font, []harfbuzz.Feature{feature})
+		if isSubstituted(text, plain, glyphs) {
+			return glyphs, RunOptions{}
+		}
+	}
+
+	return plain, syntheticScriptPosition(font, position)
+}
+
+// syntheticScriptPosition returns the options simulating superscript or subscript
+func syntheticScriptPosition(font *truetype.Font, position ScriptPosition) RunOptions {
+	upem := float32(font.Upem())
+	opts := RunOptions{FontScale: defaultScriptScale, BaselineShift: defaultSuperscriptShift * upem}
+	if position == Subscript {
+		opts.BaselineShift = defaultSubscriptShift * upem
+	}
+
+	if os2 := font.OS2; os2 != nil {
+		size, shift := os2.YSuperscriptYSize, os2.YSuperscriptYOffset
+		if position == Subscript {
+			// the subscript offset is positive downward
+			size, shift = os2.YSubscriptYSize, -os2.YSubscriptYOffset
+		}
+		if size > 0 {
+			opts.FontScale = float32(size) / upem
+			opts.BaselineShift = float32(shift)
+		}
+	}
+	return opts
+}
+
+func shapeWithFeatures(text []rune, font *truetype.Font, features []harfbuzz.Feature) []PositionedGlyph {
+	buf := harfbuzz.NewBuffer()
+	buf.AddRunes(text, 0, len(text))
+	buf.GuessSegmentProperties()
+	buf.Shape(harfbuzz.NewFont(font), features)
+	return FromBuffer(buf)
+}
+
+func hasFeature(table truetype.TableLayout, tag truetype.Tag) bool {
+	for _, feature := range table.Features {
+		if feature.Tag == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// isSubstituted returns true if every glyph of `plain`, except spaces,
+// has been replaced in `glyphs`
+func isSubstituted(text []rune, plain, glyphs []PositionedGlyph) bool {
+	if len(plain) != len(glyphs) {
+		return false
+	}
+	for i, g := range plain {
+		if g.GID == glyphs[i].GID && !unicode.IsSpace(text[g.Cluster]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,60 @@
+package layout
+
+import (
+	"testing"
+)
+
+func TestShapeScriptPosition(t *testing.T) {
+	text := []rune("12")
+
+	// Castoro provides the 'sups' and 'subs' features
+	font := loadFont(t, "Castoro-Regular.ttf")
+	plain := shape(font, string(text))
+	for _, position := range []ScriptPosition{Superscript, Subscript} {
+		glyphs, opts := ShapeScriptPosition(text, font, position)
+		if opts != (RunOptions{}) || opts.Scale() != 1 {
+			t.Fatalf("expected no synthesis, got %v", opts)
+		}
+		if len(glyphs) != len(plain) || glyphs[1].GID == plain[1].GID {
+			t.Fatalf("expected substituted glyphs, got %v", glyphs)
+		}
+	}
+
+	// 'x' is not covered by the feature : synthesize the whole run
+	if _, opts := ShapeScriptPosition([]rune("x2"), font, Superscript); opts.Scale() == 1 {
+		t.Fatalf("expected synthesis, got %v", opts)
+	}
+
+	// DejaVuSerif has no such features
+	font = loadFont(t, "DejaVuSerif.ttf")
+	plain = shape(font, string(text))
+	upem := float32(font.Upem())
+
+	glyphs, opts := ShapeScriptPosition(text, font, Superscript)
+	if glyphs[0].GID != plain[0].GID || glyphs[1].GID != plain[1].GID {
+		t.Fatalf("expected regular glyphs, got %v", glyphs)
+	}
+	if exp := float32(font.OS2.YSuperscriptYSize) / upem; opts.Scale() != exp || exp >= 1 {
+		t.Fatalf("expected scale %g, got %g", exp, opts.Scale())
+	}
+	if opts.BaselineShift <= 0 || opts.BaselineShift != float32(font.OS2.YSuperscriptYOffset) {
+		t.Fatalf("unexpected baseline shift %g", opts.BaselineShift)
+	}
+
+	_, opts = ShapeScriptPosition(text, font, Subscript)
+	if opts.Scale() >= 1 || opts.BaselineShift >= 0 {
+		t.Fatalf("unexpected subscript options %v", opts)
+	}
+
+	_, opts = ShapeScriptPosition(text, font, Baseline)
+	if opts != (RunOptions{}) {
+		t.Fatalf("unexpected baseline options %v", opts)
+	}
+
+	// without OS/2 table
+	font.OS2 = nil
+	opts = syntheticScriptPosition(font, Superscript)
+	if opts.Scale() != defaultScriptScale || opts.BaselineShift != defaultSuperscriptShift*upem {
+		t.Fatalf("unexpected default options %v", opts)
+	}
+}
@@ -0,0 +1,163 @@
+package layout
+
+import (
+	"sort"
+
+	"github.com/benoitkugler/textlayout/harfbuzz"
+)
+
+// clusterWidth returns the horizontal advance of `cl`
+func clusterWidth(glyphs []PositionedGlyph, cl Cluster) float32 {
+	var width float32
+	for _, g := range glyphs[cl.GlyphStart:cl.GlyphEnd] {
+		width += g.XAdvance
+	}
+	return width
+}
+
+// CaretX returns the horizontal position of the caret placed before the
+// rune `charIndex`, relative to the origin of the run.
+// `clusters` is the result of Clusters, and the position is expressed in font units.
+// `dir` is the direction used to shape the run : for right-to-left runs,
+// the caret is placed on the right of the runes.
+// Inside a cluster made of several runes (such as a ligature), the
+// advance of the cluster is divided evenly between them.
+// For a `charIndex` past the end of the run, the position of the
+// end of the run is returned.
+func CaretX(glyphs []PositionedGlyph, clusters []Cluster, dir harfbuzz.Direction, charIndex int) float32 {
+	rtl := dir == harfbuzz.RightToLeft
+
+	var x, total float32
+	found := false
+	for _, cl := range clusters {
+		width := clusterWidth(glyphs, cl)
+		if cl.RuneStart <= charIndex && charIndex < cl.RuneEnd {
+			fraction := float32(charIndex-cl.RuneStart) / float32(cl.RuneEnd-cl.RuneStart)
+			if rtl {
+				x = total + width*(1-fraction)
+			} else {
+				x = total + width*fraction
+			}
+			found = true
+		}
+		total += width
+	}
+	if found {
+		return x
+	}
+	if len(clusters) == 0 {
+		return 0
+	}
+	// the logical start of right-to-left runs is on their right
+	if rtl {
+		if charIndex < clusters[len(clusters)-1].RuneStart {
+			return total
+		}
+		return 0
+	}
+	if charIndex < clusters[0].RuneStart {
+		return 0
+	}
+	return total
+}
+
+// HitTest returns the rune displayed at the horizontal position `x`,
+// expressed in font units and relative to the origin of the run.
+// `trailing` is true if `x` is on the trailing half of the rune, meaning
+// that the caret should be placed after it.
+// Positions outside of the run are clamped to its extremities.
+// As for CaretX, runes of a cluster share its advance, and `dir` is
+// the direction used to shape the run.
+func HitTest(glyphs []PositionedGlyph, clusters []Cluster, dir harfbuzz.Direction, x float32) (charIndex int, trailing bool) {
+	if len(clusters) == 0 {
+		return 0, false
+	}
+	rtl := dir == harfbuzz.RightToLeft
+
+	var start float32
+	for i, cl := range clusters {
+		width := clusterWidth(glyphs, cl)
+		if x >= start+width && i != len(clusters)-1 {
+			start += width
+			continue
+		}
+
+		// the position in the cluster, in number of runes
+		nbRunes := cl.RuneEnd - cl.RuneStart
+		var pos float32
+		if width > 0 {
+			pos = (x - start) / width * float32(nbRunes)
+		}
+		if rtl {
+			pos = float32(nbRunes) - pos
+		}
+		if pos <= 0 {
+			return cl.RuneStart, false
+		} else if pos >= float32(nbRunes) {
+			return cl.RuneEnd - 1, true
+		}
+		part := int(pos)
+		return cl.RuneStart + part, pos-float32(part) >= 0.5
+	}
+	return 0, false // not reached
+}
+
+// caretStops returns the positions where a caret may be placed in
+// the run described by `clusters`, which are the grapheme boundaries
+// between clusters, and the grapheme boundaries inside ligatures
+// (clusters rendered by one glyph), in logical order.
+func caretStops(text []rune, clusters []Cluster) []int {
+	if len(clusters) == 0 {
+		return nil
+	}
+	boundaries := graphemeBoundaries(text)
+	sorted := append([]Cluster(nil), clusters...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].RuneStart < sorted[j].RuneStart })
+
+	var stops []int
+	for _, cl := range sorted {
+		for index := cl.RuneStart; index < cl.RuneEnd && index < len(text); index++ {
+			isLigature := cl.GlyphEnd-cl.GlyphStart == 1
+			if boundaries[index] && (index == cl.RuneStart || isLigature) {
+				stops = append(stops, index)
+			}
+		}
+	}
+	// the end of the run is always a stop
+	return append(stops, sorted[len(sorted)-1].RuneEnd)
+}
+
+// NextCaret returns the position of the next caret stop after the rune `from`
+// (in logical order), in the run `glyphs`, the result of the shaping of `text`.
+// `clusters` is the result of Clusters.
+// The caret moves by grapheme clusters, so that combining sequences and
+// emojis are never split, and may be placed inside a ligature, between
+// its graphemes.
+// The end of the run is returned if there is no more stops.
+func NextCaret(text []rune, glyphs []PositionedGlyph, clusters []Cluster, from int) int {
+	stops := caretStops(text, clusters)
+	for _, stop := range stops {
+		if stop > from {
+			return stop
+		}
+	}
+	if len(stops) == 0 {
+		return from
+	}
+	return stops[len(stops)-1]
+}
+
+// PrevCaret is the same as NextCaret, but returns the position of the caret
+// stop before `from`, or the start of the run.
+func PrevCaret(text []rune, glyphs []PositionedGlyph, clusters []Cluster, from int) int {
+	stops := caretStops(text, clusters)
+	for i := len(stops) - 1; i >= 0; i-- {
+		if stops[i] < from {
+			return stops[i]
+		}
+	}
+	if len(stops) == 0 {
+		return from
+	}
+	return stops[0]
+}
@@ -0,0 +1,174 @@
+package layout
+
+import (
+	"testing"
+
+	"github.com/benoitkugler/textlayout/harfbuzz"
+)
+
+func TestCaretLTR(t *testing.T) {
+	font := loadFont(t, "DejaVuSerif.ttf")
+	text := []rune("Hi")
+	glyphs := shape(font, string(text))
+	clusters := Clusters(glyphs, text)
+	advH := glyphs[0].XAdvance
+	total := advH + glyphs[1].XAdvance
+
+	for i, exp := range []float32{0, advH, total, total} {
+		if x := CaretX(glyphs, clusters, harfbuzz.LeftToRight, i); x != exp {
+			t.Fatalf("caret %d: expected %g, got %g", i, exp, x)
+		}
+	}
+
+	for _, test := range []struct {
+		x        float32
+		index    int
+		trailing bool
+	}{
+		{-10, 0, false},
+		{advH / 4, 0, false},
+		{3 * advH / 4, 0, true},
+		{advH + 1, 1, false},
+		{total + 10, 1, true},
+	} {
+		if index, trailing := HitTest(glyphs, clusters, harfbuzz.LeftToRight, test.x); index != test.index || trailing != test.trailing {
+			t.Fatalf("hit test at %g: expected %d %v, got %d %v", test.x, test.index, test.trailing, index, trailing)
+		}
+	}
+}
+
+func TestCaretRTL(t *testing.T) {
+	font := loadFont(t, "FreeSerif.ttf")
+	text := []rune("של")
+	glyphs := shape(font, string(text))
+	clusters := Clusters(glyphs, text)
+	// visual order : lamed then shin
+	advLamed := glyphs[0].XAdvance
+	total := advLamed + glyphs[1].XAdvance
+
+	for i, exp := range []float32{total, advLamed, 0} {
+		if x := CaretX(glyphs, clusters, harfbuzz.RightToLeft, i); x != exp {
+			t.Fatalf("caret %d: expected %g, got %g", i, exp, x)
+		}
+	}
+
+	if index, trailing := HitTest(glyphs, clusters, harfbuzz.RightToLeft, advLamed/4); index != 1 || !trailing {
+		t.Fatalf("unexpected hit test %d %v", index, trailing)
+	}
+	if index, trailing := HitTest(glyphs, clusters, harfbuzz.RightToLeft, total-1); index != 0 || trailing {
+		t.Fatalf("unexpected hit test %d %v", index, trailing)
+	}
+}
+
+func TestCaretLigature(t *testing.T) {
+	font := loadFont(t, "DejaVuSerif.ttf")
+	text := []rune("fin")
+	glyphs := shape(font, string(text))
+	clusters := Clusters(glyphs, text)
+	if len(clusters) != 2 {
+		t.Fatalf("expected a ligature, got %v", clusters)
+	}
+	advLigature := glyphs[0].XAdvance
+
+	// the caret between 'f' and 'i' is in the middle of the ligature
+	if x := CaretX(glyphs, clusters, harfbuzz.LeftToRight, 1); x != advLigature/2 {
+		t.Fatalf("expected caret at %g, got %g", advLigature/2, x)
+	}
+	if x := CaretX(glyphs, clusters, harfbuzz.LeftToRight, 2); x != advLigature {
+		t.Fatalf("expected caret at %g, got %g", advLigature, x)
+	}
+
+	if index, trailing := HitTest(glyphs, clusters, harfbuzz.LeftToRight, 0.6*advLigature); index != 1 || trailing {
+		t.Fatalf("unexpected hit test %d %v", index, trailing)
+	}
+	if index, trailing := HitTest(glyphs, clusters, harfbuzz.LeftToRight, 0.4*advLigature); index != 0 || !trailing {
+		t.Fatalf("unexpected hit test %d %v", index, trailing)
+	}
+}
+
+func TestCaretSingleClusterRTL(t *testing.T) {
+	// a lam-alef ligature, after a left-to-right letter, shaped in its own run
+	text := []rune("xلا")
+	glyphs := []PositionedGlyph{{Cluster: 1, XAdvance: 10}}
+	clusters := Clusters(glyphs, text)
+
+	for i, exp := range []float32{10, 10, 5, 0} {
+		if x := CaretX(glyphs, clusters, harfbuzz.RightToLeft, i); x != exp {
+			t.Fatalf("caret %d: expected %g, got %g", i, exp, x)
+		}
+	}
+	if index, trailing := HitTest(glyphs, clusters, harfbuzz.RightToLeft, 9); index != 1 || trailing {
+		t.Fatalf("unexpected hit test %d %v", index, trailing)
+	}
+	if index, trailing := HitTest(glyphs, clusters, harfbuzz.RightToLeft, 1); index != 2 || !trailing {
+		t.Fatalf("unexpected hit test %d %v", index, trailing)
+	}
+}
+
+func TestGraphemeBoundaries(t *testing.T) {
+	for _, test := range []struct {
+		text     string
+		expected []int
+	}{
+		{"ab", []int{0, 1, 2}},
+		{"e\u0301\u0327x", []int{0, 3, 4}},
+		{"\r\n\n", []int{0, 2, 3}},
+		{"\U0001F1EB\U0001F1F7\U0001F1E9\U0001F1EA\U0001F1EB", []int{0, 2, 4, 5}},
+		{"\U0001F469\u200D\U0001F4BB!", []int{0, 3, 4}}, // woman technologist
+		{"\u1100\u1161\u11A8a", []int{0, 3, 4}},         // Hangul jamos
+	} {
+		boundaries := graphemeBoundaries([]rune(test.text))
+		var got []int
+		for i, b := range boundaries {
+			if b {
+				got = append(got, i)
+			}
+		}
+		if len(got) != len(test.expected) {
+			t.Fatalf("for %q, expected %v, got %v", test.text, test.expected, got)
+		}
+		for i := range got {
+			if got[i] != test.expected[i] {
+				t.Fatalf("for %q, expected %v, got %v", test.text, test.expected, got)
+			}
+		}
+	}
+}
+
+func TestNextCaret(t *testing.T) {
+	latin := loadFont(t, "DejaVuSerif.ttf")
+	emoji := loadFont(t, "NotoColorEmoji.ttf")
+
+	for _, test := range []struct {
+		font     string
+		text     string
+		expected []int // successive stops, from 0
+	}{
+		{"latin", "e\u0301\u0327x", []int{3, 4}},                           // combining sequence
+		{"latin", "fin", []int{1, 2, 3}},                                   // inside a ligature
+		{"emoji", "\U0001F1EB\U0001F1F7\U0001F1E9\U0001F1EA", []int{2, 4}}, // flags
+	} {
+		font := latin
+		if test.font == "emoji" {
+			font = emoji
+		}
+		text := []rune(test.text)
+		glyphs := shape(font, test.text)
+		clusters := Clusters(glyphs, text)
+
+		from := 0
+		for _, exp := range test.expected {
+			next := NextCaret(text, glyphs, clusters, from)
+			if next != exp {
+				t.Fatalf("for %q, expected next caret %d after %d, got %d", test.text, exp, from, next)
+			}
+			if prev := PrevCaret(text, glyphs, clusters, next); prev != from {
+				t.Fatalf("for %q, expected previous caret %d before %d, got %d", test.text, from, next, prev)
+			}
+			from = next
+		}
+		if next := NextCaret(text, glyphs, clusters, from); next != len(text) {
+			t.Fatalf("expected the end of the run, got %d", next)
+		}
+	}
+}
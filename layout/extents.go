@@ -0,0 +1,75 @@
+package layout
+
+import (
+	"github.com/benoitkugler/textlayout/fonts/truetype"
+)
+
+// Rectangle is a box, in pixels, relative to the origin of
+// a run, with the Y axis increasing up.
+type Rectangle struct {
+	XMin, YMin, XMax, YMax float32
+}
+
+// IsEmpty returns true if the rectangle has no area.
+func (r Rectangle) IsEmpty() bool { return r.XMin >= r.XMax || r.YMin >= r.YMax }
+
+// Union returns the smallest rectangle containing `r` and `other`.
+// Empty rectangles are ignored.
+func (r Rectangle) Union(other Rectangle) Rectangle {
+	if r.IsEmpty() {
+		return other
+	}
+	if other.IsEmpty() {
+		return r
+	}
+	return Rectangle{
+		XMin: min(r.XMin, other.XMin),
+		YMin: min(r.YMin, other.YMin),
+		XMax: max(r.XMax, other.XMax),
+		YMax: max(r.YMax, other.YMax),
+	}
+}
+
+// RunExtents measures the glyphs of a run shaped with `font`, at `ppem` pixels per em.
+// The logical box spans the advances of the glyphs horizontally, and the
+// ascender and descender of the font vertically. The ink box is the
+// union of the glyph boxes, and is empty if no glyph has ink.
+func RunExtents(glyphs []PositionedGlyph, font *truetype.Font, ppem float32) (ink, logical Rectangle) {
+	scale := font.ScaleFactor(ppem)
+	ppemInt := uint16(ppem + 0.5)
+
+	var x, y float32 // pen position, in font units
+	for _, glyph := range glyphs {
+		if ext, ok := font.GlyphExtents(glyph.GID, ppemInt, ppemInt); ok && ext.Width != 0 && ext.Height != 0 {
+			x0, y0 := x+glyph.XOffset+ext.XBearing, y+glyph.YOffset+ext.YBearing
+			x1, y1 := x0+ext.Width, y0+ext.Height // Height is usually negative
+			box := Rectangle{
+				XMin: min(x0, x1) * scale, XMax: max(x0, x1) * scale,
+				YMin: min(y0, y1) * scale, YMax: max(y0, y1) * scale,
+			}
+			ink = ink.Union(box)
+		}
+		x += glyph.XAdvance
+		y += glyph.YAdvance
+	}
+
+	logical.XMin, logical.XMax = min(0, x*scale), max(0, x*scale)
+	if metrics, ok := font.FontHExtents(); ok {
+		logical.YMin, logical.YMax = metrics.Descender*scale, metrics.Ascender*scale
+	}
+	return ink, logical
+}
+
+func min(a, b float32) float32 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max(a, b float32) float32 {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,43 @@
+package layout
+
+import (
+	"testing"
+)
+
+func TestRunExtents(t *testing.T) {
+	font := loadFont(t, "DejaVuSerif.ttf")
+	const ppem = 20
+	scale := font.ScaleFactor(ppem)
+
+	ink, logical := RunExtents(shape(font, "Hi"), font, ppem)
+	if ink.IsEmpty() || logical.IsEmpty() {
+		t.Fatalf("unexpected empty extents %v %v", ink, logical)
+	}
+	if ink.YMin != 0 { // both glyphs lie on the baseline
+		t.Fatalf("unexpected ink bottom %g", ink.YMin)
+	}
+
+	// a leading space has an advance, but no ink
+	spaceInk, spaceLogical := RunExtents(shape(font, " Hi"), font, ppem)
+	gid, _ := font.NominalGlyph(' ')
+	spaceAdvance := font.HorizontalAdvance(gid) * scale
+	if w, exp := spaceLogical.XMax-spaceLogical.XMin, logical.XMax-logical.XMin+spaceAdvance; w != exp {
+		t.Fatalf("expected logical width %g, got %g", exp, w)
+	}
+	if spaceLogical.XMax-spaceLogical.XMin <= spaceInk.XMax-spaceInk.XMin {
+		t.Fatalf("expected logical width larger than ink width, got %v and %v", spaceLogical, spaceInk)
+	}
+	if spaceInk.XMin != ink.XMin+spaceAdvance {
+		t.Fatalf("expected ink shifted by the space advance, got %v", spaceInk)
+	}
+
+	// only spaces
+	ink, logical = RunExtents(shape(font, "  "), font, ppem)
+	if !ink.IsEmpty() || logical.XMax != 2*spaceAdvance {
+		t.Fatalf("unexpected extents %v %v", ink, logical)
+	}
+	metrics, _ := font.FontHExtents()
+	if logical.YMax != metrics.Ascender*scale || logical.YMin != metrics.Descender*scale {
+		t.Fatalf("unexpected logical height %v", logical)
+	}
+}
@@ -0,0 +1,165 @@
+package layout
+
+import (
+	"sort"
+
+	"github.com/benoitkugler/textlayout/fonts/truetype"
+	"github.com/benoitkugler/textlayout/harfbuzz"
+)
+
+// FontProvider returns the fonts to try for runes
+// not supported by the primary font.
+type FontProvider interface {
+	// FallbackFonts returns the candidate fonts for `r`,
+	// by order of preference.
+	FallbackFonts(r rune) []*truetype.Font
+}
+
+// GlyphRun is a sequence of glyphs shaped with the same font.
+type GlyphRun struct {
+	Font *truetype.Font
+	// Start and End delimit the runes [Start, End) of the text
+	// shaped in this run.
+	Start, End int
+	// Glyphs are in visual order, and their positions are
+	// expressed in the units of Font.
+	Glyphs []PositionedGlyph
+}
+
+// FallbackOptions tunes ShapeWithFallback.
+type FallbackOptions struct {
+	// MaxFonts is the maximum number of distinct fallback fonts used
+	// for the whole text (or the edited part of the text for Reshape),
+	// not counting the primary font. Zero means no limit.
+	MaxFonts int
+
+	// LastResort, if not nil, is used to shape the parts of the
+	// text supported by no font, so that they are rendered
+	// with its .notdef glyph (typically a visible box).
+	LastResort *truetype.Font
+}
+
+// ShapeWithFallback shapes `text` with `primary`, and re-shapes the parts of the
+// text not supported by it with the fonts returned by `provider`, which may be nil.
+// The returned runs are in logical order and their clusters refer to `text`.
+// When no font supports a part of the text, it is shaped with
+// options.LastResort, or kept as shaped by the last font tried,
+// that is with .notdef glyphs.
+func ShapeWithFallback(text []rune, props harfbuzz.SegmentProperties, primary *truetype.Font,
+	provider FontProvider, options FallbackOptions) []GlyphRun {
+	return shapeRange(text, 0, len(text), props, primary, provider, options, newFallbackState(primary))
+}
+
+// fallbackState is shared by the recursive calls of shapeRange
+type fallbackState struct {
+	tried map[*truetype.Font]bool // the fonts of the current recursion, including the primary font
+	used  map[*truetype.Font]bool // the fallback fonts used for the whole text
+}
+
+func newFallbackState(primary *truetype.Font) *fallbackState {
+	return &fallbackState{
+		tried: map[*truetype.Font]bool{primary: true},
+		used:  map[*truetype.Font]bool{},
+	}
+}
+
+func shapeRunes(text []rune, start, end int, props harfbuzz.SegmentProperties, font *truetype.Font) []PositionedGlyph {
+	buf := harfbuzz.NewBuffer()
+	buf.AddRunes(text, start, end-start)
+	buf.Props = props
+	buf.Shape(harfbuzz.NewFont(font), nil)
+	return FromBuffer(buf)
+}
+
+// shapeRange shapes text[start:end] with `font`, then
+// falls back for the missing glyphs, trying fonts not in `state.tried`
+func shapeRange(text []rune, start, end int, props harfbuzz.SegmentProperties,
+	font *truetype.Font, provider FontProvider, options FallbackOptions, state *fallbackState) []GlyphRun {
+	if start >= end {
+		return nil
+	}
+
+	glyphs := shapeRunes(text, start, end, props, font)
+
+	// split the text into ranges of covered and missing clusters,
+	// in logical order
+	clusters := Clusters(glyphs, text[:end])
+	sort.Slice(clusters, func(i, j int) bool { return clusters[i].RuneStart < clusters[j].RuneStart })
+	var out []GlyphRun
+	for i := 0; i < len(clusters); {
+		missing := hasMissingGlyph(glyphs[clusters[i].GlyphStart:clusters[i].GlyphEnd])
+		j := i + 1
+		for ; j < len(clusters); j++ {
+			if hasMissingGlyph(glyphs[clusters[j].GlyphStart:clusters[j].GlyphEnd]) != missing {
+				break
+			}
+		}
+		rangeStart, rangeEnd := clusters[i].RuneStart, clusters[j-1].RuneEnd
+		i = j
+
+		if missing {
+			if fallback := state.nextFallback(text[rangeStart], provider, options.MaxFonts); fallback != nil {
+				state.tried[fallback] = true
+				state.used[fallback] = true
+				out = append(out, shapeRange(text, rangeStart, rangeEnd, props, fallback, provider, options, state)...)
+				delete(state.tried, fallback)
+				continue
+			}
+			if options.LastResort != nil {
+				out = append(out, GlyphRun{
+					Font:   options.LastResort,
+					Start:  rangeStart,
+					End:    rangeEnd,
+					Glyphs: shapeRunes(text, rangeStart, rangeEnd, props, options.LastResort),
+				})
+				continue
+			}
+		}
+		out = append(out, GlyphRun{
+			Font:   font,
+			Start:  rangeStart,
+			End:    rangeEnd,
+			Glyphs: glyphsInRange(glyphs, rangeStart, rangeEnd),
+		})
+	}
+	return out
+}
+
+func hasMissingGlyph(glyphs []PositionedGlyph) bool {
+	for _, g := range glyphs {
+		if g.GID == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// nextFallback returns the first font supporting `r` not already tried, or nil.
+// Once `maxFonts` fallback fonts have been used, only them are considered.
+func (state *fallbackState) nextFallback(r rune, provider FontProvider, maxFonts int) *truetype.Font {
+	if provider == nil {
+		return nil
+	}
+	full := maxFonts > 0 && len(state.used) >= maxFonts
+	for _, font := range provider.FallbackFonts(r) {
+		if state.tried[font] || (full && !state.used[font]) {
+			continue
+		}
+		if font.CoversViaDecomposition(r) {
+			return font
+		}
+	}
+	return nil
+}
+
+// glyphsInRange returns a copy of the glyphs whose cluster is in [start, end),
+// preserving their (visual) order
+func glyphsInRange(glyphs []PositionedGlyph, start, end int) []PositionedGlyph {
+	var out []PositionedGlyph
+	for _, g := range glyphs {
+		if start <= g.Cluster && g.Cluster < end {
+			out = append(out, g)
+		}
+	}
+	return out
+}
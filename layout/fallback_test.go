@@ -0,0 +1,113 @@
+package layout
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/benoitkugler/textlayout/fonts/truetype"
+	"github.com/benoitkugler/textlayout/harfbuzz"
+	"github.com/benoitkugler/textlayout/language"
+)
+
+type fontList []*truetype.Font
+
+func (l fontList) FallbackFonts(rune) []*truetype.Font { return l }
+
+func TestShapeWithFallback(t *testing.T) {
+	latin := loadFont(t, "DejaVuSerif.ttf")
+	emoji := loadFont(t, "NotoColorEmoji.ttf")
+	text := []rune("Hi 😀😀!")
+	props := harfbuzz.SegmentProperties{Direction: harfbuzz.LeftToRight, Script: language.Latin}
+
+	runs := ShapeWithFallback(text, props, latin, fontList{latin, emoji}, FallbackOptions{})
+	expected := []GlyphRun{
+		{Font: latin, Start: 0, End: 3},
+		{Font: emoji, Start: 3, End: 5},
+		{Font: latin, Start: 5, End: 6},
+	}
+	if len(runs) != len(expected) {
+		t.Fatalf("expected %d runs, got %d", len(expected), len(runs))
+	}
+	for i, run := range runs {
+		exp := expected[i]
+		if run.Font != exp.Font || run.Start != exp.Start || run.End != exp.End {
+			t.Fatalf("unexpected run %d: %d %d", i, run.Start, run.End)
+		}
+		if len(run.Glyphs) != run.End-run.Start {
+			t.Fatalf("unexpected glyphs %v", run.Glyphs)
+		}
+		for j, g := range run.Glyphs {
+			if g.GID == 0 || g.Cluster != run.Start+j {
+				t.Fatalf("unexpected glyph %v", g)
+			}
+		}
+	}
+
+	// without fallback, the emojis are kept as .notdef
+	runs = ShapeWithFallback(text, props, latin, nil, FallbackOptions{})
+	if len(runs) != 3 || runs[1].Font != latin || runs[1].Glyphs[0].GID != 0 {
+		t.Fatalf("unexpected runs %v", runs)
+	}
+}
+
+func TestShapeWithFallbackOptions(t *testing.T) {
+	latin := loadFont(t, "DejaVuSerif.ttf")
+	hebrew := loadFont(t, "FreeSerif.ttf")
+	emoji := loadFont(t, "NotoColorEmoji.ttf")
+	lastResort := loadFont(t, "Castoro-Regular.ttf")
+	text := []rune("א😀")
+	props := harfbuzz.SegmentProperties{Direction: harfbuzz.LeftToRight, Script: language.Latin}
+
+	// the emoji requires a second fallback font
+	runs := ShapeWithFallback(text, props, latin, fontList{hebrew, emoji}, FallbackOptions{})
+	if len(runs) != 2 || runs[0].Font != hebrew || runs[1].Font != emoji {
+		t.Fatalf("unexpected runs %v", runs)
+	}
+
+	runs = ShapeWithFallback(text, props, latin, fontList{hebrew, emoji}, FallbackOptions{MaxFonts: 1})
+	if len(runs) != 2 || runs[0].Font != hebrew || runs[1].Font != hebrew || runs[1].Glyphs[0].GID != 0 {
+		t.Fatalf("unexpected runs %v", runs)
+	}
+
+	// unsupported runes use the last resort font
+	runs = ShapeWithFallback(text, props, latin, fontList{hebrew, emoji}, FallbackOptions{MaxFonts: 1, LastResort: lastResort})
+	if len(runs) != 2 || runs[1].Font != lastResort || len(runs[1].Glyphs) != 1 || runs[1].Glyphs[0].Cluster != 1 {
+		t.Fatalf("unexpected runs %v", runs)
+	}
+	if runs[1].Glyphs[0].XAdvance != lastResort.HorizontalAdvance(0) {
+		t.Fatal("expected the .notdef glyph of the last resort font")
+	}
+}
+
+func TestShapeWithFallbackMaxFonts(t *testing.T) {
+	latin := loadFont(t, "DejaVuSerif.ttf")
+	hebrew := loadFont(t, "FreeSerif.ttf")
+	emoji := loadFont(t, "NotoColorEmoji.ttf")
+	other := loadFont(t, "Castoro-Regular.ttf")
+	// the unsupported runes are in separate parts of the text
+	text := []rune("אa😀bא")
+	props := harfbuzz.SegmentProperties{Direction: harfbuzz.LeftToRight, Script: language.Latin}
+	candidates := fontList{other, hebrew, emoji}
+
+	fontsOf := func(runs []GlyphRun) (out []*truetype.Font) {
+		for _, run := range runs {
+			out = append(out, run.Font)
+		}
+		return out
+	}
+
+	runs := ShapeWithFallback(text, props, latin, candidates, FallbackOptions{MaxFonts: 2})
+	if got, exp := fontsOf(runs), []*truetype.Font{hebrew, latin, emoji, latin, hebrew}; !reflect.DeepEqual(got, exp) {
+		t.Fatalf("unexpected runs %v", runs)
+	}
+
+	// the limit applies to the whole text : the emoji is not supported,
+	// but the font already used for the first letter may still be used
+	runs = ShapeWithFallback(text, props, latin, candidates, FallbackOptions{MaxFonts: 1})
+	if got, exp := fontsOf(runs), []*truetype.Font{hebrew, latin, latin, latin, hebrew}; !reflect.DeepEqual(got, exp) {
+		t.Fatalf("unexpected runs %v", runs)
+	}
+	if runs[2].Start != 2 || runs[2].End != 3 || runs[2].Glyphs[0].GID != 0 {
+		t.Fatalf("expected the emoji to be shaped with .notdef, got %v", runs[2])
+	}
+}
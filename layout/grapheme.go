@@ -0,0 +1,79 @@
+package layout
+
+import (
+	"unicode"
+
+	ucd "github.com/benoitkugler/textlayout/unicodedata"
+)
+
+// graphemeBoundaries returns a slice of length len(text)+1, whose
+// i-th item is true if there is an extended grapheme cluster
+// boundary before text[i], following the rules of UAX #29
+// (see https://unicode.org/reports/tr29/#Grapheme_Cluster_Boundary_Rules).
+func graphemeBoundaries(text []rune) []bool {
+	out := make([]bool, len(text)+1)
+	if len(text) == 0 {
+		return out
+	}
+	out[0], out[len(text)] = true, true // GB1, GB2
+
+	var (
+		prev        = ucd.LookupGraphemeBreakClass(text[0])
+		inEmoji     = unicode.Is(ucd.Extended_Pictographic, text[0]) // ExtPict Extend*
+		emojiZWJ    = false                                          // ExtPict Extend* ZWJ
+		nbRegionals = 0                                              // consecutive RIs before the boundary
+	)
+	if prev == ucd.GraphemeBreakRegional_Indicator {
+		nbRegionals = 1
+	}
+	for i := 1; i < len(text); i++ {
+		r := text[i]
+		class := ucd.LookupGraphemeBreakClass(r)
+		isPictographic := unicode.Is(ucd.Extended_Pictographic, r)
+
+		out[i] = isGraphemeBoundary(prev, class, isPictographic && emojiZWJ, nbRegionals)
+
+		// update the state
+		emojiZWJ = inEmoji && class == ucd.GraphemeBreakZWJ
+		inEmoji = isPictographic || (inEmoji && class == ucd.GraphemeBreakExtend)
+		if class == ucd.GraphemeBreakRegional_Indicator {
+			nbRegionals++
+		} else {
+			nbRegionals = 0
+		}
+		prev = class
+	}
+	return out
+}
+
+// isGraphemeBoundary applies the rules GB3 to GB999 between
+// two runes with classes `prev` and `next`.
+func isGraphemeBoundary(prev, next *unicode.RangeTable, emojiSequence bool, nbRegionals int) bool {
+	switch {
+	case prev == ucd.GraphemeBreakCR && next == ucd.GraphemeBreakLF: // GB3
+		return false
+	case prev == ucd.GraphemeBreakControl || prev == ucd.GraphemeBreakCR || prev == ucd.GraphemeBreakLF: // GB4
+		return true
+	case next == ucd.GraphemeBreakControl || next == ucd.GraphemeBreakCR || next == ucd.GraphemeBreakLF: // GB5
+		return true
+	case prev == ucd.GraphemeBreakL && (next == ucd.GraphemeBreakL || next == ucd.GraphemeBreakV ||
+		next == ucd.GraphemeBreakLV || next == ucd.GraphemeBreakLVT): // GB6
+		return false
+	case (prev == ucd.GraphemeBreakLV || prev == ucd.GraphemeBreakV) &&
+		(next == ucd.GraphemeBreakV || next == ucd.GraphemeBreakT): // GB7
+		return false
+	case (prev == ucd.GraphemeBreakLVT || prev == ucd.GraphemeBreakT) && next == ucd.GraphemeBreakT: // GB8
+		return false
+	case next == ucd.GraphemeBreakExtend || next == ucd.GraphemeBreakZWJ: // GB9
+		return false
+	case next == ucd.GraphemeBreakSpacingMark: // GB9a
+		return false
+	case prev == ucd.GraphemeBreakPrepend: // GB9b
+		return false
+	case emojiSequence: // GB11
+		return false
+	case prev == ucd.GraphemeBreakRegional_Indicator && next == ucd.GraphemeBreakRegional_Indicator: // GB12, GB13
+		return nbRegionals%2 == 0
+	}
+	return true // GB999
+}
@@ -0,0 +1,47 @@
+package layout
+
+import "github.com/benoitkugler/textlayout/fonts/truetype"
+
+const softHyphen = 0x00AD
+
+// SoftHyphenBreaks returns the rune indexes at which `text` may be broken
+// thanks to a soft hyphen (U+00AD), that is, the indexes following the soft hyphens.
+// The shaper renders soft hyphens as invisible, zero-width glyphs : when a line is
+// actually broken at one of these positions, ShowSoftHyphen should be applied to the line.
+func SoftHyphenBreaks(text []rune) []int {
+	var out []int
+	for i, r := range text {
+		if r == softHyphen {
+			out = append(out, i+1)
+		}
+	}
+	return out
+}
+
+// ShowSoftHyphen returns a copy of `line`, a horizontal line shaped with `font`
+// and ending at rune `end` of `text`, where the glyph of a soft hyphen ending the line
+// is replaced by a visible hyphen. The hyphen glyph is U+2010 if the font supports it,
+// or U+002D otherwise.
+// If the line does not end with a soft hyphen, or if the font has no hyphen glyph,
+// the line is returned unchanged.
+func ShowSoftHyphen(line []PositionedGlyph, text []rune, end int, font *truetype.Font) []PositionedGlyph {
+	out := append([]PositionedGlyph(nil), line...)
+	if end < 1 || end > len(text) || text[end-1] != softHyphen {
+		return out
+	}
+	hyphen, ok := font.NominalGlyph(0x2010)
+	if !ok {
+		hyphen, ok = font.NominalGlyph('-')
+	}
+	if !ok {
+		return out
+	}
+	for i, g := range out {
+		if g.Cluster == end-1 {
+			out[i].GID = hyphen
+			out[i].XAdvance = font.HorizontalAdvance(hyphen)
+			break
+		}
+	}
+	return out
+}
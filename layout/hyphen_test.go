@@ -0,0 +1,54 @@
+package layout
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSoftHyphen(t *testing.T) {
+	font := loadFont(t, "DejaVuSerif.ttf")
+	text := "hy\u00ADphen\u00ADated"
+	runes := []rune(text)
+	if breaks := SoftHyphenBreaks(runes); !reflect.DeepEqual(breaks, []int{3, 8}) {
+		t.Fatalf("unexpected breaks %v", breaks)
+	}
+
+	hyphen, ok := font.NominalGlyph(0x2010)
+	if !ok {
+		hyphen, _ = font.NominalGlyph('-')
+	}
+	hasHyphen := func(glyphs []PositionedGlyph) bool {
+		for _, g := range glyphs {
+			if g.GID == hyphen {
+				return true
+			}
+		}
+		return false
+	}
+
+	glyphs := shape(font, text)
+	// without a line break, soft hyphens are invisible
+	if hasHyphen(glyphs) {
+		t.Fatal("unexpected visible hyphen")
+	}
+	if glyphs[2].XAdvance != 0 {
+		t.Fatalf("expected zero width soft hyphen, got %g", glyphs[2].XAdvance)
+	}
+
+	// break the line after "hy-"
+	first := ShowSoftHyphen(glyphsInRange(glyphs, 0, 3), runes, 3, font)
+	second := ShowSoftHyphen(glyphsInRange(glyphs, 3, len(runes)), runes, len(runes), font)
+	if len(first) != 3 || first[2].GID != hyphen {
+		t.Fatalf("expected hyphen at the end of the first line, got %v", first)
+	}
+	if first[2].XAdvance != font.HorizontalAdvance(hyphen) || first[2].XAdvance == 0 {
+		t.Fatalf("unexpected hyphen advance %g", first[2].XAdvance)
+	}
+	if hasHyphen(second) || second[4].XAdvance != 0 {
+		t.Fatalf("unexpected hyphen on the second line: %v", second)
+	}
+	// the original glyphs are not modified
+	if glyphs[2].GID == hyphen {
+		t.Fatal("original glyphs should not be modified")
+	}
+}
@@ -0,0 +1,112 @@
+// Package layout provides the building blocks required to
+// lay out shaped text : extents, spacing, font fallback, carets...
+//
+// Glyph positions are expressed in font units, as returned by
+// harfbuzz with the default font scale, whereas the results
+// of measurements are expressed in pixels.
+package layout
+
+import (
+	"sort"
+
+	"github.com/benoitkugler/textlayout/fonts"
+	"github.com/benoitkugler/textlayout/harfbuzz"
+)
+
+// PositionedGlyph is a glyph, as returned by a shaper.
+type PositionedGlyph struct {
+	GID fonts.GID
+
+	// Cluster is the index of the first rune rendered by the glyph,
+	// in the shaped text (see harfbuzz.GlyphInfo.Cluster).
+	Cluster int
+
+	// XAdvance and YAdvance are the advance of the pen after the glyph.
+	// XOffset and YOffset are the position of the glyph, relative to the pen.
+	XAdvance, YAdvance, XOffset, YOffset float32
+
+	// UnsafeToBreak is true if the text can't be broken at the start
+	// of the glyph cluster without shaping again both sides
+	// (see harfbuzz.GlyphUnsafeToBreak).
+	UnsafeToBreak bool
+}
+
+// FromBuffer returns the glyphs stored in a shaped buffer.
+func FromBuffer(buf *harfbuzz.Buffer) []PositionedGlyph {
+	out := make([]PositionedGlyph, len(buf.Info))
+	for i, info := range buf.Info {
+		pos := buf.Pos[i]
+		out[i] = PositionedGlyph{
+			GID:      info.Glyph,
+			Cluster:  info.Cluster,
+			XAdvance: float32(pos.XAdvance),
+			YAdvance: float32(pos.YAdvance),
+			XOffset:  float32(pos.XOffset),
+			YOffset:  float32(pos.YOffset),
+
+			UnsafeToBreak: info.Mask&harfbuzz.GlyphUnsafeToBreak != 0,
+		}
+	}
+	return out
+}
+
+// Cluster is a group of runes shaped together, such as a base
+// and its combining marks, or the components of a ligature.
+// A cluster can't be split by a line break or a spacing.
+type Cluster struct {
+	RuneStart, RuneEnd   int // runes [RuneStart, RuneEnd) of the text
+	GlyphStart, GlyphEnd int // glyphs [GlyphStart, GlyphEnd) of the run
+
+	// IsWordSeparator is true for spaces, as defined by CSS
+	// for the word-spacing property.
+	IsWordSeparator bool
+
+	// IsGraphemeStart is true if the cluster starts an extended grapheme
+	// cluster, that is if it is not a part of the previous one (in logical order),
+	// such as a combining mark shaped in its own cluster.
+	IsGraphemeStart bool
+}
+
+// Clusters groups `glyphs`, the result of the shaping of `text`, into clusters,
+// returned in glyph (visual) order. Glyphs of the same cluster must be contiguous,
+// which is always the case with the default harfbuzz cluster level.
+func Clusters(glyphs []PositionedGlyph, text []rune) []Cluster {
+	var out []Cluster
+	for i, glyph := range glyphs {
+		if i == 0 || glyph.Cluster != glyphs[i-1].Cluster {
+			out = append(out, Cluster{RuneStart: glyph.Cluster, GlyphStart: i})
+		}
+		out[len(out)-1].GlyphEnd = i + 1
+	}
+
+	// a cluster ends where the following one, in logical order, starts
+	starts := make([]int, len(out))
+	for i, cl := range out {
+		starts[i] = cl.RuneStart
+	}
+	sort.Ints(starts)
+	boundaries := graphemeBoundaries(text)
+	for i := range out {
+		cl := &out[i]
+		next := sort.SearchInts(starts, cl.RuneStart+1)
+		if next < len(starts) {
+			cl.RuneEnd = starts[next]
+		} else {
+			cl.RuneEnd = len(text)
+		}
+		if cl.RuneStart < len(text) {
+			cl.IsWordSeparator = isWordSeparator(text[cl.RuneStart])
+			cl.IsGraphemeStart = boundaries[cl.RuneStart]
+		}
+	}
+	return out
+}
+
+// see https://www.w3.org/TR/css-text-3/#word-separator
+func isWordSeparator(r rune) bool {
+	switch r {
+	case 0x0020, 0x00A0, 0x1361, 0x10100, 0x10101, 0x1039F, 0x1091F:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,45 @@
+package layout
+
+import (
+	"bytes"
+	"testing"
+
+	testdata "github.com/benoitkugler/textlayout-testdata/truetype"
+	"github.com/benoitkugler/textlayout/fonts/truetype"
+	"github.com/benoitkugler/textlayout/harfbuzz"
+)
+
+func loadFont(t testing.TB, filename string) *truetype.Font {
+	file, err := testdata.Files.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	font, err := truetype.Parse(bytes.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return font
+}
+
+func shape(font *truetype.Font, text string) []PositionedGlyph {
+	buf := harfbuzz.NewBuffer()
+	runes := []rune(text)
+	buf.AddRunes(runes, 0, len(runes))
+	buf.GuessSegmentProperties()
+	buf.Shape(harfbuzz.NewFont(font), nil)
+	return FromBuffer(buf)
+}
+
+func TestFromBuffer(t *testing.T) {
+	font := loadFont(t, "DejaVuSerif.ttf")
+	glyphs := shape(font, "Hi")
+	if len(glyphs) != 2 {
+		t.Fatalf("expected 2 glyphs, got %d", len(glyphs))
+	}
+	for i, r := range "Hi" {
+		gid, _ := font.NominalGlyph(r)
+		if g := glyphs[i]; g.GID != gid || g.Cluster != i || g.XAdvance != font.HorizontalAdvance(gid) {
+			t.Fatalf("unexpected glyph %v", g)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package layout
+
+// ParagraphBreak is the hard line break ending a paragraph.
+type ParagraphBreak uint8
+
+const (
+	// BreakEndOfText is used for the last paragraph, not followed by a separator.
+	BreakEndOfText          ParagraphBreak = iota
+	BreakLF                                // U+000A
+	BreakCR                                // U+000D
+	BreakCRLF                              // U+000D U+000A
+	BreakNextLine                          // U+0085
+	BreakLineSeparator                     // U+2028
+	BreakParagraphSeparator                // U+2029
+	BreakOther                             // vertical tab U+000B or form feed U+000C
+)
+
+// len returns the number of runes of the separator
+func (pb ParagraphBreak) len() int {
+	switch pb {
+	case BreakEndOfText:
+		return 0
+	case BreakCRLF:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// ParagraphRange is a paragraph of a text, delimited by hard line breaks.
+type ParagraphRange struct {
+	// Start and End delimit the runes [Start, End) of the paragraph,
+	// excluding the separator, which starts at End.
+	Start, End int
+	Break      ParagraphBreak
+}
+
+// SplitParagraphs splits `text` at the mandatory breaks defined by UAX #14
+// (class BK, CR, LF and NL), CR LF being a single break.
+// Each paragraph should then be shaped (and reordered by the bidi algorithm) independently.
+// A separator ending the text does not start an empty paragraph, and
+// an empty text has no paragraph.
+func SplitParagraphs(text []rune) []ParagraphRange {
+	var (
+		out   []ParagraphRange
+		start int
+	)
+	for i := 0; i < len(text); i++ {
+		var pb ParagraphBreak
+		switch text[i] {
+		case '\n':
+			pb = BreakLF
+		case '\r':
+			pb = BreakCR
+			if i+1 < len(text) && text[i+1] == '\n' {
+				pb = BreakCRLF
+			}
+		case 0x0085:
+			pb = BreakNextLine
+		case 0x2028:
+			pb = BreakLineSeparator
+		case 0x2029:
+			pb = BreakParagraphSeparator
+		case 0x000B, 0x000C:
+			pb = BreakOther
+		default:
+			continue
+		}
+		out = append(out, ParagraphRange{Start: start, End: i, Break: pb})
+		start = i + pb.len()
+		i = start - 1
+	}
+	if start < len(text) {
+		out = append(out, ParagraphRange{Start: start, End: len(text), Break: BreakEndOfText})
+	}
+	return out
+}
@@ -0,0 +1,37 @@
+package layout
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitParagraphs(t *testing.T) {
+	for _, test := range []struct {
+		text     string
+		expected []ParagraphRange
+	}{
+		{"", nil},
+		{"abc", []ParagraphRange{{0, 3, BreakEndOfText}}},
+		{"ab\r\ncd\u2029ef", []ParagraphRange{
+			{0, 2, BreakCRLF},
+			{4, 6, BreakParagraphSeparator},
+			{7, 9, BreakEndOfText},
+		}},
+		{"ab\n\rcd\r\n", []ParagraphRange{
+			{0, 2, BreakLF},
+			{3, 3, BreakCR},
+			{4, 6, BreakCRLF},
+		}},
+		{"\u2028a\u0085b\u000cc\r", []ParagraphRange{
+			{0, 0, BreakLineSeparator},
+			{1, 2, BreakNextLine},
+			{3, 4, BreakOther},
+			{5, 6, BreakCR},
+		}},
+		{"a\tb", []ParagraphRange{{0, 3, BreakEndOfText}}}, // tabs are not breaks
+	} {
+		if got := SplitParagraphs([]rune(test.text)); !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("%q: expected %v, got %v", test.text, test.expected, got)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package layout
+
+import (
+	"math"
+
+	"github.com/benoitkugler/textlayout/fonts/truetype"
+)
+
+// Rounding selects how positions are snapped to the pixel grid.
+type Rounding uint8
+
+const (
+	RoundNone    Rounding = iota // fractional positions are kept, as for animated text
+	RoundNearest                 // positions are rounded to the nearest pixel
+	RoundFloor                   // positions are rounded down
+	RoundCeil                    // positions are rounded up
+)
+
+func (r Rounding) apply(v float32) float32 {
+	switch r {
+	case RoundNearest:
+		return float32(math.Round(float64(v)))
+	case RoundFloor:
+		return float32(math.Floor(float64(v)))
+	case RoundCeil:
+		return float32(math.Ceil(float64(v)))
+	default:
+		return v
+	}
+}
+
+// PositioningOptions controls the conversion of the glyph positions
+// to device units. The zero value keeps fractional positions.
+type PositioningOptions struct {
+	// RoundX applies to XAdvance and XOffset, RoundY to YAdvance and YOffset.
+	RoundX, RoundY Rounding
+}
+
+// ToPixels returns a copy of `glyphs`, shaped with `font`, with positions
+// converted to pixels for a size of `ppem` pixels per em, and then rounded.
+// Pixel snapping is suitable for hinted text, whereas fractional
+// positions are required by sub-pixel positioning.
+func (opts PositioningOptions) ToPixels(glyphs []PositionedGlyph, font *truetype.Font, ppem float32) []PositionedGlyph {
+	scale := font.ScaleFactor(ppem)
+	out := make([]PositionedGlyph, len(glyphs))
+	for i, g := range glyphs {
+		g.XAdvance = opts.RoundX.apply(g.XAdvance * scale)
+		g.XOffset = opts.RoundX.apply(g.XOffset * scale)
+		g.YAdvance = opts.RoundY.apply(g.YAdvance * scale)
+		g.YOffset = opts.RoundY.apply(g.YOffset * scale)
+		out[i] = g
+	}
+	return out
+}
@@ -0,0 +1,47 @@
+package layout
+
+import (
+	"math"
+	"testing"
+)
+
+func isInteger(v float32) bool { return v == float32(math.Trunc(float64(v))) }
+
+func TestToPixels(t *testing.T) {
+	font := loadFont(t, "DejaVuSerif.ttf")
+	glyphs := shape(font, "Ax\u0301q\u0323")
+	const ppem = 13
+
+	// the default keeps fractional positions
+	smooth := PositioningOptions{}.ToPixels(glyphs, font, ppem)
+	hasFractional, hasOffset := false, false
+	for i, g := range smooth {
+		if g.XAdvance != glyphs[i].XAdvance*font.ScaleFactor(ppem) || g.XOffset != glyphs[i].XOffset*font.ScaleFactor(ppem) {
+			t.Fatalf("unexpected position %v", g)
+		}
+		hasFractional = hasFractional || !isInteger(g.XAdvance) || !isInteger(g.XOffset)
+		hasOffset = hasOffset || g.XOffset != 0
+	}
+	if !hasFractional || !hasOffset {
+		t.Fatalf("expected fractional offsets, got %v", smooth)
+	}
+
+	crisp := PositioningOptions{RoundX: RoundNearest}.ToPixels(glyphs, font, ppem)
+	for i, g := range crisp {
+		if !isInteger(g.XAdvance) || !isInteger(g.XOffset) {
+			t.Fatalf("expected integer x positions, got %v", g)
+		}
+		if d := g.XOffset - smooth[i].XOffset; d < -0.5 || d > 0.5 {
+			t.Fatalf("unexpected rounding from %v to %v", smooth[i], g)
+		}
+		if g.YOffset != smooth[i].YOffset {
+			t.Fatalf("unexpected y rounding from %v to %v", smooth[i], g)
+		}
+	}
+
+	for _, g := range (PositioningOptions{RoundX: RoundFloor, RoundY: RoundCeil}).ToPixels(glyphs, font, ppem) {
+		if !isInteger(g.XOffset) || !isInteger(g.YOffset) {
+			t.Fatalf("expected integer positions, got %v", g)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package layout
+
+import (
+	"sort"
+
+	"github.com/benoitkugler/textlayout/fonts/truetype"
+	"github.com/benoitkugler/textlayout/harfbuzz"
+)
+
+// Reshape updates `prev`, the result of ShapeWithFallback, after an edit
+// of the text, avoiding to shape the whole text again.
+// The runes [editStart, editEnd) of the previous text have been replaced, so that
+// the new text is `text`. The other arguments must be the ones used for `prev`.
+//
+// Only the runs touched by the edit are shaped again, and only between
+// the nearest boundaries which are safe to break (see PositionedGlyph.UnsafeToBreak)
+// around the edit. The other runs are reused, with their clusters shifted.
+// `prev` is not modified.
+func Reshape(prev []GlyphRun, text []rune, editStart, editEnd int, props harfbuzz.SegmentProperties,
+	primary *truetype.Font, provider FontProvider, options FallbackOptions) []GlyphRun {
+	if len(prev) == 0 {
+		return ShapeWithFallback(text, props, primary, provider, options)
+	}
+	delta := len(text) - prev[len(prev)-1].End
+
+	first, last, start, end := reshapedRange(prev, editStart, editEnd)
+	if first == -1 { // invalid edit range
+		return ShapeWithFallback(text, props, primary, provider, options)
+	}
+
+	out := append([]GlyphRun(nil), prev[:first]...)
+	if prefix := glyphsInRange(prev[first].Glyphs, prev[first].Start, start); len(prefix) != 0 {
+		out = append(out, GlyphRun{Font: prev[first].Font, Start: prev[first].Start, End: start, Glyphs: prefix})
+	}
+
+	backward := props.Direction == harfbuzz.RightToLeft || props.Direction == harfbuzz.BottomToTop
+	for _, run := range shapeRange(text, start, end+delta, props, primary, provider, options, newFallbackState(primary)) {
+		out = appendRun(out, run, backward)
+	}
+
+	if suffix := glyphsInRange(prev[last].Glyphs, end, prev[last].End); len(suffix) != 0 {
+		run := shiftRun(GlyphRun{Font: prev[last].Font, Start: end, End: prev[last].End, Glyphs: suffix}, delta)
+		out = appendRun(out, run, backward)
+	}
+	for _, run := range prev[last+1:] {
+		out = append(out, shiftRun(run, delta))
+	}
+	return out
+}
+
+// reshapedRange selects the runs [first, last] touched by the edit [editStart, editEnd),
+// and returns the runes [start, end) of the previous text which must be shaped again.
+// `first` is -1 if the edit range is invalid.
+func reshapedRange(prev []GlyphRun, editStart, editEnd int) (first, last, start, end int) {
+	first, last = -1, -1
+	for i, run := range prev {
+		if run.Start <= editEnd && run.End >= editStart {
+			if first == -1 {
+				first = i
+			}
+			last = i
+		}
+	}
+	if first == -1 {
+		return -1, -1, 0, 0
+	}
+	start, end = safeBoundaries(prev[first], prev[last], editStart, editEnd)
+	return first, last, start, end
+}
+
+// safeBoundaries returns the closest positions around [editStart, editEnd)
+// where the text may be broken, inside the runs [first, last].
+// Since the flags only apply to the previous text, at least one
+// unchanged cluster is kept between the boundaries and the edit:
+// for instance, removing the first 'f' of "ff|i" gives "fi", with a ligature.
+func safeBoundaries(first, last GlyphRun, editStart, editEnd int) (start, end int) {
+	start, end = first.Start, last.End
+
+	// the cluster [starts[i], starts[i+1]) must end before the edit
+	starts, safe := safeClusters(first)
+	for i := 0; i+1 < len(starts) && starts[i+1] <= editStart; i++ {
+		if safe[i] {
+			start = starts[i]
+		}
+	}
+
+	// the cluster [starts[i-1], starts[i]) must start after the edit
+	starts, safe = safeClusters(last)
+	for i := len(starts) - 1; i >= 1 && starts[i-1] >= editEnd; i-- {
+		if safe[i] {
+			end = starts[i]
+		}
+	}
+	return start, end
+}
+
+// safeClusters returns the sorted starts of the clusters of `run`, followed by its end,
+// and whether the text is safe to break at these positions
+func safeClusters(run GlyphRun) (starts []int, safe []bool) {
+	unsafe := make(map[int]bool)
+	for _, g := range run.Glyphs {
+		unsafe[g.Cluster] = unsafe[g.Cluster] || g.UnsafeToBreak
+	}
+	for cluster := range unsafe {
+		starts = append(starts, cluster)
+	}
+	sort.Ints(starts)
+	starts = append(starts, run.End)
+	safe = make([]bool, len(starts))
+	for i, cluster := range starts {
+		safe[i] = !unsafe[cluster]
+	}
+	return starts, safe
+}
+
+// shiftRun returns a copy of `run`, moved by `delta` runes
+func shiftRun(run GlyphRun, delta int) GlyphRun {
+	if delta == 0 {
+		return run
+	}
+	glyphs := append([]PositionedGlyph(nil), run.Glyphs...)
+	for i := range glyphs {
+		glyphs[i].Cluster += delta
+	}
+	return GlyphRun{Font: run.Font, Start: run.Start + delta, End: run.End + delta, Glyphs: glyphs}
+}
+
+// appendRun adds `run` to `runs`, merging it with the last run if they share the same font.
+// `backward` is true if glyphs are in reverse logical order.
+func appendRun(runs []GlyphRun, run GlyphRun, backward bool) []GlyphRun {
+	if len(runs) == 0 {
+		return append(runs, run)
+	}
+	lastRun := runs[len(runs)-1]
+	if lastRun.Font != run.Font || lastRun.End != run.Start {
+		return append(runs, run)
+	}
+	var glyphs []PositionedGlyph
+	if backward {
+		glyphs = append(append(glyphs, run.Glyphs...), lastRun.Glyphs...)
+	} else {
+		glyphs = append(append(glyphs, lastRun.Glyphs...), run.Glyphs...)
+	}
+	runs[len(runs)-1] = GlyphRun{Font: run.Font, Start: lastRun.Start, End: run.End, Glyphs: glyphs}
+	return runs
+}
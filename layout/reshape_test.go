@@ -0,0 +1,95 @@
+package layout
+
+import (
+	"testing"
+
+	"github.com/benoitkugler/textlayout/harfbuzz"
+	"github.com/benoitkugler/textlayout/language"
+)
+
+func assertSameRuns(t *testing.T, got, expected []GlyphRun) {
+	t.Helper()
+	if len(got) != len(expected) {
+		t.Fatalf("expected %d runs, got %d", len(expected), len(got))
+	}
+	for i, run := range got {
+		exp := expected[i]
+		if run.Font != exp.Font || run.Start != exp.Start || run.End != exp.End || len(run.Glyphs) != len(exp.Glyphs) {
+			t.Fatalf("run %d: expected [%d, %d), got [%d, %d)", i, exp.Start, exp.End, run.Start, run.End)
+		}
+		for j, g := range run.Glyphs {
+			e := exp.Glyphs[j]
+			if g.GID != e.GID || g.Cluster != e.Cluster || g.XAdvance != e.XAdvance || g.XOffset != e.XOffset || g.YOffset != e.YOffset {
+				t.Fatalf("run %d, glyph %d: expected %v, got %v", i, j, e, g)
+			}
+		}
+	}
+}
+
+func TestReshape(t *testing.T) {
+	latin := loadFont(t, "DejaVuSerif.ttf")
+	emoji := loadFont(t, "NotoColorEmoji.ttf")
+	provider := fontList{emoji}
+	props := harfbuzz.SegmentProperties{Direction: harfbuzz.LeftToRight, Script: language.Latin}
+
+	before := []rune("An efficient office \U0001F600 with coffee")
+	prev := ShapeWithFallback(before, props, latin, provider, FallbackOptions{})
+	if len(prev) != 3 {
+		t.Fatalf("unexpected runs %v", prev)
+	}
+
+	for _, test := range []struct {
+		text               string
+		editStart, editEnd int
+	}{
+		{"An efficient offIce \U0001F600 with coffee", 16, 17},  // replacement breaking a ligature
+		{"An efficient oiffice \U0001F600 with coffee", 14, 14}, // insertion
+		{"An eficient office \U0001F600 with coffee", 4, 5},     // deletion
+		{"An efficient office \U0001F600 with cofee", 29, 30},   // in the last run
+		{"An efficient office \U0001F600\U0001F600 with coffee", 20, 20},
+		{"An efficient office \U0001F600 with coffee!", 33, 33}, // at the end
+	} {
+		text := []rune(test.text)
+		got := Reshape(prev, text, test.editStart, test.editEnd, props, latin, provider, FallbackOptions{})
+		assertSameRuns(t, got, ShapeWithFallback(text, props, latin, provider, FallbackOptions{}))
+	}
+}
+
+func TestReshapedRange(t *testing.T) {
+	// one glyph per rune, with the given unsafe to break clusters
+	run := func(start, end int, unsafe ...int) GlyphRun {
+		out := GlyphRun{Start: start, End: end}
+		for i := start; i < end; i++ {
+			out.Glyphs = append(out.Glyphs, PositionedGlyph{Cluster: i})
+		}
+		for _, cluster := range unsafe {
+			out.Glyphs[cluster-start].UnsafeToBreak = true
+		}
+		return out
+	}
+
+	for _, test := range []struct {
+		prev               []GlyphRun
+		editStart, editEnd int
+		first, last        int
+		start, end         int
+	}{
+		// one unchanged cluster is kept on each side of the edit
+		{[]GlyphRun{run(0, 10)}, 5, 6, 0, 0, 4, 7},
+		{[]GlyphRun{run(0, 10)}, 5, 5, 0, 0, 4, 6},
+		// the boundaries move to the nearest safe clusters
+		{[]GlyphRun{run(0, 10, 3, 4, 7)}, 5, 6, 0, 0, 2, 8},
+		{[]GlyphRun{run(0, 10, 1, 2, 3, 4, 7, 8, 9)}, 5, 6, 0, 0, 0, 10},
+		// only the runs touched by the edit are used
+		{[]GlyphRun{run(0, 4), run(4, 6), run(6, 10)}, 7, 8, 2, 2, 6, 9},
+		{[]GlyphRun{run(0, 4), run(4, 6), run(6, 10)}, 3, 7, 0, 2, 2, 8},
+		// invalid edit
+		{[]GlyphRun{run(0, 4)}, 6, 7, -1, -1, 0, 0},
+	} {
+		first, last, start, end := reshapedRange(test.prev, test.editStart, test.editEnd)
+		if first != test.first || last != test.last || start != test.start || end != test.end {
+			t.Fatalf("for edit [%d, %d), expected runs [%d, %d] and range [%d, %d), got [%d, %d] and [%d, %d)",
+				test.editStart, test.editEnd, test.first, test.last, test.start, test.end, first, last, start, end)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package layout
+
+import (
+	"sync"
+
+	"github.com/benoitkugler/textlayout/fonts"
+	"github.com/benoitkugler/textlayout/fonts/truetype"
+	"github.com/benoitkugler/textlayout/harfbuzz"
+)
+
+var tagOpticalSize = truetype.MustNewTag("opsz")
+
+// ShapeOptions controls the font instance used by Shape.
+type ShapeOptions struct {
+	// Variations are the values of the variable axes of the font,
+	// expressed in design units. Axes not specified keep the
+	// coordinates currently set on the font.
+	Variations []truetype.Variation
+
+	// Size is the font size, in points.
+	Size float32
+
+	// DisableAutoOpticalSize, if true, does not set the 'opsz' axis of
+	// variable fonts. By default, the 'opsz' axis is set to Size,
+	// unless Variations already specifies it, as CSS does
+	// with 'font-optical-sizing: auto'.
+	DisableAutoOpticalSize bool
+
+	// NormalizationMode, if not zero, overrides the Unicode
+	// normalization preferred by the shaper.
+	NormalizationMode harfbuzz.NormalizationMode
+
+	// DottedCircle is the glyph inserted by the shaper for broken clusters,
+	// when the font has no glyph for U+25CC.
+	// Zero (the default) means that no dotted circle is inserted for such fonts.
+	DottedCircle fonts.GID
+}
+
+// dottedCircleFace uses a fallback glyph for U+25CC
+type dottedCircleFace struct {
+	*truetype.Font
+	dottedCircle fonts.GID
+}
+
+func (f *dottedCircleFace) NominalGlyph(r rune) (fonts.GID, bool) {
+	glyph, ok := f.Font.NominalGlyph(r)
+	if !ok && r == 0x25CC {
+		return f.dottedCircle, true
+	}
+	return glyph, ok
+}
+
+type dottedCircleKey struct {
+	font         *truetype.Font
+	dottedCircle fonts.GID
+}
+
+// dottedCircleFaces stores one face per font and fallback glyph,
+// so that the shaping plans cached by harfbuzz for a face are reused
+var dottedCircleFaces sync.Map // dottedCircleKey -> *dottedCircleFace
+
+func newDottedCircleFace(font *truetype.Font, dottedCircle fonts.GID) *dottedCircleFace {
+	key := dottedCircleKey{font: font, dottedCircle: dottedCircle}
+	if face, ok := dottedCircleFaces.Load(key); ok {
+		return face.(*dottedCircleFace)
+	}
+	face, _ := dottedCircleFaces.LoadOrStore(key, &dottedCircleFace{Font: font, dottedCircle: dottedCircle})
+	return face.(*dottedCircleFace)
+}
+
+// variations returns the variations to apply to `font`,
+// which are empty if the font instance should not be changed
+func (opts ShapeOptions) variations(font *truetype.Font) []truetype.Variation {
+	if opts.DisableAutoOpticalSize || opts.Size <= 0 {
+		return opts.Variations
+	}
+	for _, v := range opts.Variations {
+		if v.Tag == tagOpticalSize {
+			return opts.Variations
+		}
+	}
+	for _, axis := range font.Variations().Axis {
+		if axis.Tag == tagOpticalSize {
+			out := append([]truetype.Variation(nil), opts.Variations...)
+			return append(out, truetype.Variation{Tag: tagOpticalSize, Value: opts.Size})
+		}
+	}
+	return opts.Variations
+}
+
+// updateCoordinates changes the coordinates of `font` for the axes
+// of `variations` only, starting from its current coordinates
+func updateCoordinates(font *truetype.Font, variations []truetype.Variation) {
+	fvar := font.Variations()
+	if len(variations) == 0 || len(fvar.Axis) == 0 {
+		return
+	}
+	target := font.NormalizeVariations(fvar.GetDesignCoordsDefault(variations))
+
+	coords := make([]float32, len(fvar.Axis))
+	copy(coords, font.VarCoordinates())
+	for i, axis := range fvar.Axis {
+		for _, v := range variations {
+			if v.Tag == axis.Tag {
+				coords[i] = target[i]
+			}
+		}
+	}
+	font.SetVarCoordinates(coords)
+}
+
+// Shape shapes `text` with `font`, after selecting the variable
+// font instance described by `options`, and with its normalization mode.
+// Note that the variation coordinates of `font` are modified for the axes
+// set by `options` (including the 'opsz' axis when optical sizing applies),
+// so that the glyphs may be rendered with the same instance.
+// The other axes keep the coordinates previously set on `font`.
+func Shape(text []rune, props harfbuzz.SegmentProperties, font *truetype.Font, options ShapeOptions) []PositionedGlyph {
+	updateCoordinates(font, options.variations(font))
+
+	buf := harfbuzz.NewBuffer()
+	buf.AddRunes(text, 0, len(text))
+	buf.Props = props
+	buf.Normalization = options.NormalizationMode
+	var face harfbuzz.Face = font
+	if options.DottedCircle != 0 {
+		face = newDottedCircleFace(font, options.DottedCircle)
+	}
+	buf.Shape(harfbuzz.NewFont(face), nil)
+	return FromBuffer(buf)
+}
@@ -0,0 +1,121 @@
+package layout
+
+import (
+	"bytes"
+	"testing"
+
+	hbtestdata "github.com/benoitkugler/textlayout-testdata/harfbuzz"
+	"github.com/benoitkugler/textlayout/fonts/truetype"
+	"github.com/benoitkugler/textlayout/harfbuzz"
+	"github.com/benoitkugler/textlayout/language"
+)
+
+func TestShapeOpticalSize(t *testing.T) {
+	// this font has an 'opsz' axis, from 12 to 72 points,
+	// which changes the advance of the space
+	file, err := hbtestdata.Files.ReadFile("harfbuzz_reference/text-rendering-tests/fonts/TestCVARGVAROne.ttf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	font, err := truetype.Parse(bytes.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := []rune(" ")
+	props := harfbuzz.SegmentProperties{Direction: harfbuzz.LeftToRight, Script: language.Latin}
+
+	small := Shape(text, props, font, ShapeOptions{Size: 8})
+	large := Shape(text, props, font, ShapeOptions{Size: 72})
+	if small[0].XAdvance == large[0].XAdvance {
+		t.Fatalf("expected different advances, got %g", small[0].XAdvance)
+	}
+
+	// explicit variations take precedence
+	options := ShapeOptions{Size: 72}
+	options.Variations = []truetype.Variation{{Tag: truetype.MustNewTag("opsz"), Value: 8}}
+	if glyphs := Shape(text, props, font, options); glyphs[0].XAdvance != small[0].XAdvance {
+		t.Fatalf("expected advance %g, got %g", small[0].XAdvance, glyphs[0].XAdvance)
+	}
+
+	// without variations nor optical sizing, the coordinates of the font are kept
+	truetype.SetVariations(font, []truetype.Variation{{Tag: truetype.MustNewTag("opsz"), Value: 72}})
+	for _, options := range []ShapeOptions{{}, {Size: 8, DisableAutoOpticalSize: true}} {
+		if glyphs := Shape(text, props, font, options); glyphs[0].XAdvance != large[0].XAdvance {
+			t.Fatalf("expected advance %g, got %g", large[0].XAdvance, glyphs[0].XAdvance)
+		}
+	}
+}
+
+func TestShapeKeepsCoordinates(t *testing.T) {
+	font := loadFont(t, "Commissioner-VF.ttf")
+	wght, slnt := truetype.MustNewTag("wght"), truetype.MustNewTag("slnt")
+	text := []rune("$")
+	props := harfbuzz.SegmentProperties{Direction: harfbuzz.LeftToRight, Script: language.Latin}
+
+	// the weight is set before shaping
+	truetype.SetVariations(font, []truetype.Variation{{Tag: wght, Value: 900}})
+	heavy := font.VarCoordinates()[0]
+	if heavy == 0 {
+		t.Fatal("expected a non default weight")
+	}
+
+	options := ShapeOptions{Size: 12, Variations: []truetype.Variation{{Tag: slnt, Value: -12}}}
+	Shape(text, props, font, options)
+	coords := font.VarCoordinates()
+	if coords[0] != heavy {
+		t.Fatalf("expected the weight coordinate %g to be kept, got %g", heavy, coords[0])
+	}
+	if coords[1] == 0 {
+		t.Fatal("expected the slant to be applied")
+	}
+}
+
+func TestShapeNormalizationMode(t *testing.T) {
+	font := loadFont(t, "DejaVuSerif.ttf")
+	text := []rune("été")
+	props := harfbuzz.SegmentProperties{Direction: harfbuzz.LeftToRight, Script: language.Latin}
+
+	if glyphs := Shape(text, props, font, ShapeOptions{}); len(glyphs) != 3 {
+		t.Fatalf("expected precomposed glyphs, got %v", glyphs)
+	}
+	glyphs := Shape(text, props, font, ShapeOptions{NormalizationMode: harfbuzz.NormalizationDecomposed})
+	if len(glyphs) != 5 {
+		t.Fatalf("expected decomposed glyphs, got %v", glyphs)
+	}
+	if clusters := Clusters(glyphs, text); len(clusters) != 3 {
+		t.Fatalf("expected 3 clusters, got %v", clusters)
+	}
+}
+
+func TestShapeDottedCircle(t *testing.T) {
+	font := loadFont(t, "Roboto-BoldItalic.ttf")
+	if _, ok := font.NominalGlyph(0x25CC); ok {
+		t.Fatal("expected a font without dotted circle")
+	}
+	text := []rune("\u17B6") // a lone vowel sign is a broken cluster
+	props := harfbuzz.SegmentProperties{Direction: harfbuzz.LeftToRight, Script: language.Khmer}
+
+	// by default, no dotted circle is inserted
+	glyphs := Shape(text, props, font, ShapeOptions{})
+	if len(glyphs) != 1 {
+		t.Fatalf("expected no dotted circle, got %v", glyphs)
+	}
+
+	circle, _ := font.NominalGlyph('o')
+	glyphs = Shape(text, props, font, ShapeOptions{DottedCircle: circle})
+	if len(glyphs) != 2 {
+		t.Fatalf("expected a dotted circle, got %v", glyphs)
+	}
+	var found bool
+	for _, g := range glyphs {
+		found = found || g.GID == circle
+	}
+	if !found {
+		t.Fatalf("expected the fallback dotted circle glyph %d, got %v", circle, glyphs)
+	}
+
+	// the face is reused, so that its shaping plans are cached only once
+	if newDottedCircleFace(font, circle) != newDottedCircleFace(font, circle) {
+		t.Fatal("expected the same dotted circle face")
+	}
+}
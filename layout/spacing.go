@@ -0,0 +1,35 @@
+package layout
+
+// ApplySpacing adds `letterSpacing` between each pair of visually adjacent
+// grapheme clusters, and `wordSpacing` after each word separator, by adjusting
+// the horizontal advances of `glyphs` (in place). `clusters` is the result of
+// Clusters, and the spacings are expressed in font units.
+// As required by CSS, no letter spacing is added at the edges of the run, for
+// both left-to-right and right-to-left text, and the spacing is added after
+// the last glyph of a cluster, so that ligatures and combining sequences
+// are never split.
+func ApplySpacing(glyphs []PositionedGlyph, clusters []Cluster, letterSpacing, wordSpacing float32) {
+	for i, cl := range clusters {
+		if cl.GlyphEnd <= cl.GlyphStart || cl.GlyphEnd > len(glyphs) {
+			continue
+		}
+		var spacing float32
+		if i+1 < len(clusters) && startsGrapheme(cl, clusters[i+1]) {
+			spacing += letterSpacing
+		}
+		if cl.IsWordSeparator {
+			spacing += wordSpacing
+		}
+		glyphs[cl.GlyphEnd-1].XAdvance += spacing
+	}
+}
+
+// startsGrapheme returns true if there is a grapheme boundary
+// between the visually adjacent clusters `left` and `right`,
+// that is if the logically last one starts a grapheme
+func startsGrapheme(left, right Cluster) bool {
+	if right.RuneStart > left.RuneStart {
+		return right.IsGraphemeStart
+	}
+	return left.IsGraphemeStart
+}
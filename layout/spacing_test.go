@@ -0,0 +1,108 @@
+package layout
+
+import "testing"
+
+func advance(glyphs []PositionedGlyph) (out float32) {
+	for _, g := range glyphs {
+		out += g.XAdvance
+	}
+	return out
+}
+
+func TestClusters(t *testing.T) {
+	font := loadFont(t, "DejaVuSerif.ttf")
+	text := []rune("fin office")
+	glyphs := shape(font, string(text))
+	clusters := Clusters(glyphs, text)
+	// the fi and ff ligatures are single clusters
+	if len(clusters) != 8 {
+		t.Fatalf("expected 8 clusters, got %v", clusters)
+	}
+	if cl := clusters[0]; cl.RuneStart != 0 || cl.RuneEnd != 2 || cl.GlyphStart != 0 || cl.GlyphEnd != 1 {
+		t.Fatalf("unexpected ligature cluster %v", cl)
+	}
+	if cl := clusters[4]; cl.RuneStart != 5 || cl.RuneEnd != 7 {
+		t.Fatalf("unexpected ligature cluster %v", cl)
+	}
+	for i, cl := range clusters {
+		if cl.IsWordSeparator != (i == 2) {
+			t.Fatalf("unexpected word separator %v", cl)
+		}
+	}
+}
+
+func TestApplySpacing(t *testing.T) {
+	font := loadFont(t, "DejaVuSerif.ttf")
+	text := []rune("Hello world")
+	glyphs := shape(font, string(text))
+	width := advance(glyphs)
+
+	const letter, word = 100, 300
+	ApplySpacing(glyphs, Clusters(glyphs, text), letter, word)
+	if got, exp := advance(glyphs), width+10*letter+word; got != exp {
+		t.Fatalf("expected width %g, got %g", exp, got)
+	}
+
+	// ligatures are not split
+	text = []rune("fin")
+	glyphs = shape(font, string(text))
+	width = advance(glyphs)
+	ApplySpacing(glyphs, Clusters(glyphs, text), letter, word)
+	if got, exp := advance(glyphs), width+letter; got != exp {
+		t.Fatalf("expected width %g, got %g", exp, got)
+	}
+}
+
+func TestApplySpacingRTL(t *testing.T) {
+	font := loadFont(t, "FreeSerif.ttf")
+	text := []rune("שלום")
+	glyphs := shape(font, string(text))
+	if len(glyphs) != 4 || glyphs[0].Cluster != 3 {
+		t.Fatalf("expected visual order, got %v", glyphs)
+	}
+	width := advance(glyphs)
+
+	ApplySpacing(glyphs, Clusters(glyphs, text), 100, 0)
+	if got, exp := advance(glyphs), width+3*100; got != exp {
+		t.Fatalf("expected width %g, got %g", exp, got)
+	}
+	// the spacing is between visual neighbours : the left-most glyph (the
+	// logical last letter) is followed by a space, but not the right-most one
+	// (the logical first letter)
+	if glyphs[0].XAdvance != font.HorizontalAdvance(glyphs[0].GID)+100 {
+		t.Fatal("missing spacing between the last two letters")
+	}
+	if glyphs[3].XAdvance != font.HorizontalAdvance(glyphs[3].GID) {
+		t.Fatal("unexpected spacing before the first letter")
+	}
+}
+
+func TestApplySpacingGraphemes(t *testing.T) {
+	// a base and its combining mark, shaped in separate clusters,
+	// followed by an other letter
+	text := []rune("e\u0301x")
+	for _, test := range []struct {
+		glyphs   []PositionedGlyph
+		expected []float32
+	}{
+		{ // left to right
+			[]PositionedGlyph{{Cluster: 0, XAdvance: 10}, {Cluster: 1}, {Cluster: 2, XAdvance: 10}},
+			[]float32{10, 1, 10},
+		},
+		{ // right to left
+			[]PositionedGlyph{{Cluster: 2, XAdvance: 10}, {Cluster: 1}, {Cluster: 0, XAdvance: 10}},
+			[]float32{11, 0, 10},
+		},
+	} {
+		clusters := Clusters(test.glyphs, text)
+		if len(clusters) != 3 {
+			t.Fatalf("expected 3 clusters, got %v", clusters)
+		}
+		ApplySpacing(test.glyphs, clusters, 1, 0)
+		for i, g := range test.glyphs {
+			if g.XAdvance != test.expected[i] {
+				t.Fatalf("glyph %d: expected advance %g, got %g", i, test.expected[i], g.XAdvance)
+			}
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package rasterizer
+
+import (
+	"container/list"
+	"math"
+	"sync"
+
+	"github.com/benoitkugler/textlayout/fonts"
+	"github.com/benoitkugler/textlayout/fonts/truetype"
+)
+
+// RenderMode identifies the kind of rendering stored in a GlyphCache.
+type RenderMode uint8
+
+const (
+	RenderGray RenderMode = iota // one coverage value per pixel
+	RenderLCD                    // three coverage values per pixel
+)
+
+// GlyphKey identifies a rendered glyph.
+// The variation coordinates of Font are automatically
+// added to the key by GlyphCache.Load.
+type GlyphKey struct {
+	Font    *truetype.Font // fonts are compared by pointer
+	GID     fonts.GID
+	Ppem    float32
+	Mode    RenderMode
+	Options RasterOptions
+
+	// SubpixelX is the fractional horizontal position of the glyph,
+	// in an unit chosen by the caller, or 0.
+	SubpixelX uint8
+
+	coords string // normalized variation coordinates of Font, see coordsKey
+}
+
+// coordsKey returns a comparable version of the coordinates of `font`,
+// since they may be changed between two calls.
+func coordsKey(font *truetype.Font) string {
+	if font == nil {
+		return ""
+	}
+	coords := font.VarCoordinates()
+	out := make([]byte, 4*len(coords))
+	for i, c := range coords {
+		bits := math.Float32bits(c)
+		out[4*i], out[4*i+1], out[4*i+2], out[4*i+3] = byte(bits>>24), byte(bits>>16), byte(bits>>8), byte(bits)
+	}
+	return string(out)
+}
+
+// Bitmap is a rendered glyph, as returned by Rasterize,
+// RasterizeLCD or a custom renderer.
+type Bitmap struct {
+	// Pix stores the pixels, row by row, whose format depends on the
+	// RenderMode, or are RGBA values if Color is true.
+	Pix                      []byte
+	Width, Height, Left, Top int
+
+	// Color is true for color glyphs (such as emojis),
+	// which are not rendered from outlines.
+	Color bool
+}
+
+type cacheEntry struct {
+	key    GlyphKey
+	bitmap Bitmap
+}
+
+// GlyphCache stores rendered glyphs, so that rasterizing the same glyph
+// several times is avoided. Once its capacity is reached, the least
+// recently used glyphs are evicted.
+// A GlyphCache is safe for concurrent use.
+type GlyphCache struct {
+	lock     sync.Mutex
+	capacity int // in bytes
+	size     int // in bytes
+	entries  map[GlyphKey]*list.Element
+	lru      list.List // front is most recently used, with values of type *cacheEntry
+}
+
+// NewGlyphCache returns an empty cache, storing at most
+// `capacity` bytes of pixels.
+func NewGlyphCache(capacity int) *GlyphCache {
+	return &GlyphCache{capacity: capacity, entries: make(map[GlyphKey]*list.Element)}
+}
+
+// Len returns the number of cached glyphs.
+func (c *GlyphCache) Len() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return len(c.entries)
+}
+
+// Size returns the number of bytes used by the cached glyphs.
+func (c *GlyphCache) Size() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.size
+}
+
+// Load returns the bitmap cached for `key`, or calls `render`
+// and stores its result.
+// The returned bitmap is shared and must not be modified.
+func (c *GlyphCache) Load(key GlyphKey, render func() Bitmap) Bitmap {
+	key.coords = coordsKey(key.Font)
+
+	c.lock.Lock()
+	if elem, ok := c.entries[key]; ok {
+		c.lru.MoveToFront(elem)
+		c.lock.Unlock()
+		return elem.Value.(*cacheEntry).bitmap
+	}
+	c.lock.Unlock()
+
+	// do not block other glyphs while rendering
+	bitmap := render()
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if _, ok := c.entries[key]; ok || len(bitmap.Pix) > c.capacity {
+		return bitmap
+	}
+	c.entries[key] = c.lru.PushFront(&cacheEntry{key: key, bitmap: bitmap})
+	c.size += len(bitmap.Pix)
+	for c.size > c.capacity {
+		last := c.lru.Back()
+		entry := last.Value.(*cacheEntry)
+		c.lru.Remove(last)
+		delete(c.entries, entry.key)
+		c.size -= len(entry.bitmap.Pix)
+	}
+	return bitmap
+}
+
+// Glyph is a cached version of RasterizeGlyph.
+func (c *GlyphCache) Glyph(font *truetype.Font, gid fonts.GID, ppem float32, options RasterOptions) Bitmap {
+	key := GlyphKey{Font: font, GID: gid, Ppem: ppem, Mode: RenderGray, Options: options}
+	return c.Load(key, func() (out Bitmap) {
+		out.Pix, out.Width, out.Height, out.Left, out.Top = RasterizeGlyph(font, gid, ppem, options)
+		return out
+	})
+}
+
+// GlyphLCD is a cached version of RasterizeGlyphLCD.
+func (c *GlyphCache) GlyphLCD(font *truetype.Font, gid fonts.GID, ppem float32, options RasterOptions) Bitmap {
+	key := GlyphKey{Font: font, GID: gid, Ppem: ppem, Mode: RenderLCD, Options: options}
+	return c.Load(key, func() (out Bitmap) {
+		out.Pix, out.Width, out.Height, out.Left, out.Top = RasterizeGlyphLCD(font, gid, ppem, options)
+		return out
+	})
+}
@@ -0,0 +1,122 @@
+package rasterizer
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/benoitkugler/textlayout/fonts"
+	"github.com/benoitkugler/textlayout/fonts/truetype"
+)
+
+func TestGlyphCache(t *testing.T) {
+	font := loadFont(t, "DejaVuSerif.ttf")
+	cache := NewGlyphCache(1 << 20)
+
+	for _, r := range "Hello" {
+		gid, _ := font.NominalGlyph(r)
+		for _, options := range []RasterOptions{{}, {Hinting: true}} {
+			alpha, w, h, left, top := RasterizeGlyph(font, gid, 16, options)
+			for range [2]int{} { // miss, then hit
+				bm := cache.Glyph(font, gid, 16, options)
+				if !bytes.Equal(bm.Pix, alpha) || bm.Width != w || bm.Height != h || bm.Left != left || bm.Top != top {
+					t.Fatalf("cached glyph %d differs from the uncached one", gid)
+				}
+			}
+
+			rgb, w, h, left, top := RasterizeGlyphLCD(font, gid, 16, options)
+			bm := cache.GlyphLCD(font, gid, 16, options)
+			if !bytes.Equal(bm.Pix, rgb) || bm.Width != w || bm.Height != h || bm.Left != left || bm.Top != top {
+				t.Fatalf("cached LCD glyph %d differs from the uncached one", gid)
+			}
+		}
+	}
+	// 'l' is repeated
+	if cache.Len() != 4*2*2 {
+		t.Fatalf("unexpected number of cached glyphs %d", cache.Len())
+	}
+}
+
+func TestGlyphCacheVariations(t *testing.T) {
+	font := loadFont(t, "Commissioner-VF.ttf")
+	cache := NewGlyphCache(1 << 20)
+	gid, _ := font.NominalGlyph('H')
+
+	truetype.SetVariations(font, []truetype.Variation{{Tag: truetype.MustNewTag("wght"), Value: 100}})
+	light := cache.Glyph(font, gid, 32, RasterOptions{})
+
+	truetype.SetVariations(font, []truetype.Variation{{Tag: truetype.MustNewTag("wght"), Value: 900}})
+	bold := cache.Glyph(font, gid, 32, RasterOptions{})
+	alpha, _, _, _, _ := RasterizeGlyph(font, gid, 32, RasterOptions{})
+	if !bytes.Equal(bold.Pix, alpha) || bytes.Equal(bold.Pix, light.Pix) {
+		t.Fatal("expected the glyph of the current instance")
+	}
+	if cache.Len() != 2 {
+		t.Fatalf("unexpected number of cached glyphs %d", cache.Len())
+	}
+}
+
+func TestGlyphCacheLoad(t *testing.T) {
+	cache := NewGlyphCache(10)
+	renders := 0
+	render := func(size int) func() Bitmap {
+		return func() Bitmap {
+			renders++
+			return Bitmap{Pix: make([]byte, size), Width: size, Height: 1}
+		}
+	}
+	key := func(gid fonts.GID) GlyphKey { return GlyphKey{GID: gid, Ppem: 12} }
+
+	cache.Load(key(1), render(4))
+	cache.Load(key(1), render(4))
+	if renders != 1 {
+		t.Fatalf("expected cache hit, got %d renders", renders)
+	}
+
+	cache.Load(key(2), render(4))
+	cache.Load(key(1), render(4)) // 1 is now more recently used than 2
+	cache.Load(key(3), render(4)) // evicts 2
+	if renders != 3 || cache.Len() != 2 || cache.Size() != 8 {
+		t.Fatalf("unexpected cache state: %d renders, %d glyphs, %d bytes", renders, cache.Len(), cache.Size())
+	}
+	cache.Load(key(1), render(4))
+	if renders != 3 {
+		t.Fatal("expected glyph 1 to be cached")
+	}
+	cache.Load(key(2), render(4))
+	if renders != 4 {
+		t.Fatal("expected glyph 2 to be evicted")
+	}
+
+	// too large to be stored
+	if bm := cache.Load(key(4), render(20)); len(bm.Pix) != 20 {
+		t.Fatal("expected rendered bitmap")
+	}
+	if cache.Size() > 10 || cache.Len() != 2 {
+		t.Fatalf("unexpected cache state: %d glyphs, %d bytes", cache.Len(), cache.Size())
+	}
+}
+
+func BenchmarkGlyphCache(b *testing.B) {
+	font := loadFont(b, "DejaVuSerif.ttf")
+	var gids []fonts.GID
+	for _, r := range "The quick brown fox jumps over the lazy dog" {
+		gid, _ := font.NominalGlyph(r)
+		gids = append(gids, gid)
+	}
+
+	b.Run("uncached", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, gid := range gids {
+				RasterizeGlyph(font, gid, 16, RasterOptions{})
+			}
+		}
+	})
+	b.Run("cached", func(b *testing.B) {
+		cache := NewGlyphCache(1 << 20)
+		for i := 0; i < b.N; i++ {
+			for _, gid := range gids {
+				cache.Glyph(font, gid, 16, RasterOptions{})
+			}
+		}
+	})
+}
@@ -0,0 +1,168 @@
+// Package draw composites glyphs onto images, so that
+// shaped text may be rendered with the standard image/draw package.
+//
+// Color bitmaps embedded in fonts (such as emojis) are decoded with image.Decode:
+// callers must register the decoders of the formats they expect,
+// typically by importing image/png (and, less often, image/jpeg or golang.org/x/image/tiff).
+// Bitmaps in an unregistered format are ignored.
+package draw
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+
+	"github.com/benoitkugler/textlayout/fonts"
+	"github.com/benoitkugler/textlayout/fonts/truetype"
+	"github.com/benoitkugler/textlayout/layout"
+	"github.com/benoitkugler/textlayout/rasterizer"
+	xdraw "golang.org/x/image/draw"
+)
+
+// subpixelSteps is the number of horizontal positions
+// at which a glyph is rendered, inside a pixel
+const subpixelSteps = 4
+
+// DrawGlyphs renders `glyphs` from `font` at `ppem` pixels per em,
+// and composites them onto `dst`. `origin` is the position in `dst` of the
+// origin of the run, that is the start of its baseline.
+// The glyphs are placed by accumulating their advances and adding their offsets,
+// as returned by a shaper (see layout.FromBuffer and layout.Shape).
+// Outlines are filled with `color`, whereas color bitmaps (such as emojis)
+// are copied as they are, scaled to `ppem`, provided their format
+// decoder is registered (see the package documentation).
+// Glyphs without data are ignored.
+// Glyph origins are aligned on quarters of pixel horizontally,
+// and on pixels vertically.
+// If not nil, `cache` is used to avoid rendering the same glyphs several times,
+// and may be shared between calls (see rasterizer.NewGlyphCache).
+func DrawGlyphs(dst draw.Image, glyphs []layout.PositionedGlyph, font *truetype.Font, origin image.Point, color color.Color, ppem float32,
+	cache *rasterizer.GlyphCache) {
+	scale := font.ScaleFactor(ppem)
+	src := image.NewUniform(color)
+	var penX, penY float32 // in font units, relative to the origin of the run
+	for _, glyph := range glyphs {
+		// glyph position in dst
+		x := float64(origin.X) + float64((penX+glyph.XOffset)*scale)
+		y := float64(origin.Y) - float64((penY+glyph.YOffset)*scale)
+		penX += glyph.XAdvance
+		penY += glyph.YAdvance
+		ix, iy := int(math.Floor(x)), int(math.Round(y))
+		subpixel := int(math.Round((x - math.Floor(x)) * subpixelSteps))
+		if subpixel == subpixelSteps {
+			ix, subpixel = ix+1, 0
+		}
+
+		key := rasterizer.GlyphKey{Font: font, GID: glyph.GID, Ppem: ppem, SubpixelX: uint8(subpixel)}
+		render := func() rasterizer.Bitmap { return renderGlyph(key) }
+		var bitmap rasterizer.Bitmap
+		if cache != nil {
+			bitmap = cache.Load(key, render)
+		} else {
+			bitmap = render()
+		}
+		if bitmap.Width == 0 || bitmap.Height == 0 {
+			continue
+		}
+
+		r := image.Rect(0, 0, bitmap.Width, bitmap.Height).Add(image.Pt(ix+bitmap.Left, iy-bitmap.Top))
+		if bitmap.Color {
+			img := &image.RGBA{Pix: bitmap.Pix, Stride: 4 * bitmap.Width, Rect: image.Rect(0, 0, bitmap.Width, bitmap.Height)}
+			draw.Draw(dst, r, img, image.Point{}, draw.Over)
+		} else {
+			mask := &image.Alpha{Pix: bitmap.Pix, Stride: bitmap.Width, Rect: image.Rect(0, 0, bitmap.Width, bitmap.Height)}
+			draw.DrawMask(dst, r, src, image.Point{}, mask, image.Point{}, draw.Over)
+		}
+	}
+}
+
+// renderGlyph returns the glyph bitmap, positioned relatively to the
+// pen position, with the Y axis increasing up
+func renderGlyph(key rasterizer.GlyphKey) rasterizer.Bitmap {
+	ppemInt := uint16(math.Round(float64(key.Ppem)))
+	switch data := key.Font.GlyphData(key.GID, ppemInt, ppemInt).(type) {
+	case fonts.GlyphOutline:
+		return renderOutline(key, data)
+	case fonts.GlyphSVG:
+		return renderOutline(key, data.Outline)
+	case fonts.GlyphBitmap:
+		extents, ok := key.Font.GlyphExtents(key.GID, ppemInt, ppemInt)
+		if !ok {
+			return rasterizer.Bitmap{}
+		}
+		return renderBitmap(data, extents, key.Font.ScaleFactor(key.Ppem))
+	}
+	return rasterizer.Bitmap{}
+}
+
+func renderOutline(key rasterizer.GlyphKey, outline fonts.GlyphOutline) (out rasterizer.Bitmap) {
+	// apply the sub-pixel position by shifting (a copy of) the outline
+	upem := key.Font.Upem()
+	dx := float32(key.SubpixelX) / subpixelSteps * float32(upem) / key.Ppem
+	outline.Segments = append([]fonts.Segment(nil), outline.Segments...)
+	for i := range outline.Segments {
+		args := outline.Segments[i].ArgsSlice()
+		for j := range args {
+			args[j].X += dx
+		}
+	}
+
+	out.Pix, out.Width, out.Height, out.Left, out.Top = rasterizer.Rasterize(outline, key.Ppem, rasterizer.RasterOptions{Upem: upem})
+	return out
+}
+
+// renderBitmap scales `bitmap` to the size given by `extents`
+func renderBitmap(bitmap fonts.GlyphBitmap, extents fonts.GlyphExtents, scale float32) (out rasterizer.Bitmap) {
+	var img image.Image
+	if bitmap.Format == fonts.BlackAndWhite {
+		// monochrome bitmaps are masks, filled with the text color
+		mask := blackAndWhiteMask(bitmap)
+		if mask == nil {
+			return out
+		}
+		img = mask
+	} else {
+		var err error
+		img, _, err = image.Decode(bytes.NewReader(bitmap.Data))
+		if err != nil {
+			return out
+		}
+		out.Color = true
+	}
+
+	out.Left = int(math.Round(float64(extents.XBearing * scale)))
+	out.Top = int(math.Round(float64(extents.YBearing * scale)))
+	out.Width = int(math.Round(float64(extents.Width * scale)))
+	out.Height = int(math.Round(math.Abs(float64(extents.Height * scale))))
+	if out.Width <= 0 || out.Height <= 0 {
+		return rasterizer.Bitmap{}
+	}
+
+	r := image.Rect(0, 0, out.Width, out.Height)
+	var dst draw.Image
+	if out.Color {
+		rgba := image.NewRGBA(r)
+		dst, out.Pix = rgba, rgba.Pix
+	} else {
+		alpha := image.NewAlpha(r)
+		dst, out.Pix = alpha, alpha.Pix
+	}
+	xdraw.ApproxBiLinear.Scale(dst, r, img, img.Bounds(), draw.Src, nil)
+	return out
+}
+
+// blackAndWhiteMask unpacks the bit aligned rows of `bitmap`
+func blackAndWhiteMask(bitmap fonts.GlyphBitmap) *image.Alpha {
+	if bitmap.Width <= 0 || bitmap.Height <= 0 || len(bitmap.Data)*8 < bitmap.Width*bitmap.Height {
+		return nil
+	}
+	mask := image.NewAlpha(image.Rect(0, 0, bitmap.Width, bitmap.Height))
+	for i := range mask.Pix {
+		if bitmap.Data[i/8]&(0x80>>(i%8)) != 0 {
+			mask.Pix[i] = 0xff
+		}
+	}
+	return mask
+}
@@ -0,0 +1,102 @@
+package draw
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+
+	testdata "github.com/benoitkugler/textlayout-testdata/truetype"
+	"github.com/benoitkugler/textlayout/fonts"
+	"github.com/benoitkugler/textlayout/fonts/truetype"
+	"github.com/benoitkugler/textlayout/harfbuzz"
+	"github.com/benoitkugler/textlayout/layout"
+	"github.com/benoitkugler/textlayout/rasterizer"
+)
+
+func loadFont(t *testing.T, filename string) *truetype.Font {
+	file, err := testdata.Files.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	font, err := truetype.Parse(bytes.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return font
+}
+
+// shape returns the glyphs of `text`, as positioned by harfbuzz
+func shape(font *truetype.Font, text string) []layout.PositionedGlyph {
+	buf := harfbuzz.NewBuffer()
+	runes := []rune(text)
+	buf.AddRunes(runes, 0, len(runes))
+	buf.GuessSegmentProperties()
+	buf.Shape(harfbuzz.NewFont(font), nil)
+	return layout.FromBuffer(buf)
+}
+
+func TestDrawGlyphs(t *testing.T) {
+	font := loadFont(t, "DejaVuSerif.ttf")
+	glyphs := shape(font, "Hi")
+
+	img := image.NewRGBA(image.Rect(0, 0, 60, 40))
+	DrawGlyphs(img, glyphs, font, image.Pt(5, 30), color.Black, 24, nil)
+
+	var inked, left, right int
+	for y := 0; y < 40; y++ {
+		for x := 0; x < 60; x++ {
+			if img.RGBAAt(x, y).A == 0 {
+				continue
+			}
+			inked++
+			if x < 22 {
+				left++
+			} else {
+				right++
+			}
+			if y > 30 {
+				t.Fatalf("unexpected ink below the baseline at (%d, %d)", x, y)
+			}
+		}
+	}
+	if left == 0 || right == 0 {
+		t.Fatalf("expected both glyphs to be drawn, got %d and %d inked pixels", left, right)
+	}
+}
+
+func TestBlackAndWhiteMask(t *testing.T) {
+	// 3x3 cross, bit aligned
+	bitmap := fonts.GlyphBitmap{Data: []byte{0x5d, 0x00}, Width: 3, Height: 3, Format: fonts.BlackAndWhite}
+	mask := blackAndWhiteMask(bitmap)
+	exp := []byte{0, 0xff, 0, 0xff, 0xff, 0xff, 0, 0xff, 0}
+	if !bytes.Equal(mask.Pix, exp) {
+		t.Fatalf("expected %v, got %v", exp, mask.Pix)
+	}
+
+	bitmap.Data = bitmap.Data[:1]
+	if blackAndWhiteMask(bitmap) != nil {
+		t.Fatal("expected nil mask for truncated data")
+	}
+}
+
+func TestDrawGlyphsCache(t *testing.T) {
+	font := loadFont(t, "DejaVuSerif.ttf")
+	glyphs := shape(font, "Hello, world")
+
+	draw := func(cache *rasterizer.GlyphCache) *image.RGBA {
+		img := image.NewRGBA(image.Rect(0, 0, 200, 40))
+		DrawGlyphs(img, glyphs, font, image.Pt(5, 30), color.Black, 17.5, cache)
+		return img
+	}
+
+	uncached := draw(nil)
+	cache := rasterizer.NewGlyphCache(1 << 20)
+	cachedMiss, cachedHit := draw(cache), draw(cache)
+	if cache.Len() == 0 {
+		t.Fatal("expected cached glyphs")
+	}
+	if !bytes.Equal(uncached.Pix, cachedMiss.Pix) || !bytes.Equal(uncached.Pix, cachedHit.Pix) {
+		t.Fatal("cached and uncached drawings differ")
+	}
+}
@@ -0,0 +1,86 @@
+package rasterizer
+
+import (
+	"github.com/benoitkugler/textlayout/fonts"
+	"github.com/benoitkugler/textlayout/fonts/truetype"
+)
+
+// SubpixelOrder is the physical layout of the color
+// components of a pixel, from left to right.
+type SubpixelOrder uint8
+
+const (
+	SubpixelRGB SubpixelOrder = iota
+	SubpixelBGR
+)
+
+// LCDFilter is a 5-tap FIR filter applied horizontally on subpixels.
+// Its weights should sum to 256 so that the total coverage is preserved.
+type LCDFilter [5]uint8
+
+var (
+	// LCDFilterLight is the FreeType light filter.
+	LCDFilterLight = LCDFilter{0x00, 0x55, 0x56, 0x55, 0x00}
+	// LCDFilterDefault is the FreeType default filter,
+	// which is stronger than LCDFilterLight.
+	LCDFilterDefault = LCDFilter{0x08, 0x4D, 0x56, 0x4D, 0x08}
+)
+
+// RasterizeLCD is the same as Rasterize, but renders with a 3x horizontal
+// resolution, suitable for LCD screens. It returns the coverage of
+// each color component of the `w` x `h` pixels, so that each row has
+// 3*`w` bytes, ordered as given by `options.Subpixel`.
+// The filter `options.LCDFilter` spreads the coverage over the neighbouring
+// subpixels : to receive it, the bitmap has an additional pixel
+// column on each side, compared to the box returned by Rasterize.
+func RasterizeLCD(outline fonts.GlyphOutline, ppem float32, options RasterOptions) (rgb []byte, w, h, left, top int) {
+	scale := ppem / options.upem()
+	left, top, w, h = pixelBounds(outline, scale, scale)
+	if w <= 0 || h <= 0 {
+		return nil, 0, 0, 0, 0
+	}
+	left, w = left-1, w+2
+
+	coverage := fill(outline, 3*scale, scale, 3*left, top, 3*w, h)
+
+	filter := options.LCDFilter
+	if filter == (LCDFilter{}) {
+		filter = LCDFilterLight
+	}
+	rgb = make([]byte, len(coverage))
+	stride := 3 * w
+	for y := 0; y < h; y++ {
+		filter.apply(rgb[y*stride:(y+1)*stride], coverage[y*stride:(y+1)*stride])
+	}
+
+	if options.Subpixel == SubpixelBGR {
+		for i := 0; i+2 < len(rgb); i += 3 {
+			rgb[i], rgb[i+2] = rgb[i+2], rgb[i]
+		}
+	}
+	return rgb, w, h, left, top
+}
+
+// RasterizeGlyphLCD is the same as RasterizeLCD, for the outline of the glyph `gid` of `font`.
+// See RasterizeGlyph for the handling of `options`.
+func RasterizeGlyphLCD(font *truetype.Font, gid fonts.GID, ppem float32, options RasterOptions) (rgb []byte, w, h, left, top int) {
+	options.Upem = font.Upem()
+	return RasterizeLCD(glyphOutline(font, gid, ppem, options), ppem, options)
+}
+
+// apply convolves `src` with the filter, writing into `dst`
+func (f LCDFilter) apply(dst, src []byte) {
+	for i := range dst {
+		var sum uint32
+		for k, weight := range f {
+			if j := i + k - 2; 0 <= j && j < len(src) {
+				sum += uint32(weight) * uint32(src[j])
+			}
+		}
+		sum = (sum + 0x80) >> 8
+		if sum > 0xff {
+			sum = 0xff
+		}
+		dst[i] = byte(sum)
+	}
+}
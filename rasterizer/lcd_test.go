@@ -0,0 +1,51 @@
+package rasterizer
+
+import (
+	"testing"
+
+	"github.com/benoitkugler/textlayout/fonts"
+)
+
+func sum(coverage []byte) (out int) {
+	for _, c := range coverage {
+		out += int(c)
+	}
+	return out
+}
+
+func TestRasterizeLCD(t *testing.T) {
+	outline := fonts.GlyphOutline{Segments: rectangle(30, -200, 470, 300)}
+	alpha, w, h, left, top := Rasterize(outline, 10, RasterOptions{})
+
+	for _, filter := range []LCDFilter{LCDFilterLight, LCDFilterDefault} {
+		rgb, lcdW, lcdH, lcdLeft, lcdTop := RasterizeLCD(outline, 10, RasterOptions{LCDFilter: filter})
+		if lcdW != w+2 || lcdH != h || lcdLeft != left-1 || lcdTop != top {
+			t.Fatalf("unexpected bitmap geometry %d %d %d %d", lcdW, lcdH, lcdLeft, lcdTop)
+		}
+		if len(rgb) != 3*lcdW*lcdH {
+			t.Fatalf("expected 3 subpixels per pixel, got %d bytes for %dx%d", len(rgb), lcdW, lcdH)
+		}
+
+		// the filter conserves energy, and the three channels
+		// together cover the same area as the grayscale pixels
+		exp, got := 3*sum(alpha), sum(rgb)
+		if diff := exp - got; diff < -len(rgb) || diff > len(rgb) {
+			t.Fatalf("energy not conserved: expected %d, got %d", exp, got)
+		}
+	}
+}
+
+func TestRasterizeLCDOrder(t *testing.T) {
+	// a shape covering only the left third of a pixel
+	outline := fonts.GlyphOutline{Segments: rectangle(0, 0, 33, 100)}
+	rgb, w, _, _, _ := RasterizeLCD(outline, 10, RasterOptions{})
+	bgr, _, _, _, _ := RasterizeLCD(outline, 10, RasterOptions{Subpixel: SubpixelBGR})
+	// skip the padding pixel
+	r, b := rgb[3], rgb[5]
+	if r <= b {
+		t.Fatalf("expected red to dominate, got %v", rgb[:3*w])
+	}
+	if bgr[3] != b || bgr[5] != r {
+		t.Fatalf("expected swapped channels, got %v and %v", rgb[:3*w], bgr[:3*w])
+	}
+}
@@ -0,0 +1,160 @@
+// Package rasterizer renders glyph outlines into anti-aliased
+// coverage bitmaps, which may then be composited onto images.
+//
+// The scanline fill is delegated to golang.org/x/image/vector.
+package rasterizer
+
+import (
+	"image"
+	"math"
+
+	"github.com/benoitkugler/textlayout/fonts"
+	"github.com/benoitkugler/textlayout/fonts/truetype"
+	"golang.org/x/image/vector"
+)
+
+// RasterOptions controls the rendering of outlines.
+type RasterOptions struct {
+	// Upem is the number of font units per em of the font
+	// providing the outline. If zero, 1000 is used.
+	Upem uint16
+
+	// Subpixel is the order of the color components of
+	// the screen pixels, used by RasterizeLCD.
+	Subpixel SubpixelOrder
+
+	// LCDFilter is the filter applied by RasterizeLCD to reduce
+	// color fringes. If zero, LCDFilterLight is used.
+	LCDFilter LCDFilter
+
+	// Hinting enables the execution of the TrueType instructions
+	// by RasterizeGlyph, which grid-fits the vertical features of the glyphs.
+	Hinting bool
+}
+
+func (opts RasterOptions) upem() float32 {
+	if opts.Upem == 0 {
+		return 1000
+	}
+	return float32(opts.Upem)
+}
+
+// Rasterize renders `outline` at `ppem` pixels per em, without hinting.
+// It returns the 8-bit coverage values of a `w` x `h` bitmap, stored row by
+// row, from top to bottom (0 is transparent, 255 is fully covered).
+// `left` and `top` give the position, in pixels, of the top-left corner
+// of the bitmap relative to the glyph origin, with the Y axis increasing up
+// (that is, `top` is usually positive for glyphs above the baseline).
+// An empty outline returns an empty bitmap.
+func Rasterize(outline fonts.GlyphOutline, ppem float32, options RasterOptions) (alpha []byte, w, h, left, top int) {
+	scale := ppem / options.upem()
+	return rasterize(outline, scale, scale)
+}
+
+// RasterizeGlyph is the same as Rasterize, for the outline of the glyph `gid` of `font`.
+// `options.Upem` is ignored and replaced by the font value.
+// If `options.Hinting` is true and `ppem` is an integer, the outline is first
+// grid-fitted (see truetype.Font.HintedGlyphOutline). When the glyph
+// can't be hinted, the unhinted outline is used.
+// Glyphs without outlines return an empty bitmap.
+func RasterizeGlyph(font *truetype.Font, gid fonts.GID, ppem float32, options RasterOptions) (alpha []byte, w, h, left, top int) {
+	options.Upem = font.Upem()
+	return Rasterize(glyphOutline(font, gid, ppem, options), ppem, options)
+}
+
+// glyphOutline returns the outline to render, hinted if needed and possible
+func glyphOutline(font *truetype.Font, gid fonts.GID, ppem float32, options RasterOptions) fonts.GlyphOutline {
+	if ppemInt := uint16(ppem); options.Hinting && float32(ppemInt) == ppem {
+		if outline, err := font.HintedGlyphOutline(gid, ppemInt); err == nil {
+			return outline
+		}
+	}
+
+	switch data := font.GlyphData(gid, uint16(ppem), uint16(ppem)).(type) {
+	case fonts.GlyphOutline:
+		return data
+	case fonts.GlyphSVG:
+		return data.Outline
+	}
+	return fonts.GlyphOutline{}
+}
+
+// rasterize applies independent horizontal and vertical scales
+func rasterize(outline fonts.GlyphOutline, scaleX, scaleY float32) (alpha []byte, w, h, left, top int) {
+	left, top, w, h = pixelBounds(outline, scaleX, scaleY)
+	if w <= 0 || h <= 0 {
+		return nil, 0, 0, 0, 0
+	}
+	return fill(outline, scaleX, scaleY, left, top, w, h), w, h, left, top
+}
+
+// pixelBounds returns the smallest pixel box enclosing the scaled outline
+func pixelBounds(outline fonts.GlyphOutline, scaleX, scaleY float32) (left, top, w, h int) {
+	if len(outline.Segments) == 0 {
+		return 0, 0, 0, 0
+	}
+
+	// the control points enclose the curves
+	minX, minY := float32(math.Inf(+1)), float32(math.Inf(+1))
+	maxX, maxY := float32(math.Inf(-1)), float32(math.Inf(-1))
+	for i := range outline.Segments {
+		for _, pt := range outline.Segments[i].ArgsSlice() {
+			x, y := pt.X*scaleX, pt.Y*scaleY
+			minX, maxX = min32(minX, x), max32(maxX, x)
+			minY, maxY = min32(minY, y), max32(maxY, y)
+		}
+	}
+
+	left, top = int(math.Floor(float64(minX))), int(math.Ceil(float64(maxY)))
+	right, bottom := int(math.Ceil(float64(maxX))), int(math.Floor(float64(minY)))
+	return left, top, right - left, top - bottom
+}
+
+// fill renders the scaled outline into the `w` x `h` box
+// whose top-left corner is at (`left`, `top`)
+func fill(outline fonts.GlyphOutline, scaleX, scaleY float32, left, top, w, h int) []byte {
+	// convert to the bitmap space, where the Y axis increases down
+	fLeft, fTop := float32(left), float32(top)
+	point := func(pt fonts.SegmentPoint) (float32, float32) {
+		return pt.X*scaleX - fLeft, fTop - pt.Y*scaleY
+	}
+
+	r := vector.NewRasterizer(w, h)
+	for _, seg := range outline.Segments {
+		switch seg.Op {
+		case fonts.SegmentOpMoveTo:
+			r.ClosePath()
+			r.MoveTo(point(seg.Args[0]))
+		case fonts.SegmentOpLineTo:
+			r.LineTo(point(seg.Args[0]))
+		case fonts.SegmentOpQuadTo:
+			bx, by := point(seg.Args[0])
+			cx, cy := point(seg.Args[1])
+			r.QuadTo(bx, by, cx, cy)
+		case fonts.SegmentOpCubeTo:
+			bx, by := point(seg.Args[0])
+			cx, cy := point(seg.Args[1])
+			dx, dy := point(seg.Args[2])
+			r.CubeTo(bx, by, cx, cy, dx, dy)
+		}
+	}
+	r.ClosePath()
+
+	dst := image.NewAlpha(image.Rect(0, 0, w, h))
+	r.Draw(dst, dst.Bounds(), image.Opaque, image.Point{})
+	return dst.Pix
+}
+
+func min32(a, b float32) float32 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max32(a, b float32) float32 {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,137 @@
+package rasterizer
+
+import (
+	"bytes"
+	"testing"
+
+	testdata "github.com/benoitkugler/textlayout-testdata/truetype"
+	"github.com/benoitkugler/textlayout/fonts"
+	"github.com/benoitkugler/textlayout/fonts/truetype"
+)
+
+func loadFont(t testing.TB, filename string) *truetype.Font {
+	file, err := testdata.Files.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	font, err := truetype.Parse(bytes.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return font
+}
+
+// rectangle returns a closed contour with corners (x0, y0) and (x1, y1)
+func rectangle(x0, y0, x1, y1 float32) []fonts.Segment {
+	pt := func(x, y float32) [3]fonts.SegmentPoint { return [3]fonts.SegmentPoint{{X: x, Y: y}} }
+	return []fonts.Segment{
+		{Op: fonts.SegmentOpMoveTo, Args: pt(x0, y0)},
+		{Op: fonts.SegmentOpLineTo, Args: pt(x1, y0)},
+		{Op: fonts.SegmentOpLineTo, Args: pt(x1, y1)},
+		{Op: fonts.SegmentOpLineTo, Args: pt(x0, y1)},
+		{Op: fonts.SegmentOpLineTo, Args: pt(x0, y0)},
+	}
+}
+
+func TestRasterizeEmpty(t *testing.T) {
+	alpha, w, h, _, _ := Rasterize(fonts.GlyphOutline{}, 12, RasterOptions{})
+	if alpha != nil || w != 0 || h != 0 {
+		t.Fatalf("expected empty bitmap, got %dx%d", w, h)
+	}
+}
+
+func TestRasterizeRectangle(t *testing.T) {
+	// 500 units at 10 ppem (upem 1000) is 5 pixels, above the baseline
+	outline := fonts.GlyphOutline{Segments: rectangle(0, 0, 500, 500)}
+	alpha, w, h, left, top := Rasterize(outline, 10, RasterOptions{})
+	if w != 5 || h != 5 || left != 0 || top != 5 {
+		t.Fatalf("unexpected bitmap geometry %d %d %d %d", w, h, left, top)
+	}
+	if !bytes.Equal(alpha, bytes.Repeat([]byte{0xff}, 25)) {
+		t.Fatalf("expected full coverage, got %v", alpha)
+	}
+
+	// a vertical edge in the middle of a pixel gives half coverage
+	outline = fonts.GlyphOutline{Segments: rectangle(-100, -200, 250, 200)}
+	alpha, w, h, left, top = Rasterize(outline, 10, RasterOptions{})
+	if w != 4 || h != 4 || left != -1 || top != 2 {
+		t.Fatalf("unexpected bitmap geometry %d %d %d %d", w, h, left, top)
+	}
+	for y := 0; y < h; y++ {
+		row := alpha[y*w : (y+1)*w]
+		if row[0] != 0xff || row[2] != 0xff {
+			t.Fatalf("expected full coverage at row %d, got %v", y, row)
+		}
+		if c := row[3]; c < 0x7e || c > 0x81 {
+			t.Fatalf("expected half coverage at row %d, got %d", y, c)
+		}
+	}
+}
+
+func TestRasterizeUpem(t *testing.T) {
+	outline := fonts.GlyphOutline{Segments: rectangle(0, 0, 1024, 512)}
+	_, w, h, _, _ := Rasterize(outline, 16, RasterOptions{Upem: 2048})
+	if w != 8 || h != 4 {
+		t.Fatalf("unexpected bitmap size %dx%d", w, h)
+	}
+}
+
+func TestRasterizeGlyph(t *testing.T) {
+	font := loadFont(t, "DejaVuSerif.ttf")
+	gid, _ := font.NominalGlyph('o')
+	outline, ok := font.GlyphData(gid, 0, 0).(fonts.GlyphOutline)
+	if !ok {
+		t.Fatal("missing outline")
+	}
+
+	alpha, w, h, left, top := Rasterize(outline, 32, RasterOptions{Upem: font.Upem()})
+	if w == 0 || h == 0 || len(alpha) != w*h {
+		t.Fatalf("unexpected bitmap size %dx%d", w, h)
+	}
+	if left < 0 || top <= 0 {
+		t.Fatalf("unexpected bitmap position %d %d", left, top)
+	}
+	// the counter of the 'o' is empty, its stroke is covered
+	if c := alpha[(h/2)*w+w/2]; c != 0 {
+		t.Fatalf("expected empty counter, got %d", c)
+	}
+	if c := alpha[(h/2)*w+1]; c != 0xff {
+		t.Fatalf("expected covered stroke, got %d", c)
+	}
+}
+
+// isSharp returns true if every coverage value in the column `x` is either 0 or 255
+func isSharp(alpha []byte, w, h, x int) bool {
+	for y := 0; y < h; y++ {
+		if c := alpha[y*w+x]; c != 0 && c != 0xff {
+			return false
+		}
+	}
+	return true
+}
+
+func TestRasterizeGlyphHinting(t *testing.T) {
+	font := loadFont(t, "DejaVuSerif.ttf")
+	gid, _ := font.NominalGlyph('H')
+
+	// at 13 ppem, the cap height of DejaVuSerif is 9.48 pixels :
+	// the horizontal edges of the stems are blurred without hinting
+	alpha, w, h, _, _ := RasterizeGlyph(font, gid, 13, RasterOptions{})
+	if isSharp(alpha, w, h, w/2) {
+		t.Fatal("expected blurred edges without hinting")
+	}
+	alpha, w, h, _, top := RasterizeGlyph(font, gid, 13, RasterOptions{Hinting: true})
+	if !isSharp(alpha, w, h, w/2) {
+		t.Fatal("expected sharp edges with hinting")
+	}
+	if top != 9 {
+		t.Fatalf("expected cap height on the pixel grid, got %d", top)
+	}
+
+	// fractional sizes are not hinted
+	hinted, _, _, _, _ := RasterizeGlyph(font, gid, 13.5, RasterOptions{Hinting: true})
+	unhinted, _, _, _, _ := RasterizeGlyph(font, gid, 13.5, RasterOptions{})
+	if !bytes.Equal(hinted, unhinted) {
+		t.Fatal("expected unhinted rendering for fractional ppem")
+	}
+}